@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hotel-california-backend/configs/envvars"
+	"hotel-california-backend/internal/events"
+	"hotel-california-backend/internal/outbox"
+)
+
+// busPublisher is the name of the in-process bus among the publishers
+const busPublisher = "bus"
+
+// newPublishers returns the publishers the outbox relay publishes through, events are published on the bus and
+// through the configured publishers. closeAll closes what the publishers opened.
+func newPublishers(ev *envvars.EnvVars, bus *events.Bus) (ps []outbox.Publisher, closeAll func() error, err error) {
+	ps = []outbox.Publisher{{Name: busPublisher, Publisher: bus}}
+	var closers []func() error
+
+	closeAll = func() error {
+		var errs []error
+		for _, c := range closers {
+			errs = append(errs, c())
+		}
+
+		return errors.Join(errs...)
+	}
+
+	for _, name := range ev.Outbox.Publishers {
+		switch name {
+		case envvars.OutboxPublisherFile:
+			fp, err := events.NewFilePublisher(ev.Outbox.FilePath)
+			if err != nil {
+				_ = closeAll()
+				return nil, nil, err
+			}
+
+			ps = append(ps, outbox.Publisher{Name: name, Publisher: fp})
+			closers = append(closers, fp.Close)
+		case envvars.OutboxPublisherHTTP:
+			if ev.Outbox.HTTPURL == "" {
+				_ = closeAll()
+				return nil, nil, errors.New("OUTBOX_HTTP_URL is required by the http publisher")
+			}
+
+			ps = append(ps, outbox.Publisher{Name: name, Publisher: events.NewHTTPPublisher(ev.Outbox.HTTPURL, ev.Outbox.HTTPTimeout)})
+		default:
+			_ = closeAll()
+			return nil, nil, fmt.Errorf("unknown outbox publisher %q", name)
+		}
+	}
+
+	return ps, closeAll, nil
+}
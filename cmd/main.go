@@ -4,12 +4,25 @@ import (
 	"context"
 	"errors"
 	"github.com/go-kit/kit/log"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+	"hotel-california-backend/internal/events"
 	"hotel-california-backend/internal/localization"
-	mysqlstore "hotel-california-backend/internal/store/mysql"
+	"hotel-california-backend/internal/logging"
+	"hotel-california-backend/internal/metrics"
+	"hotel-california-backend/internal/notification"
+	"hotel-california-backend/internal/outbox"
+	"hotel-california-backend/internal/scheduler"
+	"hotel-california-backend/internal/store"
+	"hotel-california-backend/internal/tracing"
+	"hotel-california-backend/internal/webhook"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"hotel-california-backend/configs/envvars"
 	"hotel-california-backend/internal/middleware"
@@ -17,13 +30,19 @@ import (
 
 	hotelcalifornia "hotel-california-backend"
 	authmiddlaware "hotel-california-backend/internal/middleware/auth"
+	loggingmiddleware "hotel-california-backend/internal/middleware/logging"
+	metricsmiddleware "hotel-california-backend/internal/middleware/metrics"
+	tracingmiddleware "hotel-california-backend/internal/middleware/tracing"
 	httptransport "hotel-california-backend/internal/transport/http"
 )
 
+const metricsNamespace = "hotel_california"
+
 func main() {
 	var l log.Logger
 	{
 		l = log.NewLogfmtLogger(os.Stdout)
+		l = logging.NewRedactingLogger(l)
 		l = log.With(l, "time", log.DefaultTimestampUTC)
 	}
 
@@ -37,26 +56,121 @@ func main() {
 		}
 	}
 
-	var ps mysqlstore.Store
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err = runMigrate(l, ev, os.Args[2:]); err != nil {
+			_ = l.Log("command", "migrate", "error", err.Error())
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	var shutdownTracing func(context.Context) error
+	{
+		shutdownTracing, err = tracing.Setup(context.Background(), ev.Tracing, ev.Service.Environment)
+		if err != nil {
+			_ = l.Log("error", err.Error())
+			return
+		}
+	}
+
+	var qm *store.QueryMetrics
+	{
+		qm = store.NewQueryMetrics(kitprometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "store",
+			Name:      "query_duration_seconds",
+			Help:      "Duration of database queries in seconds.",
+			Buckets:   stdprometheus.DefBuckets,
+		}, []string{"operation", "table", "success"}))
+	}
+
+	var ps store.Store
+	{
+		ps, err = newStore(ev, []gorm.Plugin{qm, store.NewQueryTracing()})
+
+		if err != nil {
+			_ = l.Log("error", err.Error())
+			return
+		}
+
+		stdprometheus.MustRegister(metrics.NewDBStatsCollector(metricsNamespace, ps.Stats))
+	}
+
+	var bus *events.Bus
+	var relay *outbox.Relay
+	var closePublisher func() error
+	{
+		var publishers []outbox.Publisher
+
+		bus = events.NewBus()
+
+		publishers, closePublisher, err = newPublishers(ev, bus)
+		if err != nil {
+			_ = l.Log("error", err.Error())
+			return
+		}
+
+		relay = outbox.NewRelay(log.With(l, "component", "outbox"), ps, publishers, outbox.Options{
+			Interval:    ev.Outbox.Interval,
+			BatchSize:   ev.Outbox.BatchSize,
+			Retention:   ev.Outbox.Retention,
+			MaxAttempts: ev.Outbox.MaxAttempts,
+		})
+	}
+
+	var dispatcher *webhook.Dispatcher
 	{
-		ps, err = mysqlstore.NewStore(mysqlstore.Options{
-			UserName:       ev.MySql.UserName,
-			Password:       ev.MySql.Password,
-			URI:            ev.MySql.URI,
-			Database:       ev.MySql.Database,
-			Port:           ev.MySql.Port,
-			ConnectTimeout: ev.MySql.ConnectTimeout,
+		dispatcher = webhook.NewDispatcher(log.With(l, "component", "webhook"), ps, webhook.Options{
+			Interval:    ev.Webhook.Interval,
+			BatchSize:   ev.Webhook.BatchSize,
+			Timeout:     ev.Webhook.Timeout,
+			MaxAttempts: ev.Webhook.MaxAttempts,
+			Backoff:     ev.Webhook.Backoff,
+			MaxBackoff:  ev.Webhook.MaxBackoff,
 		})
 
+		bus.Subscribe(dispatcher.Handle)
+	}
+
+	var notifier *notification.Notifier
+	{
+		var sender notification.Sender
+
+		sender, err = newSender(ev)
 		if err != nil {
 			_ = l.Log("error", err.Error())
 			return
 		}
+
+		if sender != nil {
+			notifier = notification.NewNotifier(log.With(l, "component", "notification"), ps, sender, notification.Options{
+				From:        ev.Notification.From,
+				QueueSize:   ev.Notification.QueueSize,
+				Workers:     ev.Notification.Workers,
+				MaxAttempts: ev.Notification.MaxAttempts,
+				Interval:    ev.Notification.Interval,
+				Lease:       ev.Notification.Lease,
+			})
+
+			bus.Subscribe(notifier.Handle)
+		}
 	}
 
+	var sch *scheduler.Scheduler
+	{
+		sch, err = newScheduler(log.With(l, "component", "scheduler"), ev, ps)
+		if err != nil {
+			_ = l.Log("error", err.Error())
+			return
+		}
+	}
+
+	var ss *service.Service
 	var s hotelcalifornia.Service
 	{
-		s = service.NewService(ev.Service.Environment, l, ps, ev.JWTToken)
+		ss = service.NewService(ev.Service.Environment, l, ps, ev.JWTToken, ev.Reservation)
+		s = ss
 	}
 
 	var am middleware.Middleware
@@ -66,9 +180,53 @@ func main() {
 		s = am(s)
 	}
 
+	var lm middleware.Middleware
+	{
+		lm = loggingmiddleware.NewLoggingMiddleware(log.With(l, "middleware", "logging"))
+
+		s = lm(s)
+	}
+
+	var mm middleware.Middleware
+	{
+		mm = metricsmiddleware.NewMetricsMiddleware(
+			kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Subsystem: "service",
+				Name:      "requests_total",
+				Help:      "Total number of requests by method.",
+			}, []string{"method"}),
+			kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Subsystem: "service",
+				Name:      "errors_total",
+				Help:      "Total number of failed requests by method and api error name.",
+			}, []string{"method", "error"}),
+			kitprometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+				Namespace: metricsNamespace,
+				Subsystem: "service",
+				Name:      "request_duration_seconds",
+				Help:      "Duration of requests in seconds.",
+				Buckets:   stdprometheus.DefBuckets,
+			}, []string{"method", "success"}),
+		)
+
+		s = mm(s)
+	}
+
+	var tm middleware.Middleware
+	{
+		tm = tracingmiddleware.NewTracingMiddleware()
+
+		s = tm(s)
+	}
+
 	var h http.Handler
 	{
-		h = httptransport.MakeHTTPHandler(log.With(l, "transport", "http"), s)
+		// the proxies are validated when the environment variables are loaded
+		proxies, _ := ev.HTTPServer.TrustedProxyNetworks()
+
+		h = httptransport.MakeHTTPHandler(log.With(l, "transport", "http"), s, proxies)
 	}
 
 	var hs *http.Server
@@ -83,6 +241,14 @@ func main() {
 		}
 	}
 
+	var as *http.Server
+	{
+		as = &http.Server{
+			Addr:    ev.AdminServer.Address,
+			Handler: httptransport.MakeAdminHandler(),
+		}
+	}
+
 	err = localization.InitializeBundle(ev.Localization)
 	if err != nil {
 		_ = l.Log("error", err.Error())
@@ -96,6 +262,39 @@ func main() {
 		errs <- errors.New((<-c).String())
 	}()
 
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+
+		relay.Run(workersCtx)
+	}()
+
+	go func() {
+		defer workers.Done()
+
+		dispatcher.Run(workersCtx)
+	}()
+
+	if notifier != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+
+			notifier.Run(workersCtx)
+		}()
+	}
+
+	if sch != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+
+			sch.Run(workersCtx)
+		}()
+	}
+
 	go func() {
 		_ = l.Log("transport", "http", "address", ev.HTTPServer.Address)
 
@@ -106,9 +305,25 @@ func main() {
 		}
 	}()
 
+	go func() {
+		_ = l.Log("transport", "admin", "address", ev.AdminServer.Address)
+
+		err := as.ListenAndServe()
+
+		if !errors.Is(http.ErrServerClosed, err) {
+			errs <- err
+		}
+	}()
+
 	err = <-errs
 	_ = l.Log("error", err.Error())
 
+	// readiness fails from now on, the delay gives the load balancer time to stop routing new requests to the instance
+	// before the servers stop accepting them
+	ss.BeginShutdown()
+
+	time.Sleep(ev.HTTPServer.DrainDelay)
+
 	ctx, cf := context.WithTimeout(context.Background(), ev.HTTPServer.ShutdownTimeout)
 
 	defer cf()
@@ -117,7 +332,29 @@ func main() {
 		_ = l.Log("error", err.Error())
 	}
 
+	if err = as.Shutdown(ctx); err != nil {
+		_ = l.Log("error", err.Error())
+	}
+
+	// the relay, the webhook dispatcher, the notifier and the scheduler are stopped before the store they read from is
+	// closed, pending events, deliveries and jobs are picked up on the next start
+	stopWorkers()
+	workers.Wait()
+
+	// emails still queued are sent within the shutdown timeout, the rest are sent after the next start
+	if notifier != nil {
+		notifier.Drain(ctx)
+	}
+
+	if err = closePublisher(); err != nil {
+		_ = l.Log("error", err.Error())
+	}
+
 	if err = ps.Close(); err != nil {
 		_ = l.Log("error", err.Error())
 	}
+
+	if err = shutdownTracing(ctx); err != nil {
+		_ = l.Log("error", err.Error())
+	}
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-kit/kit/log"
+	"hotel-california-backend/configs/envvars"
+	"hotel-california-backend/internal/migrate"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down | status | to <version>"
+
+// runMigrate runs the migrate subcommand against the configured database
+func runMigrate(l log.Logger, ev *envvars.EnvVars, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	m, db, err := openMigrator(ev)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	ctx := context.Background()
+
+	var run []*migrate.Migration
+
+	switch args[0] {
+	case "up":
+		run, err = m.Up(ctx)
+	case "down":
+		run, err = m.Down(ctx)
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+
+		version, perr := strconv.ParseInt(args[1], 10, 64)
+		if perr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+
+		run, err = m.To(ctx, version)
+	case "status":
+		return migrateStatus(ctx, l, m)
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	for _, mg := range run {
+		_ = l.Log("command", "migrate", "action", args[0], "version", mg.Version, "name", mg.Name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	_ = l.Log("command", "migrate", "version", version)
+
+	return nil
+}
+
+func migrateStatus(ctx context.Context, l log.Logger, m *migrate.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range statuses {
+		keyvals := []interface{}{"command", "migrate", "version", st.Version, "name", st.Name, "applied", st.Applied}
+		if st.Applied {
+			keyvals = append(keyvals, "applied_at", st.AppliedAt)
+		}
+
+		if st.ChecksumMismatch {
+			keyvals = append(keyvals, "checksum_mismatch", true)
+		}
+
+		_ = l.Log(keyvals...)
+	}
+
+	return nil
+}
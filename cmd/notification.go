@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"hotel-california-backend/configs/envvars"
+	"hotel-california-backend/internal/notification"
+)
+
+// newSender returns the sender guests are emailed with, nil when notifications are disabled
+func newSender(ev *envvars.EnvVars) (notification.Sender, error) {
+	switch ev.Notification.Sender {
+	case envvars.NotificationSenderNone:
+		return nil, nil
+	case envvars.NotificationSenderSMTP:
+		return notification.NewSMTPSender(
+			ev.Notification.SMTPHost,
+			ev.Notification.SMTPPort,
+			ev.Notification.SMTPUserName,
+			ev.Notification.SMTPPassword,
+			ev.Notification.SMTPTimeout,
+		), nil
+	case envvars.NotificationSenderFile:
+		return notification.NewFileSender(ev.Notification.FileDirectory)
+	default:
+		return nil, fmt.Errorf("unknown notification sender %q", ev.Notification.Sender)
+	}
+}
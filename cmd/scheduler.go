@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/go-kit/kit/log"
+	"hotel-california-backend/configs/envvars"
+	"hotel-california-backend/internal/scheduler"
+	"hotel-california-backend/internal/store"
+	"time"
+)
+
+// newScheduler returns the scheduler of the background jobs, nil when the scheduler is disabled. Reservations are
+// expired even when holds are turned off, so that the holds made before are not kept forever.
+func newScheduler(l log.Logger, ev *envvars.EnvVars, ms store.Store) (*scheduler.Scheduler, error) {
+	if !ev.Scheduler.Enabled {
+		return nil, nil
+	}
+
+	s := scheduler.NewScheduler(l, ms, scheduler.Options{
+		Interval:   ev.Scheduler.Interval,
+		LockTTL:    ev.Scheduler.LockTTL,
+		RetryDelay: ev.Scheduler.RetryDelay,
+	})
+
+	if ev.Reservation.ReminderDays > 0 {
+		lead := time.Duration(ev.Reservation.ReminderDays) * 24 * time.Hour
+
+		if err := s.Add(scheduler.NewReminderJob(ev.Scheduler.ReminderSchedule, ms, lead)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.Add(scheduler.NewExpiryJob(ev.Scheduler.ExpirySchedule, ms)); err != nil {
+		return nil, err
+	}
+
+	penalty := scheduler.Penalty{Amount: ev.Reservation.NoShowPenalty, Currency: ev.Reservation.Currency}
+
+	if err := s.Add(scheduler.NewStayJob(ev.Scheduler.StaySchedule, ms, ev.Reservation.NoShowCutoff, penalty)); err != nil {
+		return nil, err
+	}
+
+	if err := s.Add(scheduler.NewIdempotencyKeyCleanupJob(ev.Scheduler.IdempotencySchedule, ms)); err != nil {
+		return nil, err
+	}
+
+	if err := s.Add(scheduler.NewNotificationCleanupJob(ev.Scheduler.NotificationSchedule, ms, ev.Notification.Retention)); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"gorm.io/gorm"
+	"hotel-california-backend/configs/envvars"
+	"hotel-california-backend/internal/migrate"
+	"hotel-california-backend/internal/store"
+	mysqlstore "hotel-california-backend/internal/store/mysql"
+	postgresstore "hotel-california-backend/internal/store/postgres"
+	sqlitestore "hotel-california-backend/internal/store/sqlite"
+)
+
+// newStore creates and returns the store of the configured driver
+func newStore(ev *envvars.EnvVars, plugins []gorm.Plugin) (store.Store, error) {
+	switch ev.Store.Driver {
+	case envvars.StoreDriverPostgres:
+		return postgresstore.NewStore(postgresOptions(ev, plugins))
+	case envvars.StoreDriverSQLite:
+		return sqlitestore.NewStore(sqliteOptions(ev, plugins))
+	}
+
+	return mysqlstore.NewStore(mysqlOptions(ev, plugins))
+}
+
+// openMigrator opens the configured database and returns its migrator, the database has to be closed by the caller
+func openMigrator(ev *envvars.EnvVars) (*migrate.Migrator, *sql.DB, error) {
+	var db *sql.DB
+	var err error
+	var d store.Dialect
+
+	switch ev.Store.Driver {
+	case envvars.StoreDriverPostgres:
+		db, err = postgresstore.OpenDB(postgresOptions(ev, nil))
+		d = postgresstore.Dialect
+	case envvars.StoreDriverSQLite:
+		db, err = sqlitestore.OpenDB(sqliteOptions(ev, nil))
+		d = sqlitestore.Dialect
+	default:
+		db, err = mysqlstore.OpenDB(mysqlOptions(ev, nil))
+		d = mysqlstore.Dialect
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return migrate.NewMigrator(db, d.Migrations, d.Migrate), db, nil
+}
+
+func mysqlOptions(ev *envvars.EnvVars, plugins []gorm.Plugin) store.Options {
+	return store.Options{
+		UserName:              ev.MySql.UserName,
+		Password:              ev.MySql.Password,
+		URI:                   ev.MySql.URI,
+		Database:              ev.MySql.Database,
+		Port:                  ev.MySql.Port,
+		ConnectTimeout:        ev.MySql.ConnectTimeout,
+		PingTimeout:           ev.MySql.PingTimeout,
+		ReadTimeout:           ev.MySql.ReadTimeout,
+		WriteTimeout:          ev.MySql.WriteTimeout,
+		DisconnectTimeout:     ev.MySql.DisconnectTimeout,
+		MaxOpenConns:          ev.MySql.MaxOpenConns,
+		MaxIdleConns:          ev.MySql.MaxIdleConns,
+		ConnMaxLifetime:       ev.MySql.ConnMaxLifetime,
+		ConnMaxIdleTime:       ev.MySql.ConnMaxIdleTime,
+		ConnectRetries:        ev.MySql.ConnectRetries,
+		ConnectRetryBackoff:   ev.MySql.ConnectRetryBackoff,
+		Replicas:              ev.MySql.Replicas,
+		ReplicaHealthInterval: ev.MySql.ReplicaHealthInterval,
+		ReplicaStickiness:     ev.MySql.ReplicaStickiness,
+		Plugins:               plugins,
+	}
+}
+
+func postgresOptions(ev *envvars.EnvVars, plugins []gorm.Plugin) store.Options {
+	return store.Options{
+		UserName:              ev.Postgres.UserName,
+		Password:              ev.Postgres.Password,
+		URI:                   ev.Postgres.URI,
+		Database:              ev.Postgres.Database,
+		Port:                  ev.Postgres.Port,
+		SSLMode:               ev.Postgres.SSLMode,
+		ConnectTimeout:        ev.Postgres.ConnectTimeout,
+		PingTimeout:           ev.Postgres.PingTimeout,
+		Replicas:              ev.Postgres.Replicas,
+		ReplicaHealthInterval: ev.Postgres.ReplicaHealthInterval,
+		ReplicaStickiness:     ev.Postgres.ReplicaStickiness,
+		Plugins:               plugins,
+	}
+}
+
+func sqliteOptions(ev *envvars.EnvVars, plugins []gorm.Plugin) sqlitestore.Options {
+	return sqlitestore.Options{
+		Path:        ev.SQLite.Path,
+		BusyTimeout: ev.SQLite.BusyTimeout,
+		Plugins:     plugins,
+	}
+}
@@ -3,6 +3,9 @@ package envvars
 import (
 	"fmt"
 	"github.com/codingconcepts/env"
+	"hotel-california-backend/internal/encryption"
+	"net"
+	"strings"
 	"time"
 )
 
@@ -10,9 +13,19 @@ import (
 type EnvVars struct {
 	Service      Service
 	HTTPServer   HTTPServer
+	AdminServer  AdminServer
+	Store        Store
 	MySql        MySql
+	Postgres     Postgres
+	SQLite       SQLite
 	Localization Localization
 	JWTToken     JWTToken
+	Reservation  Reservation
+	Tracing      Tracing
+	Outbox       Outbox
+	Webhook      Webhook
+	Notification Notification
+	Scheduler    Scheduler
 }
 
 // Service represents service configurations
@@ -28,16 +41,103 @@ type HTTPServer struct {
 	IdleTimeout     time.Duration `env:"HTTP_SERVER_IDLE_TIMEOUT" default:"15s"`
 	MaxHeaderBytes  int           `env:"HTTP_SERVER_MAX_HEADER_BYTES" default:"1048576"`
 	ShutdownTimeout time.Duration `env:"HTTP_SERVER_SHUTDOWN_TIMEOUT" default:"10s"`
+	DrainDelay      time.Duration `env:"HTTP_SERVER_DRAIN_DELAY" default:"5s"`
+	TrustedProxies  []string      `env:"HTTP_SERVER_TRUSTED_PROXIES"`
+}
+
+// TrustedProxyNetworks returns the networks of the trusted proxies, a proxy is given as a cidr or a single address
+func (hs HTTPServer) TrustedProxyNetworks() ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(hs.TrustedProxies))
+
+	for _, proxy := range hs.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// AdminServer represents admin http server configurations, it serves metrics apart from the public api
+type AdminServer struct {
+	Address string `env:"ADMIN_SERVER_ADDRESS" default:":9090"`
+}
+
+// store drivers
+const (
+	StoreDriverMySQL    = "mysql"
+	StoreDriverPostgres = "postgres"
+	StoreDriverSQLite   = "sqlite"
+)
+
+// Store represents store configurations, driver is one of mysql, postgres and sqlite
+type Store struct {
+	Driver string `env:"STORE_DRIVER" default:"mysql"`
 }
 
 // MySql represents mysql configurations
 type MySql struct {
-	URI            string `env:"MYSQL_URI" required:"true"`
-	Database       string `env:"MYSQL_DATABASE" required:"true"`
-	UserName       string `env:"MYSQL_USER_NAME" required:"true"`
-	Port           string `env:"MYSQL_PORT" required:"true"`
-	Password       string `env:"MYSQL_PASSWORD" required:"true"`
-	ConnectTimeout int    `env:"MYSQL_CONNECT_TIMEOUT" default:"10"`
+	URI                   string        `env:"MYSQL_URI" required:"true"`
+	Database              string        `env:"MYSQL_DATABASE" required:"true"`
+	UserName              string        `env:"MYSQL_USER_NAME" required:"true"`
+	Port                  string        `env:"MYSQL_PORT" required:"true"`
+	Password              string        `env:"MYSQL_PASSWORD" required:"true"`
+	ConnectTimeout        int           `env:"MYSQL_CONNECT_TIMEOUT" default:"10"`
+	PingTimeout           time.Duration `env:"MYSQL_PING_TIMEOUT" default:"2s"`
+	ReadTimeout           time.Duration `env:"MYSQL_READ_TIMEOUT" default:"30s"`
+	WriteTimeout          time.Duration `env:"MYSQL_WRITE_TIMEOUT" default:"30s"`
+	DisconnectTimeout     time.Duration `env:"MYSQL_DISCONNECT_TIMEOUT" default:"5s"`
+	MaxOpenConns          int           `env:"MYSQL_MAX_OPEN_CONNS" default:"25"`
+	MaxIdleConns          int           `env:"MYSQL_MAX_IDLE_CONNS" default:"10"`
+	ConnMaxLifetime       time.Duration `env:"MYSQL_CONN_MAX_LIFETIME" default:"5m"`
+	ConnMaxIdleTime       time.Duration `env:"MYSQL_CONN_MAX_IDLE_TIME" default:"1m"`
+	ConnectRetries        int           `env:"MYSQL_CONNECT_RETRIES" default:"5"`
+	ConnectRetryBackoff   time.Duration `env:"MYSQL_CONNECT_RETRY_BACKOFF" default:"1s"`
+	Replicas              []string      `env:"MYSQL_REPLICAS"`
+	ReplicaHealthInterval time.Duration `env:"MYSQL_REPLICA_HEALTH_INTERVAL" default:"5s"`
+	ReplicaStickiness     time.Duration `env:"MYSQL_REPLICA_STICKINESS" default:"5s"`
+}
+
+// Postgres represents postgres configurations, ssl mode is one of disable, allow, prefer, require, verify-ca and
+// verify-full
+type Postgres struct {
+	URI                   string        `env:"POSTGRES_URI" required:"true"`
+	Database              string        `env:"POSTGRES_DATABASE" required:"true"`
+	UserName              string        `env:"POSTGRES_USER_NAME" required:"true"`
+	Port                  string        `env:"POSTGRES_PORT" default:"5432"`
+	Password              string        `env:"POSTGRES_PASSWORD" required:"true"`
+	SSLMode               string        `env:"POSTGRES_SSL_MODE" default:"prefer"`
+	ConnectTimeout        int           `env:"POSTGRES_CONNECT_TIMEOUT" default:"10"`
+	PingTimeout           time.Duration `env:"POSTGRES_PING_TIMEOUT" default:"2s"`
+	Replicas              []string      `env:"POSTGRES_REPLICAS"`
+	ReplicaHealthInterval time.Duration `env:"POSTGRES_REPLICA_HEALTH_INTERVAL" default:"5s"`
+	ReplicaStickiness     time.Duration `env:"POSTGRES_REPLICA_STICKINESS" default:"5s"`
+}
+
+// SQLite represents sqlite configurations, used for local development and tests
+type SQLite struct {
+	Path        string        `env:"SQLITE_PATH" default:"hotel-california.db"`
+	BusyTimeout time.Duration `env:"SQLITE_BUSY_TIMEOUT" default:"5s"`
 }
 
 // Localization represents localization configurations
@@ -50,6 +150,121 @@ type JWTToken struct {
 	Secret string `env:"JWT_TOKEN_SECRET" required:"true"`
 }
 
+// Reservation represents reservation configurations. Updates need the If-Match header only when if match required is
+// set, so that clients are moved to it before it is enforced. Guests are reminded of their stays reminder days before
+// check-in, and reservations are held for hold ttl after they are made and expire unless they are released by then,
+// 0 turns reminders and holds off. Guests not checked in by no-show cutoff after the start of the check-in date are
+// no-shows charged no-show penalty, in minor units of currency. Calendars list stays checked out within calendar history
+// and are subscribed to at calendar url with the token of the subscription. Stays are priced per night by
+// accommodation with nightly rates of accommodation:amount pairs in minor units of currency, and taxed by the vat and
+// accommodation tax rates in percent. Guests look up a reservation without signing in lookup burst times at once per ip
+// address and once more every lookup interval, 0 interval turns the limit off. Identity documents of guests are
+// encrypted with guest data key, 32 bytes encoded with base64, and prefixed with its version, documents are not accepted
+// without it. Guest data old keys are version:key pairs of the keys it replaced, the documents encrypted before are
+// decrypted with them.
+type Reservation struct {
+	IdempotencyKeyTTL    time.Duration `env:"RESERVATION_IDEMPOTENCY_KEY_TTL" default:"24h"`
+	IfMatchRequired      bool          `env:"RESERVATION_IF_MATCH_REQUIRED" default:"false"`
+	DefaultPageSize      int           `env:"RESERVATION_DEFAULT_PAGE_SIZE" default:"20"`
+	ReminderDays         int           `env:"RESERVATION_REMINDER_DAYS" default:"2"`
+	HoldTTL              time.Duration `env:"RESERVATION_HOLD_TTL" default:"0s"`
+	NoShowCutoff         time.Duration `env:"RESERVATION_NO_SHOW_CUTOFF" default:"18h"`
+	NoShowPenalty        int64         `env:"RESERVATION_NO_SHOW_PENALTY" default:"0"`
+	Currency             string        `env:"RESERVATION_CURRENCY" default:"TRY"`
+	CalendarHistory      time.Duration `env:"RESERVATION_CALENDAR_HISTORY" default:"8760h"`
+	CalendarURL          string        `env:"RESERVATION_CALENDAR_URL" default:"http://localhost:8001/v1/reservations.ics"`
+	NightlyRates         []string      `env:"RESERVATION_NIGHTLY_RATES" default:"beach:350000,city:250000,mountain:300000"`
+	VATRate              float64       `env:"RESERVATION_VAT_RATE" default:"10"`
+	AccommodationTaxRate float64       `env:"RESERVATION_ACCOMMODATION_TAX_RATE" default:"2"`
+	InvoicePrefix        string        `env:"RESERVATION_INVOICE_PREFIX" default:"HC-"`
+	LookupInterval       time.Duration `env:"RESERVATION_LOOKUP_INTERVAL" default:"12s"`
+	LookupBurst          int           `env:"RESERVATION_LOOKUP_BURST" default:"5"`
+	GuestDataKey         string        `env:"RESERVATION_GUEST_DATA_KEY"`
+	GuestDataKeyVersion  int           `env:"RESERVATION_GUEST_DATA_KEY_VERSION" default:"1"`
+	GuestDataOldKeys     []string      `env:"RESERVATION_GUEST_DATA_OLD_KEYS"`
+}
+
+// Tracing represents tracing configurations, exporter is one of none, otlp, stdout and file
+type Tracing struct {
+	Exporter     string  `env:"TRACING_EXPORTER" default:"none"`
+	ServiceName  string  `env:"TRACING_SERVICE_NAME" default:"hotel-california-backend"`
+	SampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" default:"1"`
+	OTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" default:"localhost:4318"`
+	OTLPInsecure bool    `env:"TRACING_OTLP_INSECURE" default:"true"`
+	FilePath     string  `env:"TRACING_FILE_PATH" default:"traces.jsonl"`
+}
+
+// outbox publishers
+const (
+	OutboxPublisherFile = "file"
+	OutboxPublisherHTTP = "http"
+)
+
+// Outbox represents outbox relay configurations, publishers is a comma separated list of file and http. Events are
+// always published on the in-process bus as well. An event failing max attempts times on a publisher is dead for it.
+type Outbox struct {
+	Publishers  []string      `env:"OUTBOX_PUBLISHERS"`
+	Interval    time.Duration `env:"OUTBOX_RELAY_INTERVAL" default:"1s"`
+	BatchSize   int           `env:"OUTBOX_BATCH_SIZE" default:"100"`
+	Retention   time.Duration `env:"OUTBOX_RETENTION" default:"168h"`
+	MaxAttempts int           `env:"OUTBOX_MAX_ATTEMPTS" default:"10"`
+	FilePath    string        `env:"OUTBOX_FILE_PATH" default:"events.ndjson"`
+	HTTPURL     string        `env:"OUTBOX_HTTP_URL"`
+	HTTPTimeout time.Duration `env:"OUTBOX_HTTP_TIMEOUT" default:"5s"`
+}
+
+// Webhook represents webhook delivery configurations, the n-th failed attempt of a delivery is retried after
+// backoff*2^(n-1) capped at max backoff and the delivery is dead after max attempts
+type Webhook struct {
+	Interval    time.Duration `env:"WEBHOOK_INTERVAL" default:"1s"`
+	BatchSize   int           `env:"WEBHOOK_BATCH_SIZE" default:"50"`
+	Timeout     time.Duration `env:"WEBHOOK_TIMEOUT" default:"10s"`
+	MaxAttempts int           `env:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	Backoff     time.Duration `env:"WEBHOOK_BACKOFF" default:"30s"`
+	MaxBackoff  time.Duration `env:"WEBHOOK_MAX_BACKOFF" default:"1h"`
+}
+
+// notification senders
+const (
+	NotificationSenderNone = "none"
+	NotificationSenderSMTP = "smtp"
+	NotificationSenderFile = "file"
+)
+
+// Notification represents email notification configurations, sender is one of none, smtp and file. Notifications of
+// events are kept for retention so that events published again are not emailed twice. Notifications not sent are
+// claimed every interval once their lease expires.
+type Notification struct {
+	Sender        string        `env:"NOTIFICATION_SENDER" default:"none"`
+	From          string        `env:"NOTIFICATION_FROM" default:"Hotel California <no-reply@hotel-california.local>"`
+	QueueSize     int           `env:"NOTIFICATION_QUEUE_SIZE" default:"1000"`
+	Workers       int           `env:"NOTIFICATION_WORKERS" default:"2"`
+	MaxAttempts   int           `env:"NOTIFICATION_MAX_ATTEMPTS" default:"3"`
+	Interval      time.Duration `env:"NOTIFICATION_INTERVAL" default:"1m"`
+	Lease         time.Duration `env:"NOTIFICATION_LEASE" default:"10m"`
+	Retention     time.Duration `env:"NOTIFICATION_RETENTION" default:"720h"`
+	SMTPHost      string        `env:"NOTIFICATION_SMTP_HOST" default:"localhost"`
+	SMTPPort      int           `env:"NOTIFICATION_SMTP_PORT" default:"587"`
+	SMTPUserName  string        `env:"NOTIFICATION_SMTP_USER_NAME"`
+	SMTPPassword  string        `env:"NOTIFICATION_SMTP_PASSWORD"`
+	SMTPTimeout   time.Duration `env:"NOTIFICATION_SMTP_TIMEOUT" default:"10s"`
+	FileDirectory string        `env:"NOTIFICATION_FILE_DIRECTORY" default:"mail"`
+}
+
+// Scheduler represents background job configurations, schedules are cron expressions of five fields or descriptors
+// like @hourly
+type Scheduler struct {
+	Enabled              bool          `env:"SCHEDULER_ENABLED" default:"true"`
+	Interval             time.Duration `env:"SCHEDULER_INTERVAL" default:"30s"`
+	LockTTL              time.Duration `env:"SCHEDULER_LOCK_TTL" default:"5m"`
+	RetryDelay           time.Duration `env:"SCHEDULER_RETRY_DELAY" default:"1m"`
+	ReminderSchedule     string        `env:"SCHEDULER_REMINDER_SCHEDULE" default:"0 * * * *"`
+	ExpirySchedule       string        `env:"SCHEDULER_EXPIRY_SCHEDULE" default:"*/5 * * * *"`
+	StaySchedule         string        `env:"SCHEDULER_STAY_SCHEDULE" default:"*/15 * * * *"`
+	IdempotencySchedule  string        `env:"SCHEDULER_IDEMPOTENCY_SCHEDULE" default:"30 * * * *"`
+	NotificationSchedule string        `env:"SCHEDULER_NOTIFICATION_SCHEDULE" default:"45 * * * *"`
+}
+
 // LoadEnvVars loads and returns environment variables
 func LoadEnvVars() (*EnvVars, error) {
 	s := Service{}
@@ -62,9 +277,39 @@ func LoadEnvVars() (*EnvVars, error) {
 		return nil, fmt.Errorf("loading http server environment variables failed, %s", err.Error())
 	}
 
+	if _, err := hs.TrustedProxyNetworks(); err != nil {
+		return nil, fmt.Errorf("loading http server environment variables failed, %s", err.Error())
+	}
+
+	as := AdminServer{}
+	if err := env.Set(&as); err != nil {
+		return nil, fmt.Errorf("loading admin server environment variables failed, %s", err.Error())
+	}
+
+	st := Store{}
+	if err := env.Set(&st); err != nil {
+		return nil, fmt.Errorf("loading store environment variables failed, %s", err.Error())
+	}
+
 	ms := MySql{}
-	if err := env.Set(&ms); err != nil {
-		return nil, fmt.Errorf("loading mysql environment variables failed, %s", err.Error())
+	pg := Postgres{}
+	sl := SQLite{}
+
+	switch st.Driver {
+	case StoreDriverMySQL:
+		if err := env.Set(&ms); err != nil {
+			return nil, fmt.Errorf("loading mysql environment variables failed, %s", err.Error())
+		}
+	case StoreDriverPostgres:
+		if err := env.Set(&pg); err != nil {
+			return nil, fmt.Errorf("loading postgres environment variables failed, %s", err.Error())
+		}
+	case StoreDriverSQLite:
+		if err := env.Set(&sl); err != nil {
+			return nil, fmt.Errorf("loading sqlite environment variables failed, %s", err.Error())
+		}
+	default:
+		return nil, fmt.Errorf("unknown store driver %q", st.Driver)
 	}
 
 	l := Localization{}
@@ -77,12 +322,58 @@ func LoadEnvVars() (*EnvVars, error) {
 		return nil, fmt.Errorf("loading jwt environment variables failed, %s", err.Error())
 	}
 
+	rs := Reservation{}
+	if err := env.Set(&rs); err != nil {
+		return nil, fmt.Errorf("loading reservation environment variables failed, %s", err.Error())
+	}
+
+	if rs.GuestDataKey != "" {
+		if _, err := encryption.NewCipher(rs.GuestDataKeyVersion, rs.GuestDataKey, rs.GuestDataOldKeys); err != nil {
+			return nil, fmt.Errorf("loading reservation environment variables failed, %s", err.Error())
+		}
+	}
+
+	tr := Tracing{}
+	if err := env.Set(&tr); err != nil {
+		return nil, fmt.Errorf("loading tracing environment variables failed, %s", err.Error())
+	}
+
+	ob := Outbox{}
+	if err := env.Set(&ob); err != nil {
+		return nil, fmt.Errorf("loading outbox environment variables failed, %s", err.Error())
+	}
+
+	wh := Webhook{}
+	if err := env.Set(&wh); err != nil {
+		return nil, fmt.Errorf("loading webhook environment variables failed, %s", err.Error())
+	}
+
+	nt := Notification{}
+	if err := env.Set(&nt); err != nil {
+		return nil, fmt.Errorf("loading notification environment variables failed, %s", err.Error())
+	}
+
+	sc := Scheduler{}
+	if err := env.Set(&sc); err != nil {
+		return nil, fmt.Errorf("loading scheduler environment variables failed, %s", err.Error())
+	}
+
 	ev := &EnvVars{
 		Service:      s,
 		HTTPServer:   hs,
+		AdminServer:  as,
+		Store:        st,
 		MySql:        ms,
+		Postgres:     pg,
+		SQLite:       sl,
 		Localization: l,
 		JWTToken:     jwt,
+		Reservation:  rs,
+		Tracing:      tr,
+		Outbox:       ob,
+		Webhook:      wh,
+		Notification: nt,
+		Scheduler:    sc,
 	}
 
 	return ev, nil
@@ -4,19 +4,37 @@ import (
 	"context"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	apierror "hotel-california-backend/internal/api-error"
+	"net/http"
 )
 
 // Service defines behaviors of account service
 type Service interface {
 	Health(context.Context, HealthRequest) HealthResponse
+	Liveness(context.Context, LivenessRequest) LivenessResponse
+	Readiness(context.Context, ReadinessRequest) ReadinessResponse
 	SignIn(context.Context, SignInRequest) SignInResponse
 	CreateReservation(context.Context, CreateReservationRequest) CreateReservationResponse
 	UpdateReservation(context.Context, UpdateReservationRequest) UpdateReservationResponse
+	CancelReservation(context.Context, CancelReservationRequest) CancelReservationResponse
+	ConfirmReservation(context.Context, ConfirmReservationRequest) ConfirmReservationResponse
 	FindReservation(context.Context, FindReservationRequest) FindReservationResponse
+	FindReservationHistory(context.Context, FindReservationHistoryRequest) FindReservationHistoryResponse
 	FindReservations(context.Context, FindReservationsRequest) FindReservationsResponse
+	CreateWebhookSubscription(context.Context, CreateWebhookSubscriptionRequest) CreateWebhookSubscriptionResponse
+	FindWebhookSubscriptions(context.Context, FindWebhookSubscriptionsRequest) FindWebhookSubscriptionsResponse
+	DeleteWebhookSubscription(context.Context, DeleteWebhookSubscriptionRequest) DeleteWebhookSubscriptionResponse
+	FindWebhookDeliveries(context.Context, FindWebhookDeliveriesRequest) FindWebhookDeliveriesResponse
+	RedeliverWebhookDelivery(context.Context, RedeliverWebhookDeliveryRequest) RedeliverWebhookDeliveryResponse
+	CheckInReservation(context.Context, CheckInReservationRequest) CheckInReservationResponse
+	ExportReservations(context.Context, ExportReservationsRequest) ExportReservationsResponse
+	ExportReservation(context.Context, ExportReservationRequest) ExportReservationResponse
+	CreateCalendarSubscription(context.Context, CreateCalendarSubscriptionRequest) CreateCalendarSubscriptionResponse
+	RevokeCalendarSubscription(context.Context, RevokeCalendarSubscriptionRequest) RevokeCalendarSubscriptionResponse
+	ExportReservationConfirmation(context.Context, ExportReservationConfirmationRequest) ExportReservationConfirmationResponse
+	LookupReservation(context.Context, LookupReservationRequest) LookupReservationResponse
 }
 
-// Request defines behaviors of request
+// Request defines behaviors of request, the client ip address is set by the transport
 type Request interface {
 	SetIPAddress(ipAddress string)
 }
@@ -30,21 +48,103 @@ type Response interface {
 // compile-time proofs of request interface implementation
 var (
 	_ Request = (*HealthRequest)(nil)
+	_ Request = (*LivenessRequest)(nil)
+	_ Request = (*ReadinessRequest)(nil)
 	_ Request = (*SignInRequest)(nil)
 	_ Request = (*CreateReservationRequest)(nil)
 	_ Request = (*UpdateReservationRequest)(nil)
+	_ Request = (*CancelReservationRequest)(nil)
+	_ Request = (*ConfirmReservationRequest)(nil)
 	_ Request = (*FindReservationRequest)(nil)
+	_ Request = (*FindReservationHistoryRequest)(nil)
 	_ Request = (*FindReservationsRequest)(nil)
+	_ Request = (*CreateWebhookSubscriptionRequest)(nil)
+	_ Request = (*FindWebhookSubscriptionsRequest)(nil)
+	_ Request = (*DeleteWebhookSubscriptionRequest)(nil)
+	_ Request = (*FindWebhookDeliveriesRequest)(nil)
+	_ Request = (*RedeliverWebhookDeliveryRequest)(nil)
+	_ Request = (*CheckInReservationRequest)(nil)
+	_ Request = (*ExportReservationsRequest)(nil)
+	_ Request = (*ExportReservationRequest)(nil)
+	_ Request = (*CreateCalendarSubscriptionRequest)(nil)
+	_ Request = (*RevokeCalendarSubscriptionRequest)(nil)
+	_ Request = (*ExportReservationConfirmationRequest)(nil)
+	_ Request = (*LookupReservationRequest)(nil)
 )
 
+// Headerer defines behaviors of response carrying http headers
+type Headerer interface {
+	Headers() http.Header
+}
+
 // compile-time proofs of response interface implementation
 var (
 	_ Response = (*HealthResponse)(nil)
+	_ Response = (*LivenessResponse)(nil)
+	_ Response = (*ReadinessResponse)(nil)
 	_ Response = (*SignInResponse)(nil)
 	_ Response = (*CreateReservationResponse)(nil)
 	_ Response = (*UpdateReservationResponse)(nil)
+	_ Response = (*CancelReservationResponse)(nil)
+	_ Response = (*ConfirmReservationResponse)(nil)
 	_ Response = (*FindReservationResponse)(nil)
+	_ Response = (*FindReservationHistoryResponse)(nil)
 	_ Response = (*FindReservationsResponse)(nil)
+	_ Response = (*CreateWebhookSubscriptionResponse)(nil)
+	_ Response = (*FindWebhookSubscriptionsResponse)(nil)
+	_ Response = (*DeleteWebhookSubscriptionResponse)(nil)
+	_ Response = (*FindWebhookDeliveriesResponse)(nil)
+	_ Response = (*RedeliverWebhookDeliveryResponse)(nil)
+	_ Response = (*CheckInReservationResponse)(nil)
+	_ Response = (*ExportReservationsResponse)(nil)
+	_ Response = (*ExportReservationResponse)(nil)
+	_ Response = (*CreateCalendarSubscriptionResponse)(nil)
+	_ Response = (*RevokeCalendarSubscriptionResponse)(nil)
+	_ Response = (*ExportReservationConfirmationResponse)(nil)
+	_ Response = (*LookupReservationResponse)(nil)
+)
+
+// compile-time proofs of headerer interface implementation
+var (
+	_ Headerer = (*UpdateReservationResponse)(nil)
+	_ Headerer = (*CancelReservationResponse)(nil)
+	_ Headerer = (*ConfirmReservationResponse)(nil)
+	_ Headerer = (*FindReservationResponse)(nil)
+)
+
+// StatusCoder defines behaviors of successful response with a status code other than 200
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// compile-time proofs of status coder interface implementation
+var (
+	_ StatusCoder = (*ReadinessResponse)(nil)
+)
+
+// File represents a file served instead of a json body
+type File struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// Filer defines behaviors of response carrying a file, the file is written instead of the json body when it is not nil
+type Filer interface {
+	File() *File
+}
+
+// compile-time proofs of filer interface implementation
+var (
+	_ Filer = (*ExportReservationsResponse)(nil)
+	_ Filer = (*ExportReservationResponse)(nil)
+	_ Filer = (*ExportReservationConfirmationResponse)(nil)
+)
+
+// health statuses
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
 )
 
 type Header struct {
@@ -60,6 +160,32 @@ type HealthResponse struct {
 	Ping string
 }
 
+// liveness and readiness models
+type (
+	LivenessRequest struct {
+		IPAddress string `json:"-"`
+	}
+
+	LivenessResponse struct {
+		Status string `json:"status"`
+	}
+
+	ReadinessRequest struct {
+		IPAddress string `json:"-"`
+	}
+
+	ReadinessResponse struct {
+		Status string         `json:"status"`
+		Checks []*HealthCheck `json:"checks"`
+	}
+
+	HealthCheck struct {
+		Name      string  `json:"name"`
+		Status    string  `json:"status"`
+		LatencyMs float64 `json:"latencyMs"`
+	}
+)
+
 // sign in models
 type (
 	SignInRequest struct {
@@ -83,13 +209,16 @@ type (
 type (
 	CreateReservationRequest struct {
 		Header
-		IPAddress     string `json:"-"`
-		UserId        int64  `json:"-"`
-		Destination   string `json:"destination" validate:"required"`
-		CheckInDate   string `json:"checkInDate" validate:"required"`
-		CheckOutDate  string `json:"checkOutDate" validate:"required"`
-		Accommodation string `json:"accommodation" validate:"required"`
-		GuestCount    int    `json:"guestCount" validate:"required"`
+		IPAddress      string `json:"-"`
+		UserId         int64  `json:"-"`
+		IdempotencyKey string `json:"-" header:"Idempotency-Key" validate:"omitempty,max=255"`
+		Destination    string `json:"destination" validate:"required"`
+		CheckInDate    string `json:"checkInDate" validate:"required"`
+		CheckOutDate   string `json:"checkOutDate" validate:"required"`
+		Accommodation  string `json:"accommodation" validate:"required"`
+		GuestCount     int    `json:"guestCount" validate:"required"`
+
+		Guests []ReservationGuest `json:"guests" validate:"omitempty,max=20,dive"`
 	}
 
 	CreateReservationResponse struct {
@@ -103,23 +232,51 @@ type (
 	}
 )
 
+// guest models, guests are given with the guest count of a reservation. Type is adult or child, the age of children
+// is required. Nationality is an ISO 3166-1 alpha-2 country code and the identity document is optional, its type is
+// passport or national_id.
+type (
+	ReservationGuest struct {
+		FullName       string `json:"fullName" validate:"required,max=100"`
+		Type           string `json:"type" validate:"required,oneof=adult child"`
+		Age            *int   `json:"age" validate:"required_if=Type child,omitempty,min=0,max=120"`
+		Nationality    string `json:"nationality" validate:"required,iso3166_1_alpha2"`
+		DocumentType   string `json:"documentType" validate:"required_with=DocumentNumber,omitempty,oneof=passport national_id"`
+		DocumentNumber string `json:"documentNumber" validate:"required_with=DocumentType,omitempty,alphanum,max=32"`
+	}
+
+	// ReservationGuestData represents a guest of a reservation, the document number is masked but its last characters
+	ReservationGuestData struct {
+		FullName       string `json:"fullName"`
+		Type           string `json:"type"`
+		Age            *int   `json:"age,omitempty"`
+		Nationality    string `json:"nationality"`
+		DocumentType   string `json:"documentType,omitempty"`
+		DocumentNumber string `json:"documentNumber,omitempty"`
+	}
+)
+
 // update reservation models
 type (
 	UpdateReservationRequest struct {
 		Header
 		IPAddress     string `json:"-"`
 		UserId        int64  `json:"-"`
+		IfMatch       string `json:"-" header:"If-Match"`
 		PNR           string `json:"pnr" validate:"required"`
 		Destination   string `json:"destination" validate:"required"`
 		CheckInDate   string `json:"checkInDate" validate:"required"`
 		CheckOutDate  string `json:"checkOutDate" validate:"required"`
 		Accommodation string `json:"accommodation" validate:"required"`
 		GuestCount    int    `json:"guestCount" validate:"required"`
+
+		Guests []ReservationGuest `json:"guests" validate:"omitempty,max=20,dive"`
 	}
 
 	UpdateReservationResponse struct {
 		Result *apierror.APIError     `json:"result"`
 		Data   *UpdateReservationData `json:"data"`
+		ETag   string                 `json:"-"`
 	}
 
 	UpdateReservationData struct {
@@ -127,6 +284,104 @@ type (
 	}
 )
 
+// cancel reservation models
+type (
+	CancelReservationRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+		IfMatch   string `json:"-" header:"If-Match"`
+		PNR       string `json:"pnr" validate:"required"`
+	}
+
+	CancelReservationResponse struct {
+		Result *apierror.APIError     `json:"result"`
+		Data   *CancelReservationData `json:"data"`
+		ETag   string                 `json:"-"`
+	}
+
+	CancelReservationData struct {
+		IsSuccessfully bool `json:"isSuccessfully"`
+	}
+)
+
+// confirm reservation models, held reservations are kept once they are confirmed
+type (
+	ConfirmReservationRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+		IfMatch   string `json:"-" header:"If-Match"`
+		PNR       string `json:"pnr" validate:"required"`
+	}
+
+	ConfirmReservationResponse struct {
+		Result *apierror.APIError      `json:"result"`
+		Data   *ConfirmReservationData `json:"data"`
+		ETag   string                  `json:"-"`
+	}
+
+	ConfirmReservationData struct {
+		IsSuccessfully bool `json:"isSuccessfully"`
+	}
+)
+
+// check-in models, guests are checked in by staff
+type (
+	CheckInReservationRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+		PNR       string `json:"pnr" validate:"required"`
+	}
+
+	CheckInReservationResponse struct {
+		Result *apierror.APIError      `json:"result"`
+		Data   *CheckInReservationData `json:"data"`
+	}
+
+	CheckInReservationData struct {
+		IsSuccessfully bool   `json:"isSuccessfully"`
+		CheckedInAt    string `json:"checkedInAt"`
+	}
+)
+
+// find reservation history models
+type (
+	FindReservationHistoryRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+		PNR       string `json:"-" query:"pnr" validate:"required"`
+	}
+
+	FindReservationHistoryResponse struct {
+		Result *apierror.APIError          `json:"result"`
+		Data   *FindReservationHistoryData `json:"data"`
+	}
+
+	FindReservationHistoryData struct {
+		PNR     string                    `json:"pnr"`
+		Entries []ReservationHistoryEntry `json:"entries"`
+	}
+
+	// ReservationHistoryEntry represents a change of a reservation, ip address and request id are only shown to staff
+	ReservationHistoryEntry struct {
+		Action      string                       `json:"action"`
+		ActorUserID int64                        `json:"actorUserId"`
+		IPAddress   string                       `json:"ipAddress,omitempty"`
+		RequestID   string                       `json:"requestId,omitempty"`
+		Changes     map[string]ReservationChange `json:"changes"`
+		CreatedAt   string                       `json:"createdAt"`
+	}
+
+	// ReservationChange represents the value of a field before and after a change
+	ReservationChange struct {
+		From interface{} `json:"from"`
+		To   interface{} `json:"to"`
+	}
+)
+
 // find reservation models
 type (
 	// FindReservationRequest defines the request structure for finding reservations.
@@ -141,9 +396,11 @@ type (
 	FindReservationResponse struct {
 		Result *apierror.APIError   `json:"result"`
 		Data   *FindReservationData `json:"data"`
+		ETag   string               `json:"-"`
 	}
 
-	// FindReservationData defines the details of a reservation.
+	// FindReservationData defines the details of a reservation. Status is one of confirmed, cancelled, checked_in,
+	// completed and no_show, the penalty is set for no-shows charged one.
 	FindReservationData struct {
 		PNR           string `json:"pnr"`
 		Destination   string `json:"destination"`
@@ -152,6 +409,43 @@ type (
 		Accommodation string `json:"accommodation"`
 		GuestCount    int    `json:"guestCount"`
 		UserName      string `json:"userName"`
+		Status        string `json:"status"`
+		CheckedInAt   string `json:"checkedInAt,omitempty"`
+		NoShowPenalty *Money `json:"noShowPenalty,omitempty"`
+
+		Guests []ReservationGuestData `json:"guests,omitempty"`
+	}
+
+	// Money represents an amount in minor units of the currency, e.g. 1250 TRY is 12.50 TRY
+	Money struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+	}
+)
+
+// lookup reservation models, a reservation is looked up without signing in by its pnr and the last name of its
+// guest, so only details which are not sensitive are returned
+type (
+	LookupReservationRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		PNR       string `json:"-" query:"pnr" validate:"required"`
+		LastName  string `json:"-" query:"lastName" validate:"required,max=100"`
+	}
+
+	LookupReservationResponse struct {
+		Result *apierror.APIError     `json:"result"`
+		Data   *LookupReservationData `json:"data"`
+	}
+
+	LookupReservationData struct {
+		PNR           string `json:"pnr"`
+		Destination   string `json:"destination"`
+		CheckInDate   string `json:"checkInDate"`
+		CheckOutDate  string `json:"checkOutDate"`
+		Accommodation string `json:"accommodation"`
+		GuestCount    int    `json:"guestCount"`
+		Status        string `json:"status"`
 	}
 )
 
@@ -159,8 +453,16 @@ type (
 type (
 	FindReservationsRequest struct {
 		Header
-		IPAddress string `json:"-"`
-		UserId    int64  `json:"-"`
+		IPAddress     string `json:"-"`
+		UserId        int64  `json:"-"`
+		Limit         int    `json:"-" query:"limit" validate:"omitempty,min=1,max=100"`
+		Cursor        string `json:"-" query:"cursor"`
+		Status        string `json:"-" query:"status" validate:"omitempty,oneof=active inactive"`
+		From          string `json:"-" query:"from" validate:"omitempty,datetime=2006-01-02"`
+		To            string `json:"-" query:"to" validate:"omitempty,datetime=2006-01-02"`
+		Destination   string `json:"-" query:"destination"`
+		Accommodation string `json:"-" query:"accommodation"`
+		Sort          string `json:"-" query:"sort" validate:"omitempty,oneof=checkInDate -checkInDate createdAt -createdAt"`
 	}
 
 	FindReservationsResponse struct {
@@ -170,10 +472,207 @@ type (
 
 	FindReservationsData struct {
 		Reservations   []FindReservationData `json:"reservations"`
+		TotalCount     int64                 `json:"totalCount"`
+		NextCursor     string                `json:"nextCursor,omitempty"`
 		IsSuccessfully bool                  `json:"isSuccessfully"`
 	}
 )
 
+// calendar export models, reservations are exported as iCalendar files. The calendar of all reservations is also
+// served to the token of a calendar subscription, so that calendar clients poll it without signing in.
+type (
+	ExportReservationsRequest struct {
+		Header
+		IPAddress     string `json:"-"`
+		UserId        int64  `json:"-"`
+		CalendarToken string `json:"-" query:"token"`
+	}
+
+	ExportReservationsResponse struct {
+		Result   *apierror.APIError `json:"result"`
+		Calendar *File              `json:"-"`
+	}
+
+	ExportReservationRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+		PNR       string `json:"-" query:"pnr" validate:"required"`
+	}
+
+	ExportReservationResponse struct {
+		Result   *apierror.APIError `json:"result"`
+		Calendar *File              `json:"-"`
+	}
+
+	CreateCalendarSubscriptionRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+	}
+
+	CreateCalendarSubscriptionResponse struct {
+		Result *apierror.APIError        `json:"result"`
+		Data   *CalendarSubscriptionData `json:"data"`
+	}
+
+	// CalendarSubscriptionData defines the url calendars are subscribed to, it is returned only once and replaces the
+	// url created before
+	CalendarSubscriptionData struct {
+		URL       string `json:"url"`
+		CreatedAt string `json:"createdAt"`
+	}
+
+	RevokeCalendarSubscriptionRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+	}
+
+	RevokeCalendarSubscriptionResponse struct {
+		Result *apierror.APIError              `json:"result"`
+		Data   *RevokeCalendarSubscriptionData `json:"data"`
+	}
+
+	RevokeCalendarSubscriptionData struct {
+		IsSuccessfully bool `json:"isSuccessfully"`
+	}
+)
+
+// confirmation models, the confirmation of a reservation is a pdf with its invoice
+type (
+	ExportReservationConfirmationRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+		PNR       string `json:"-" path:"pnr" validate:"required"`
+	}
+
+	ExportReservationConfirmationResponse struct {
+		Result   *apierror.APIError `json:"result"`
+		Document *File              `json:"-"`
+	}
+)
+
+// webhook subscription models, subscriptions are managed by staff
+type (
+	CreateWebhookSubscriptionRequest struct {
+		Header
+		IPAddress         string   `json:"-"`
+		UserId            int64    `json:"-"`
+		URL               string   `json:"url" validate:"required,url,max=2048"`
+		EventTypes        []string `json:"eventTypes" validate:"required,min=1,dive,oneof=ReservationCreated ReservationUpdated ReservationCancelled ReservationConfirmed ReservationReminderDue ReservationCheckedIn ReservationCompleted ReservationNoShow"`
+		ReservationUserId int64    `json:"reservationUserId" validate:"omitempty,min=1"`
+	}
+
+	CreateWebhookSubscriptionResponse struct {
+		Result *apierror.APIError       `json:"result"`
+		Data   *WebhookSubscriptionData `json:"data"`
+	}
+
+	// WebhookSubscriptionData represents a webhook subscription, the secret is only returned when it is created
+	WebhookSubscriptionData struct {
+		ID                int64    `json:"id"`
+		URL               string   `json:"url"`
+		EventTypes        []string `json:"eventTypes"`
+		ReservationUserId int64    `json:"reservationUserId,omitempty"`
+		Secret            string   `json:"secret,omitempty"`
+		CreatedAt         string   `json:"createdAt"`
+	}
+
+	FindWebhookSubscriptionsRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+	}
+
+	FindWebhookSubscriptionsResponse struct {
+		Result *apierror.APIError            `json:"result"`
+		Data   *FindWebhookSubscriptionsData `json:"data"`
+	}
+
+	FindWebhookSubscriptionsData struct {
+		Subscriptions []WebhookSubscriptionData `json:"subscriptions"`
+	}
+
+	DeleteWebhookSubscriptionRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+		ID        int64  `json:"id" validate:"required"`
+	}
+
+	DeleteWebhookSubscriptionResponse struct {
+		Result *apierror.APIError             `json:"result"`
+		Data   *DeleteWebhookSubscriptionData `json:"data"`
+	}
+
+	DeleteWebhookSubscriptionData struct {
+		IsSuccessfully bool `json:"isSuccessfully"`
+	}
+)
+
+// webhook delivery models, deliveries are listed and redelivered by staff
+type (
+	FindWebhookDeliveriesRequest struct {
+		Header
+		IPAddress      string `json:"-"`
+		UserId         int64  `json:"-"`
+		SubscriptionId int64  `json:"-" query:"subscriptionId"`
+		Status         string `json:"-" query:"status" validate:"omitempty,oneof=pending succeeded dead"`
+		Limit          int    `json:"-" query:"limit" validate:"omitempty,min=1,max=100"`
+	}
+
+	FindWebhookDeliveriesResponse struct {
+		Result *apierror.APIError         `json:"result"`
+		Data   *FindWebhookDeliveriesData `json:"data"`
+	}
+
+	FindWebhookDeliveriesData struct {
+		Deliveries []WebhookDeliveryData `json:"deliveries"`
+	}
+
+	// WebhookDeliveryData represents the delivery of an event to a subscription with the log of its attempts
+	WebhookDeliveryData struct {
+		ID             int64                        `json:"id"`
+		SubscriptionId int64                        `json:"subscriptionId"`
+		EventID        string                       `json:"eventId"`
+		EventType      string                       `json:"eventType"`
+		Status         string                       `json:"status"`
+		Attempts       int                          `json:"attempts"`
+		NextAttemptAt  string                       `json:"nextAttemptAt,omitempty"`
+		LastStatusCode int                          `json:"lastStatusCode,omitempty"`
+		LastError      string                       `json:"lastError,omitempty"`
+		CreatedAt      string                       `json:"createdAt"`
+		AttemptLogs    []WebhookDeliveryAttemptData `json:"attemptLogs"`
+	}
+
+	// WebhookDeliveryAttemptData represents an attempt of a delivery, status code is omitted when no response was
+	// received
+	WebhookDeliveryAttemptData struct {
+		StatusCode int    `json:"statusCode,omitempty"`
+		Error      string `json:"error,omitempty"`
+		DurationMs int64  `json:"durationMs"`
+		CreatedAt  string `json:"createdAt"`
+	}
+
+	RedeliverWebhookDeliveryRequest struct {
+		Header
+		IPAddress string `json:"-"`
+		UserId    int64  `json:"-"`
+		ID        int64  `json:"id" validate:"required"`
+	}
+
+	RedeliverWebhookDeliveryResponse struct {
+		Result *apierror.APIError            `json:"result"`
+		Data   *RedeliverWebhookDeliveryData `json:"data"`
+	}
+
+	RedeliverWebhookDeliveryData struct {
+		IsSuccessfully bool `json:"isSuccessfully"`
+	}
+)
+
 // Localize method for FindReservationsResponse
 func (f FindReservationsResponse) Localize(l *i18n.Localizer) interface{} {
 	return f
@@ -189,7 +688,7 @@ func (f FindReservationsResponse) APIError() error {
 }
 
 // SetIPAddress method for FindReservationsRequest
-func (f FindReservationsRequest) SetIPAddress(ipAddress string) {
+func (f *FindReservationsRequest) SetIPAddress(ipAddress string) {
 	f.IPAddress = ipAddress
 }
 
@@ -207,6 +706,11 @@ func (f FindReservationResponse) APIError() error {
 	return f.Result
 }
 
+// Headers method for FindReservationResponse
+func (f FindReservationResponse) Headers() http.Header {
+	return etagHeader(f.ETag)
+}
+
 // SetIPAddress method for FindReservationRequest
 func (f *FindReservationRequest) SetIPAddress(ipAddress string) {
 	f.IPAddress = ipAddress
@@ -224,10 +728,273 @@ func (u UpdateReservationResponse) APIError() error {
 	return u.Result
 }
 
-func (u UpdateReservationRequest) SetIPAddress(ipAddress string) {
+func (u UpdateReservationResponse) Headers() http.Header {
+	return etagHeader(u.ETag)
+}
+
+func (u *UpdateReservationRequest) SetIPAddress(ipAddress string) {
 	u.IPAddress = ipAddress
 }
 
+func (c CancelReservationResponse) Localize(l *i18n.Localizer) interface{} {
+	return c
+}
+
+func (c CancelReservationResponse) APIError() error {
+	if c.Result == nil {
+		return nil
+	}
+
+	return c.Result
+}
+
+func (c CancelReservationResponse) Headers() http.Header {
+	return etagHeader(c.ETag)
+}
+
+func (c *CancelReservationRequest) SetIPAddress(ipAddress string) {
+	c.IPAddress = ipAddress
+}
+
+func (c ConfirmReservationResponse) Localize(l *i18n.Localizer) interface{} {
+	return c
+}
+
+func (c ConfirmReservationResponse) APIError() error {
+	if c.Result == nil {
+		return nil
+	}
+
+	return c.Result
+}
+
+func (c ConfirmReservationResponse) Headers() http.Header {
+	return etagHeader(c.ETag)
+}
+
+func (c *ConfirmReservationRequest) SetIPAddress(ipAddress string) {
+	c.IPAddress = ipAddress
+}
+
+// Localize method for CheckInReservationResponse
+func (c CheckInReservationResponse) Localize(l *i18n.Localizer) interface{} {
+	return c
+}
+
+// APIError method for CheckInReservationResponse
+func (c CheckInReservationResponse) APIError() error {
+	if c.Result == nil {
+		return nil
+	}
+
+	return c.Result
+}
+
+// SetIPAddress method for CheckInReservationRequest
+func (c *CheckInReservationRequest) SetIPAddress(ipAddress string) {
+	c.IPAddress = ipAddress
+}
+
+// Localize method for FindReservationHistoryResponse
+func (f FindReservationHistoryResponse) Localize(l *i18n.Localizer) interface{} {
+	return f
+}
+
+// APIError method for FindReservationHistoryResponse
+func (f FindReservationHistoryResponse) APIError() error {
+	if f.Result == nil {
+		return nil
+	}
+
+	return f.Result
+}
+
+// SetIPAddress method for FindReservationHistoryRequest
+func (f *FindReservationHistoryRequest) SetIPAddress(ipAddress string) {
+	f.IPAddress = ipAddress
+}
+
+// Localize method for CreateWebhookSubscriptionResponse
+func (c CreateWebhookSubscriptionResponse) Localize(l *i18n.Localizer) interface{} {
+	return c
+}
+
+// APIError method for CreateWebhookSubscriptionResponse
+func (c CreateWebhookSubscriptionResponse) APIError() error {
+	if c.Result == nil {
+		return nil
+	}
+
+	return c.Result
+}
+
+// SetIPAddress method for CreateWebhookSubscriptionRequest
+func (c *CreateWebhookSubscriptionRequest) SetIPAddress(ipAddress string) {
+	c.IPAddress = ipAddress
+}
+
+// Localize method for FindWebhookSubscriptionsResponse
+func (f FindWebhookSubscriptionsResponse) Localize(l *i18n.Localizer) interface{} {
+	return f
+}
+
+// APIError method for FindWebhookSubscriptionsResponse
+func (f FindWebhookSubscriptionsResponse) APIError() error {
+	if f.Result == nil {
+		return nil
+	}
+
+	return f.Result
+}
+
+// SetIPAddress method for FindWebhookSubscriptionsRequest
+func (f *FindWebhookSubscriptionsRequest) SetIPAddress(ipAddress string) {
+	f.IPAddress = ipAddress
+}
+
+// Localize method for DeleteWebhookSubscriptionResponse
+func (d DeleteWebhookSubscriptionResponse) Localize(l *i18n.Localizer) interface{} {
+	return d
+}
+
+// APIError method for DeleteWebhookSubscriptionResponse
+func (d DeleteWebhookSubscriptionResponse) APIError() error {
+	if d.Result == nil {
+		return nil
+	}
+
+	return d.Result
+}
+
+// SetIPAddress method for DeleteWebhookSubscriptionRequest
+func (d *DeleteWebhookSubscriptionRequest) SetIPAddress(ipAddress string) {
+	d.IPAddress = ipAddress
+}
+
+// Localize method for FindWebhookDeliveriesResponse
+func (f FindWebhookDeliveriesResponse) Localize(l *i18n.Localizer) interface{} {
+	return f
+}
+
+// APIError method for FindWebhookDeliveriesResponse
+func (f FindWebhookDeliveriesResponse) APIError() error {
+	if f.Result == nil {
+		return nil
+	}
+
+	return f.Result
+}
+
+// SetIPAddress method for FindWebhookDeliveriesRequest
+func (f *FindWebhookDeliveriesRequest) SetIPAddress(ipAddress string) {
+	f.IPAddress = ipAddress
+}
+
+// Localize method for RedeliverWebhookDeliveryResponse
+func (r RedeliverWebhookDeliveryResponse) Localize(l *i18n.Localizer) interface{} {
+	return r
+}
+
+// APIError method for RedeliverWebhookDeliveryResponse
+func (r RedeliverWebhookDeliveryResponse) APIError() error {
+	if r.Result == nil {
+		return nil
+	}
+
+	return r.Result
+}
+
+// SetIPAddress method for RedeliverWebhookDeliveryRequest
+func (r *RedeliverWebhookDeliveryRequest) SetIPAddress(ipAddress string) {
+	r.IPAddress = ipAddress
+}
+
+// Localize method for ExportReservationsResponse
+func (e ExportReservationsResponse) Localize(l *i18n.Localizer) interface{} {
+	return e
+}
+
+// APIError method for ExportReservationsResponse
+func (e ExportReservationsResponse) APIError() error {
+	if e.Result == nil {
+		return nil
+	}
+
+	return e.Result
+}
+
+// File method for ExportReservationsResponse
+func (e ExportReservationsResponse) File() *File {
+	return e.Calendar
+}
+
+// SetIPAddress method for ExportReservationsRequest
+func (e *ExportReservationsRequest) SetIPAddress(ipAddress string) {
+	e.IPAddress = ipAddress
+}
+
+// Localize method for ExportReservationResponse
+func (e ExportReservationResponse) Localize(l *i18n.Localizer) interface{} {
+	return e
+}
+
+// APIError method for ExportReservationResponse
+func (e ExportReservationResponse) APIError() error {
+	if e.Result == nil {
+		return nil
+	}
+
+	return e.Result
+}
+
+// File method for ExportReservationResponse
+func (e ExportReservationResponse) File() *File {
+	return e.Calendar
+}
+
+// SetIPAddress method for ExportReservationRequest
+func (e *ExportReservationRequest) SetIPAddress(ipAddress string) {
+	e.IPAddress = ipAddress
+}
+
+// Localize method for CreateCalendarSubscriptionResponse
+func (c CreateCalendarSubscriptionResponse) Localize(l *i18n.Localizer) interface{} {
+	return c
+}
+
+// APIError method for CreateCalendarSubscriptionResponse
+func (c CreateCalendarSubscriptionResponse) APIError() error {
+	if c.Result == nil {
+		return nil
+	}
+
+	return c.Result
+}
+
+// SetIPAddress method for CreateCalendarSubscriptionRequest
+func (c *CreateCalendarSubscriptionRequest) SetIPAddress(ipAddress string) {
+	c.IPAddress = ipAddress
+}
+
+// Localize method for RevokeCalendarSubscriptionResponse
+func (r RevokeCalendarSubscriptionResponse) Localize(l *i18n.Localizer) interface{} {
+	return r
+}
+
+// APIError method for RevokeCalendarSubscriptionResponse
+func (r RevokeCalendarSubscriptionResponse) APIError() error {
+	if r.Result == nil {
+		return nil
+	}
+
+	return r.Result
+}
+
+// SetIPAddress method for RevokeCalendarSubscriptionRequest
+func (r *RevokeCalendarSubscriptionRequest) SetIPAddress(ipAddress string) {
+	r.IPAddress = ipAddress
+}
+
 func (c CreateReservationResponse) Localize(l *i18n.Localizer) interface{} {
 	return c
 }
@@ -240,7 +1007,7 @@ func (c CreateReservationResponse) APIError() error {
 	return c.Result
 }
 
-func (c CreateReservationRequest) SetIPAddress(ipAddress string) {
+func (c *CreateReservationRequest) SetIPAddress(ipAddress string) {
 	c.IPAddress = ipAddress
 }
 
@@ -256,11 +1023,11 @@ func (p SignInResponse) APIError() error {
 	return p.Result
 }
 
-func (p SignInRequest) SetIPAddress(ipAddress string) {
+func (p *SignInRequest) SetIPAddress(ipAddress string) {
 	p.IPAddress = ipAddress
 }
 
-func (r HealthRequest) SetIPAddress(ipAddress string) {
+func (r *HealthRequest) SetIPAddress(ipAddress string) {
 	r.IPAddress = ipAddress
 }
 
@@ -271,3 +1038,89 @@ func (r HealthResponse) Localize(_ *i18n.Localizer) interface{} {
 func (r HealthResponse) APIError() error {
 	return nil
 }
+
+func (r *LivenessRequest) SetIPAddress(ipAddress string) {
+	r.IPAddress = ipAddress
+}
+
+func (r LivenessResponse) Localize(_ *i18n.Localizer) interface{} {
+	return r
+}
+
+func (r LivenessResponse) APIError() error {
+	return nil
+}
+
+func (r *ReadinessRequest) SetIPAddress(ipAddress string) {
+	r.IPAddress = ipAddress
+}
+
+func (r ReadinessResponse) Localize(_ *i18n.Localizer) interface{} {
+	return r
+}
+
+func (r ReadinessResponse) APIError() error {
+	return nil
+}
+
+// StatusCode returns 503 while any check is down so that the instance is taken out of rotation
+func (r ReadinessResponse) StatusCode() int {
+	if r.Status != HealthStatusUp {
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusOK
+}
+
+func etagHeader(etag string) http.Header {
+	h := http.Header{}
+
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+
+	return h
+}
+
+// Localize method for ExportReservationConfirmationResponse
+func (e ExportReservationConfirmationResponse) Localize(l *i18n.Localizer) interface{} {
+	return e
+}
+
+// APIError method for ExportReservationConfirmationResponse
+func (e ExportReservationConfirmationResponse) APIError() error {
+	if e.Result == nil {
+		return nil
+	}
+
+	return e.Result
+}
+
+// File method for ExportReservationConfirmationResponse
+func (e ExportReservationConfirmationResponse) File() *File {
+	return e.Document
+}
+
+// SetIPAddress method for ExportReservationConfirmationRequest
+func (e *ExportReservationConfirmationRequest) SetIPAddress(ipAddress string) {
+	e.IPAddress = ipAddress
+}
+
+// Localize method for LookupReservationResponse
+func (l LookupReservationResponse) Localize(_ *i18n.Localizer) interface{} {
+	return l
+}
+
+// APIError method for LookupReservationResponse
+func (l LookupReservationResponse) APIError() error {
+	if l.Result == nil {
+		return nil
+	}
+
+	return l.Result
+}
+
+// SetIPAddress method for LookupReservationRequest
+func (l *LookupReservationRequest) SetIPAddress(ipAddress string) {
+	l.IPAddress = ipAddress
+}
@@ -15,6 +15,21 @@ const (
 	CodeCouldNotCreateReservationError
 	CodeCouldNotChangeReservationCheckInDateError
 	CodeCouldNotCheckInGreaterThanCheckoutError
+	CodeIdempotencyKeyMismatchError
+	CodeIdempotencyKeyInProgressError
+	CodeReservationPreconditionRequiredError
+	CodeReservationModifiedError
+	CodeNotFoundError
+	CodeForbiddenError
+	CodeConflictError
+	CodeConstraintViolationError
+	CodeServiceUnavailableError
+	CodeInvalidCredentialsError
+	CodeReservationAlreadyCancelledError
+	CodeCouldNotCancelPastReservationError
+	CodeReservationAlreadyCheckedInError
+	CodeCouldNotCheckInReservationError
+	CodeTooManyRequestsError
 )
 
 // error names
@@ -26,23 +41,52 @@ const (
 	NameCouldNotCreateReservationError            = "CouldNotCreateReservationError"
 	NameCouldNotChangeReservationCheckInDateError = "CouldNotChangeReservationCheckInDateError"
 	NameCouldNotCheckInGreaterThanCheckoutError   = "CouldNotCheckInGreaterThanCheckoutError"
+	NameIdempotencyKeyMismatchError               = "IdempotencyKeyMismatchError"
+	NameIdempotencyKeyInProgressError             = "IdempotencyKeyInProgressError"
+	NameReservationPreconditionRequiredError      = "ReservationPreconditionRequiredError"
+	NameReservationModifiedError                  = "ReservationModifiedError"
+	NameNotFoundError                             = "NotFoundError"
+	NameForbiddenError                            = "ForbiddenError"
+	NameConflictError                             = "ConflictError"
+	NameConstraintViolationError                  = "ConstraintViolationError"
+	NameServiceUnavailableError                   = "ServiceUnavailableError"
+	NameInvalidCredentialsError                   = "InvalidCredentialsError"
+	NameReservationAlreadyCancelledError          = "ReservationAlreadyCancelledError"
+	NameCouldNotCancelPastReservationError        = "CouldNotCancelPastReservationError"
+	NameReservationAlreadyCheckedInError          = "ReservationAlreadyCheckedInError"
+	NameCouldNotCheckInReservationError           = "CouldNotCheckInReservationError"
+	NameTooManyRequestsError                      = "TooManyRequestsError"
 )
 
 // compile-time proof of error interface implementation
 var _ error = (*APIError)(nil)
 
 // compile-time proofs of localizer interface implementation
-var _ localization.Localizer = (*APIError)(nil)
+var (
+	_ localization.Localizer = (*APIError)(nil)
+	_ localization.Localizer = (*FieldError)(nil)
+)
 
 // APIError represents api error
 type APIError struct {
-	Message             string `json:"message"`
-	Name                string `json:"name"`
-	Code                int    `json:"code"`
-	StatusCode          int    `json:"statusCode"`
-	ErrorAction         int    `json:"errorAction,omitempty"`
-	BaseError           error  `json:"-"`
-	MessageLocalizerKey string `json:"-"`
+	Message             string        `json:"message"`
+	Name                string        `json:"name"`
+	Code                int           `json:"code"`
+	StatusCode          int           `json:"statusCode"`
+	ErrorAction         int           `json:"errorAction,omitempty"`
+	Fields              []*FieldError `json:"fields,omitempty"`
+	BaseError           error         `json:"-"`
+	MessageLocalizerKey string        `json:"-"`
+}
+
+// FieldError represents a single field's validation failure
+type FieldError struct {
+	Field               string                 `json:"field"`
+	Rule                string                 `json:"rule"`
+	Param               string                 `json:"param,omitempty"`
+	Message             string                 `json:"message"`
+	MessageLocalizerKey string                 `json:"-"`
+	TemplateData        map[string]interface{} `json:"-"`
 }
 
 // DefaultInternalServerError represents default internal server error
@@ -82,6 +126,117 @@ var CouldNotCheckInGreaterThanCheckout = &APIError{
 	MessageLocalizerKey: localization.CouldNotCheckInGreaterThanCheckoutError,
 }
 
+var IdempotencyKeyMismatch = &APIError{
+	Name:                NameIdempotencyKeyMismatchError,
+	Code:                CodeIdempotencyKeyMismatchError,
+	StatusCode:          http.StatusUnprocessableEntity,
+	MessageLocalizerKey: localization.IdempotencyKeyMismatch,
+}
+
+var IdempotencyKeyInProgress = &APIError{
+	Name:                NameIdempotencyKeyInProgressError,
+	Code:                CodeIdempotencyKeyInProgressError,
+	StatusCode:          http.StatusConflict,
+	MessageLocalizerKey: localization.IdempotencyKeyInProgress,
+}
+
+var ReservationPreconditionRequired = &APIError{
+	Name:                NameReservationPreconditionRequiredError,
+	Code:                CodeReservationPreconditionRequiredError,
+	StatusCode:          http.StatusPreconditionRequired,
+	MessageLocalizerKey: localization.ReservationPreconditionRequired,
+}
+
+var ReservationModified = &APIError{
+	Name:                NameReservationModifiedError,
+	Code:                CodeReservationModifiedError,
+	StatusCode:          http.StatusPreconditionFailed,
+	MessageLocalizerKey: localization.ReservationModified,
+}
+
+// DefaultNotFoundError represents default not found error
+var DefaultNotFoundError = &APIError{
+	Name:                NameNotFoundError,
+	Code:                CodeNotFoundError,
+	StatusCode:          http.StatusNotFound,
+	MessageLocalizerKey: localization.NotFound,
+}
+
+// DefaultForbiddenError represents default forbidden error
+var DefaultForbiddenError = &APIError{
+	Name:                NameForbiddenError,
+	Code:                CodeForbiddenError,
+	StatusCode:          http.StatusForbidden,
+	MessageLocalizerKey: localization.Forbidden,
+}
+
+// DefaultConflictError represents default conflict error
+var DefaultConflictError = &APIError{
+	Name:                NameConflictError,
+	Code:                CodeConflictError,
+	StatusCode:          http.StatusConflict,
+	MessageLocalizerKey: localization.Conflict,
+}
+
+// DefaultConstraintViolationError represents default constraint violation error
+var DefaultConstraintViolationError = &APIError{
+	Name:                NameConstraintViolationError,
+	Code:                CodeConstraintViolationError,
+	StatusCode:          http.StatusConflict,
+	MessageLocalizerKey: localization.ConstraintViolation,
+}
+
+// DefaultServiceUnavailableError represents default service unavailable error
+var DefaultServiceUnavailableError = &APIError{
+	Name:                NameServiceUnavailableError,
+	Code:                CodeServiceUnavailableError,
+	StatusCode:          http.StatusServiceUnavailable,
+	MessageLocalizerKey: localization.ServiceUnavailable,
+}
+
+var InvalidCredentials = &APIError{
+	Name:                NameInvalidCredentialsError,
+	Code:                CodeInvalidCredentialsError,
+	StatusCode:          http.StatusUnauthorized,
+	MessageLocalizerKey: localization.InvalidCredentials,
+}
+
+var ReservationAlreadyCancelled = &APIError{
+	Name:                NameReservationAlreadyCancelledError,
+	Code:                CodeReservationAlreadyCancelledError,
+	StatusCode:          http.StatusConflict,
+	MessageLocalizerKey: localization.ReservationAlreadyCancelled,
+}
+
+var CouldNotCancelPastReservation = &APIError{
+	Name:                NameCouldNotCancelPastReservationError,
+	Code:                CodeCouldNotCancelPastReservationError,
+	StatusCode:          http.StatusBadRequest,
+	MessageLocalizerKey: localization.CouldNotCancelPastReservation,
+}
+
+var ReservationAlreadyCheckedIn = &APIError{
+	Name:                NameReservationAlreadyCheckedInError,
+	Code:                CodeReservationAlreadyCheckedInError,
+	StatusCode:          http.StatusConflict,
+	MessageLocalizerKey: localization.ReservationAlreadyCheckedIn,
+}
+
+var CouldNotCheckInReservation = &APIError{
+	Name:                NameCouldNotCheckInReservationError,
+	Code:                CodeCouldNotCheckInReservationError,
+	StatusCode:          http.StatusBadRequest,
+	MessageLocalizerKey: localization.CouldNotCheckInReservation,
+}
+
+// DefaultTooManyRequestsError represents default too many requests error
+var DefaultTooManyRequestsError = &APIError{
+	Name:                NameTooManyRequestsError,
+	Code:                CodeTooManyRequestsError,
+	StatusCode:          http.StatusTooManyRequests,
+	MessageLocalizerKey: localization.TooManyRequests,
+}
+
 // NewBadRequestError returns bad request error
 func NewBadRequestError(message error) *APIError {
 	return &APIError{
@@ -93,20 +248,55 @@ func NewBadRequestError(message error) *APIError {
 }
 
 // NewValidationError returns validation error
-func NewValidationError(message string, messageLocalizerKey string) *APIError {
+func NewValidationError(message string, messageLocalizerKey string, fields ...*FieldError) *APIError {
 	return &APIError{
 		Message:             message,
 		Name:                NameValidationError,
 		Code:                CodeValidationError,
 		StatusCode:          http.StatusBadRequest,
+		Fields:              fields,
 		MessageLocalizerKey: messageLocalizerKey,
 	}
 }
 
+// WithBaseError returns a copy of api error keeping the error it was caused by
+func (apiErr *APIError) WithBaseError(err error) *APIError {
+	c := *apiErr
+	c.BaseError = err
+
+	return &c
+}
+
+// Localized returns a localized copy of api error, errors shared by requests in different languages are not changed
+func (apiErr *APIError) Localized(l *i18n.Localizer) *APIError {
+	c := *apiErr
+
+	if apiErr.Fields != nil {
+		c.Fields = make([]*FieldError, len(apiErr.Fields))
+
+		for i, f := range apiErr.Fields {
+			fc := *f
+			c.Fields[i] = &fc
+		}
+	}
+
+	c.Localize(l)
+
+	return &c
+}
+
 func (apiErr *APIError) Localize(l *i18n.Localizer) {
 	apiErr.Message = localization.Localize(l, apiErr.MessageLocalizerKey, apiErr.Message)
+
+	for _, f := range apiErr.Fields {
+		f.Localize(l)
+	}
 }
 
 func (apiErr *APIError) Error() string {
 	return apiErr.Message
 }
+
+func (fe *FieldError) Localize(l *i18n.Localizer) {
+	fe.Message = localization.LocalizeWithTemplateData(l, fe.MessageLocalizerKey, fe.TemplateData, fe.Message)
+}
@@ -0,0 +1,132 @@
+// Package calendar renders iCalendar files (RFC 5545) calendar clients import and subscribe to
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of iCalendar files
+const ContentType = "text/calendar; charset=utf-8"
+
+// event statuses
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+
+	// lines longer than maxLineLen octets are folded
+	maxLineLen = 75
+)
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// Calendar represents a calendar of events, Name is shown by clients subscribing to it
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Event represents an all-day event from the date of Start until the date of End, End is not included. Sequence is
+// increased by every change of the event and Stamp is the time the event is rendered at.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Status      string
+	Sequence    int
+	Stamp       time.Time
+}
+
+// Render renders the calendar
+func (c Calendar) Render() []byte {
+	var b bytes.Buffer
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+c.ProdID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+
+	if c.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+
+	for _, e := range c.Events {
+		e.render(&b)
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+
+	return b.Bytes()
+}
+
+func (e Event) render(b *bytes.Buffer) {
+	writeLine(b, "BEGIN:VEVENT")
+	writeLine(b, "UID:"+e.UID)
+	writeLine(b, "DTSTAMP:"+e.Stamp.UTC().Format(dateTimeLayout))
+	writeLine(b, "DTSTART;VALUE=DATE:"+e.Start.Format(dateLayout))
+	writeLine(b, "DTEND;VALUE=DATE:"+e.End.Format(dateLayout))
+	writeLine(b, "SUMMARY:"+escapeText(e.Summary))
+
+	if e.Location != "" {
+		writeLine(b, "LOCATION:"+escapeText(e.Location))
+	}
+
+	if e.Description != "" {
+		writeLine(b, "DESCRIPTION:"+escapeText(e.Description))
+	}
+
+	if e.Status != "" {
+		writeLine(b, "STATUS:"+e.Status)
+	}
+
+	writeLine(b, fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+	// stays do not make their guests busy
+	writeLine(b, "TRANSP:TRANSPARENT")
+	writeLine(b, "END:VEVENT")
+}
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeLine writes the content line folded to lines of maxLineLen octets, a multi-octet character is not split
+func writeLine(b *bytes.Buffer, line string) {
+	limit := maxLineLen
+
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+
+		b.WriteString(line[:i])
+		b.WriteString("\r\n ")
+
+		line = line[i:]
+
+		// the leading space of a continuation line counts
+		limit = maxLineLen - 1
+	}
+
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
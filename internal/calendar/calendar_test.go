@@ -0,0 +1,70 @@
+package calendar
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Render(t *testing.T) {
+	c := Calendar{
+		ProdID: "-//Hotel California//Reservations//EN",
+		Name:   "Hotel California",
+		Events: []Event{{
+			UID:         "HC7K2M9Q@hotel-california",
+			Summary:     "Hotel California, İzmir",
+			Description: "PNR: HC7K2M9Q\nAccommodation: beach; guests: 2",
+			Location:    "İzmir",
+			Start:       time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC),
+			End:         time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC),
+			Status:      StatusConfirmed,
+			Sequence:    2,
+			Stamp:       time.Date(2024, 3, 20, 9, 15, 0, 0, time.FixedZone("TRT", 3*60*60)),
+		}},
+	}
+
+	assert.Equal(t, strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Hotel California//Reservations//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Hotel California",
+		"BEGIN:VEVENT",
+		"UID:HC7K2M9Q@hotel-california",
+		"DTSTAMP:20240320T061500Z",
+		"DTSTART;VALUE=DATE:20240324",
+		"DTEND;VALUE=DATE:20240330",
+		`SUMMARY:Hotel California\, İzmir`,
+		"LOCATION:İzmir",
+		`DESCRIPTION:PNR: HC7K2M9Q\nAccommodation: beach\; guests: 2`,
+		"STATUS:CONFIRMED",
+		"SEQUENCE:2",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n"), string(c.Render()))
+}
+
+func TestWriteLine_FoldsLongLines(t *testing.T) {
+	var c Calendar
+	c.Name = strings.Repeat("ş", 60)
+
+	lines := strings.Split(strings.TrimSuffix(string(c.Render()), "\r\n"), "\r\n")
+
+	var unfolded strings.Builder
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineLen)
+
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+			continue
+		}
+
+		unfolded.WriteString("\n" + line)
+	}
+
+	assert.Contains(t, unfolded.String(), "\nX-WR-CALNAME:"+c.Name+"\n")
+}
@@ -0,0 +1,122 @@
+// Package document renders printable documents of reservations as PDF files
+package document
+
+import (
+	"bytes"
+	_ "embed"
+	"github.com/jung-kurt/gofpdf"
+	"time"
+)
+
+// ContentType is the media type of rendered documents
+const ContentType = "application/pdf"
+
+const (
+	fontFamily = "DejaVu"
+
+	pageMargin  = 20.0
+	lineHeight  = 7.0
+	labelWidth  = 60.0
+	amountWidth = 45.0
+)
+
+// DejaVu Sans covers the alphabets of every language the service is localized to, see fonts/README.md
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	regularFont []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	boldFont []byte
+)
+
+// Field represents a labelled value
+type Field struct {
+	Label string
+	Value string
+}
+
+// Line represents a line of an amount, amounts are formatted by the caller
+type Line struct {
+	Description string
+	Amount      string
+}
+
+// Document represents a document of the issuer with its details, lines of charges and totals. Texts are rendered as
+// they are given, so that they are localized by the caller. Date is the creation date of the file.
+type Document struct {
+	Issuer  string
+	Title   string
+	Details []Field
+	Columns Line
+	Lines   []Line
+	Totals  []Line
+	Footer  string
+	Date    time.Time
+}
+
+// PDF renders the document on A4 pages
+func (d Document) PDF() ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", regularFont)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", boldFont)
+	pdf.SetTitle(d.Title, true)
+	pdf.SetAuthor(d.Issuer, true)
+	pdf.SetCreationDate(d.Date)
+	pdf.SetModificationDate(d.Date)
+	pdf.AddPage()
+
+	width, _ := pdf.GetPageSize()
+	width -= 2 * pageMargin
+
+	pdf.SetFont(fontFamily, "B", 18)
+	pdf.CellFormat(width, 10, d.Issuer, "", 1, "L", false, 0, "")
+
+	pdf.SetFont(fontFamily, "", 13)
+	pdf.CellFormat(width, 8, d.Title, "B", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont(fontFamily, "", 10)
+	for _, f := range d.Details {
+		pdf.SetFont(fontFamily, "B", 10)
+		pdf.CellFormat(labelWidth, lineHeight, f.Label, "", 0, "L", false, 0, "")
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.MultiCell(width-labelWidth, lineHeight, f.Value, "", "L", false)
+	}
+
+	pdf.Ln(6)
+
+	pdf.SetFont(fontFamily, "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	pdf.CellFormat(width-amountWidth, lineHeight, d.Columns.Description, "1", 0, "L", true, 0, "")
+	pdf.CellFormat(amountWidth, lineHeight, d.Columns.Amount, "1", 1, "R", true, 0, "")
+
+	pdf.SetFont(fontFamily, "", 10)
+	for _, l := range d.Lines {
+		pdf.CellFormat(width-amountWidth, lineHeight, l.Description, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(amountWidth, lineHeight, l.Amount, "1", 1, "R", false, 0, "")
+	}
+
+	for i, l := range d.Totals {
+		// the last total is the grand total
+		if i == len(d.Totals)-1 {
+			pdf.SetFont(fontFamily, "B", 11)
+		}
+
+		pdf.CellFormat(width-amountWidth, lineHeight, l.Description, "", 0, "R", false, 0, "")
+		pdf.CellFormat(amountWidth, lineHeight, l.Amount, "1", 1, "R", false, 0, "")
+	}
+
+	if d.Footer != "" {
+		pdf.Ln(10)
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.MultiCell(width, 5, d.Footer, "", "L", false)
+	}
+
+	var b bytes.Buffer
+	if err := pdf.Output(&b); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
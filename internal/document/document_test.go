@@ -0,0 +1,39 @@
+package document
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDocument_PDF(t *testing.T) {
+	d := Document{
+		Issuer: "Hotel California",
+		Title:  "Rezervasyon onayı ve fatura",
+		Details: []Field{
+			{Label: "Misafir", Value: "Işıl Güneş"},
+			{Label: "PNR", Value: "HC7K2M9Q"},
+		},
+		Columns: Line{Description: "Açıklama", Amount: "Tutar"},
+		Lines:   []Line{{Description: "beach konaklaması, 6 gece × 3.500,00 TRY", Amount: "21.000,00 TRY"}},
+		Totals: []Line{
+			{Description: "KDV (%10)", Amount: "2.100,00 TRY"},
+			{Description: "Toplam", Amount: "23.520,00 TRY"},
+		},
+		Footer: "Teşekkür ederiz.",
+		Date:   time.Date(2024, 3, 20, 9, 15, 0, 0, time.UTC),
+	}
+
+	b, err := d.PDF()
+	require.NoError(t, err)
+
+	assert.True(t, bytes.HasPrefix(b, []byte("%PDF-")))
+	assert.True(t, bytes.HasSuffix(bytes.TrimSpace(b), []byte("%%EOF")))
+
+	// documents of the same content are the same
+	again, err := d.PDF()
+	require.NoError(t, err)
+	assert.Equal(t, b, again)
+}
@@ -0,0 +1,143 @@
+// Package encryption encrypts sensitive values before they are stored, e.g. the identity documents of guests
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// KeySize is the size of keys in bytes, values are encrypted with AES-256
+const KeySize = 32
+
+// versionPrefix starts the version of the key a value is encrypted with, e.g. "v2:" precedes the values encrypted with
+// the key of version 2
+const versionPrefix = "v"
+
+// errors
+var (
+	ErrInvalidKey        = errors.New("invalid encryption key")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	ErrUnknownKeyVersion = errors.New("unknown key version")
+)
+
+// Cipher encrypts values with AES-GCM, a value is encrypted with a random nonce every time, so the same values are
+// not told apart by their ciphertexts. Values are encrypted with the current key and prefixed with its version, the
+// values encrypted with the keys it replaced are decrypted with the key of their version, so keys are rotated without
+// encrypting the stored values again.
+type Cipher struct {
+	version int
+	aeads   map[int]cipher.AEAD
+}
+
+// NewCipher creates and returns cipher encrypting with the base64 encoded key of the version, oldKeys are
+// version:key pairs of the keys the values encrypted before are decrypted with
+func NewCipher(version int, key string, oldKeys []string) (*Cipher, error) {
+	if version <= 0 {
+		return nil, fmt.Errorf("%w, the key version has to be positive", ErrInvalidKey)
+	}
+
+	c := &Cipher{version: version, aeads: map[int]cipher.AEAD{}}
+
+	if err := c.add(version, key); err != nil {
+		return nil, err
+	}
+
+	for _, k := range oldKeys {
+		v, old, ok := strings.Cut(strings.TrimSpace(k), ":")
+		if !ok {
+			return nil, fmt.Errorf("%w, old keys have to be version:key pairs", ErrInvalidKey)
+		}
+
+		ver, err := strconv.Atoi(v)
+		if err != nil || ver <= 0 {
+			return nil, fmt.Errorf("%w, the key version %s has to be positive", ErrInvalidKey, v)
+		}
+
+		if _, ok := c.aeads[ver]; ok {
+			return nil, fmt.Errorf("%w, the key version %d is given twice", ErrInvalidKey, ver)
+		}
+
+		if err = c.add(ver, old); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// add adds the base64 encoded key of the version
+func (c *Cipher) add(version int, key string) error {
+	k, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(k) != KeySize {
+		return fmt.Errorf("%w, the key has to be %d bytes encoded with base64", ErrInvalidKey, KeySize)
+	}
+
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	c.aeads[version] = aead
+
+	return nil
+}
+
+// Encrypt returns the version of the current key and the base64 encoded nonce and ciphertext of the value, e.g.
+// "v1:bm9uY2U..."
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	aead := c.aeads[c.version]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return versionPrefix + strconv.Itoa(c.version) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the value of the ciphertext returned by Encrypt with the key of its version, ciphertexts of unknown
+// keys and changed ciphertexts are not decrypted
+func (c *Cipher) Decrypt(ciphertext string) (string, error) {
+	v, encoded, ok := strings.Cut(ciphertext, ":")
+	if !ok || !strings.HasPrefix(v, versionPrefix) {
+		return "", ErrInvalidCiphertext
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(v, versionPrefix))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	aead, ok := c.aeads[version]
+	if !ok {
+		return "", fmt.Errorf("%w, %d", ErrUnknownKeyVersion, version)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("%w, %s", ErrInvalidCiphertext, err.Error())
+	}
+
+	return string(plaintext), nil
+}
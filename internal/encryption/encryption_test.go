@@ -0,0 +1,84 @@
+package encryption
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+const (
+	testKey  = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	otherKey = "QUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUE="
+)
+
+func TestCipher(t *testing.T) {
+	c, err := NewCipher(1, testKey, nil)
+	require.NoError(t, err)
+
+	encrypted, err := c.Encrypt("U12345678")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "v1:"))
+	assert.NotContains(t, encrypted, "U12345678")
+
+	// the same value is encrypted differently every time
+	again, err := c.Encrypt("U12345678")
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	decrypted, err := c.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "U12345678", decrypted)
+
+	// ciphertexts of other keys of the same version are not decrypted
+	other, err := NewCipher(1, otherKey, nil)
+	require.NoError(t, err)
+
+	_, err = other.Decrypt(encrypted)
+	assert.True(t, errors.Is(err, ErrInvalidCiphertext))
+
+	_, err = c.Decrypt("v1:bm90IGVuY3J5cHRlZA==")
+	assert.True(t, errors.Is(err, ErrInvalidCiphertext))
+
+	_, err = c.Decrypt("bm90IGVuY3J5cHRlZA==")
+	assert.True(t, errors.Is(err, ErrInvalidCiphertext))
+}
+
+func TestCipher_Rotation(t *testing.T) {
+	old, err := NewCipher(1, testKey, nil)
+	require.NoError(t, err)
+
+	encrypted, err := old.Encrypt("U12345678")
+	require.NoError(t, err)
+
+	c, err := NewCipher(2, otherKey, []string{"1:" + testKey})
+	require.NoError(t, err)
+
+	// values of the replaced key are decrypted, new values are encrypted with the current key
+	decrypted, err := c.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "U12345678", decrypted)
+
+	rotated, err := c.Encrypt("U12345678")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rotated, "v2:"))
+
+	_, err = old.Decrypt(rotated)
+	assert.True(t, errors.Is(err, ErrUnknownKeyVersion))
+}
+
+func TestNewCipher_InvalidKey(t *testing.T) {
+	for _, key := range []string{"", "not base64", "c2hvcnQ="} {
+		_, err := NewCipher(1, key, nil)
+		assert.True(t, errors.Is(err, ErrInvalidKey), key)
+	}
+
+	for _, oldKeys := range [][]string{{testKey}, {"0:" + testKey}, {"2:" + otherKey}, {"3:c2hvcnQ="}} {
+		_, err := NewCipher(2, otherKey, oldKeys)
+		assert.True(t, errors.Is(err, ErrInvalidKey), oldKeys)
+	}
+
+	_, err := NewCipher(0, testKey, nil)
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+}
@@ -8,23 +8,69 @@ import (
 
 // Endpoints represents service endpoints
 type Endpoints struct {
-	HealthEndpoint            endpoint.Endpoint
-	SignInEndpoint            endpoint.Endpoint
-	CreateReservationEndpoint endpoint.Endpoint
-	UpdateReservationEndpoint endpoint.Endpoint
-	FindReservationEndpoint   endpoint.Endpoint
-	FindReservationsEndpoint  endpoint.Endpoint
+	HealthEndpoint             endpoint.Endpoint
+	LivenessEndpoint           endpoint.Endpoint
+	ReadinessEndpoint          endpoint.Endpoint
+	SignInEndpoint             endpoint.Endpoint
+	CreateReservationEndpoint  endpoint.Endpoint
+	UpdateReservationEndpoint  endpoint.Endpoint
+	CancelReservationEndpoint  endpoint.Endpoint
+	ConfirmReservationEndpoint endpoint.Endpoint
+	FindReservationEndpoint    endpoint.Endpoint
+	FindReservationsEndpoint   endpoint.Endpoint
+
+	FindReservationHistoryEndpoint endpoint.Endpoint
+
+	CreateWebhookSubscriptionEndpoint endpoint.Endpoint
+	FindWebhookSubscriptionsEndpoint  endpoint.Endpoint
+	DeleteWebhookSubscriptionEndpoint endpoint.Endpoint
+	FindWebhookDeliveriesEndpoint     endpoint.Endpoint
+	RedeliverWebhookDeliveryEndpoint  endpoint.Endpoint
+
+	CheckInReservationEndpoint endpoint.Endpoint
+
+	ExportReservationsEndpoint         endpoint.Endpoint
+	ExportReservationEndpoint          endpoint.Endpoint
+	CreateCalendarSubscriptionEndpoint endpoint.Endpoint
+	RevokeCalendarSubscriptionEndpoint endpoint.Endpoint
+
+	ExportReservationConfirmationEndpoint endpoint.Endpoint
+
+	LookupReservationEndpoint endpoint.Endpoint
 }
 
 // MakeEndpoints makes and returns endpoints
 func MakeEndpoints(s hotelcalifornia.Service) Endpoints {
 	return Endpoints{
-		HealthEndpoint:            MakeHealthEndpoint(s),
-		SignInEndpoint:            MakeSignInEndpoint(s),
-		CreateReservationEndpoint: MakeCreateReservationEndpoint(s),
-		UpdateReservationEndpoint: MakeUpdateReservationEndpoint(s),
-		FindReservationEndpoint:   MakeFindReservationEndpoint(s),
-		FindReservationsEndpoint:  MakeFindReservationsEndpoint(s),
+		HealthEndpoint:             MakeHealthEndpoint(s),
+		LivenessEndpoint:           MakeLivenessEndpoint(s),
+		ReadinessEndpoint:          MakeReadinessEndpoint(s),
+		SignInEndpoint:             MakeSignInEndpoint(s),
+		CreateReservationEndpoint:  MakeCreateReservationEndpoint(s),
+		UpdateReservationEndpoint:  MakeUpdateReservationEndpoint(s),
+		CancelReservationEndpoint:  MakeCancelReservationEndpoint(s),
+		ConfirmReservationEndpoint: MakeConfirmReservationEndpoint(s),
+		FindReservationEndpoint:    MakeFindReservationEndpoint(s),
+		FindReservationsEndpoint:   MakeFindReservationsEndpoint(s),
+
+		FindReservationHistoryEndpoint: MakeFindReservationHistoryEndpoint(s),
+
+		CreateWebhookSubscriptionEndpoint: MakeCreateWebhookSubscriptionEndpoint(s),
+		FindWebhookSubscriptionsEndpoint:  MakeFindWebhookSubscriptionsEndpoint(s),
+		DeleteWebhookSubscriptionEndpoint: MakeDeleteWebhookSubscriptionEndpoint(s),
+		FindWebhookDeliveriesEndpoint:     MakeFindWebhookDeliveriesEndpoint(s),
+		RedeliverWebhookDeliveryEndpoint:  MakeRedeliverWebhookDeliveryEndpoint(s),
+
+		CheckInReservationEndpoint: MakeCheckInReservationEndpoint(s),
+
+		ExportReservationsEndpoint:         MakeExportReservationsEndpoint(s),
+		ExportReservationEndpoint:          MakeExportReservationEndpoint(s),
+		CreateCalendarSubscriptionEndpoint: MakeCreateCalendarSubscriptionEndpoint(s),
+		RevokeCalendarSubscriptionEndpoint: MakeRevokeCalendarSubscriptionEndpoint(s),
+
+		ExportReservationConfirmationEndpoint: MakeExportReservationConfirmationEndpoint(s),
+
+		LookupReservationEndpoint: MakeLookupReservationEndpoint(s),
 	}
 }
 
@@ -39,6 +85,28 @@ func MakeHealthEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
 	}
 }
 
+// MakeLivenessEndpoint makes and returns liveness endpoint
+func MakeLivenessEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.LivenessRequest)
+
+		res := s.Liveness(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeReadinessEndpoint makes and returns readiness endpoint
+func MakeReadinessEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.ReadinessRequest)
+
+		res := s.Readiness(ctx, *req)
+
+		return res, nil
+	}
+}
+
 // MakeSignInEndpoint makes and returns sign in endpoint
 func MakeSignInEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
@@ -72,6 +140,28 @@ func MakeUpdateReservationEndpoint(s hotelcalifornia.Service) endpoint.Endpoint
 	}
 }
 
+// MakeCancelReservationEndpoint makes and returns cancel reservation endpoint
+func MakeCancelReservationEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.CancelReservationRequest)
+
+		res := s.CancelReservation(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeConfirmReservationEndpoint makes and returns confirm reservation endpoint
+func MakeConfirmReservationEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.ConfirmReservationRequest)
+
+		res := s.ConfirmReservation(ctx, *req)
+
+		return res, nil
+	}
+}
+
 // MakeFindReservationEndpoint makes and returns find reservation endpoint
 func MakeFindReservationEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
@@ -93,3 +183,146 @@ func MakeFindReservationsEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
 		return res, nil
 	}
 }
+
+// MakeFindReservationHistoryEndpoint makes and returns find reservation history endpoint
+func MakeFindReservationHistoryEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.FindReservationHistoryRequest)
+
+		res := s.FindReservationHistory(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeCreateWebhookSubscriptionEndpoint makes and returns create webhook subscription endpoint
+func MakeCreateWebhookSubscriptionEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.CreateWebhookSubscriptionRequest)
+
+		res := s.CreateWebhookSubscription(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeFindWebhookSubscriptionsEndpoint makes and returns find webhook subscriptions endpoint
+func MakeFindWebhookSubscriptionsEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.FindWebhookSubscriptionsRequest)
+
+		res := s.FindWebhookSubscriptions(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeDeleteWebhookSubscriptionEndpoint makes and returns delete webhook subscription endpoint
+func MakeDeleteWebhookSubscriptionEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.DeleteWebhookSubscriptionRequest)
+
+		res := s.DeleteWebhookSubscription(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeFindWebhookDeliveriesEndpoint makes and returns find webhook deliveries endpoint
+func MakeFindWebhookDeliveriesEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.FindWebhookDeliveriesRequest)
+
+		res := s.FindWebhookDeliveries(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeRedeliverWebhookDeliveryEndpoint makes and returns redeliver webhook delivery endpoint
+func MakeRedeliverWebhookDeliveryEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.RedeliverWebhookDeliveryRequest)
+
+		res := s.RedeliverWebhookDelivery(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeCheckInReservationEndpoint makes and returns check in reservation endpoint
+func MakeCheckInReservationEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.CheckInReservationRequest)
+
+		res := s.CheckInReservation(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeExportReservationsEndpoint makes and returns export reservations endpoint
+func MakeExportReservationsEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.ExportReservationsRequest)
+
+		res := s.ExportReservations(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeExportReservationEndpoint makes and returns export reservation endpoint
+func MakeExportReservationEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.ExportReservationRequest)
+
+		res := s.ExportReservation(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeCreateCalendarSubscriptionEndpoint makes and returns create calendar subscription endpoint
+func MakeCreateCalendarSubscriptionEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.CreateCalendarSubscriptionRequest)
+
+		res := s.CreateCalendarSubscription(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeRevokeCalendarSubscriptionEndpoint makes and returns revoke calendar subscription endpoint
+func MakeRevokeCalendarSubscriptionEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.RevokeCalendarSubscriptionRequest)
+
+		res := s.RevokeCalendarSubscription(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeExportReservationConfirmationEndpoint makes and returns export reservation confirmation endpoint
+func MakeExportReservationConfirmationEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.ExportReservationConfirmationRequest)
+
+		res := s.ExportReservationConfirmation(ctx, *req)
+
+		return res, nil
+	}
+}
+
+// MakeLookupReservationEndpoint makes and returns lookup reservation endpoint
+func MakeLookupReservationEndpoint(s hotelcalifornia.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*hotelcalifornia.LookupReservationRequest)
+
+		res := s.LookupReservation(ctx, *req)
+
+		return res, nil
+	}
+}
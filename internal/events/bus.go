@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Handler handles events published on the bus
+type Handler func(ctx context.Context, e Event) error
+
+// Bus publishes events to the handlers subscribed in the same process
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus creates and returns bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds the handler, it is called with every event published afterwards
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish calls every handler with the event, the event fails when any of them fails
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	var errs []error
+
+	for _, h := range handlers {
+		errs = append(errs, h(ctx, e))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,59 @@
+// Package events defines the domain events of the service and the publishers they are delivered with
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// reservation event types, ReservationReminderDue is written by the scheduler days before check-in and stays are
+// completed or marked as no-shows by the scheduler
+const (
+	ReservationCreated     = "ReservationCreated"
+	ReservationUpdated     = "ReservationUpdated"
+	ReservationCancelled   = "ReservationCancelled"
+	ReservationConfirmed   = "ReservationConfirmed"
+	ReservationReminderDue = "ReservationReminderDue"
+	ReservationCheckedIn   = "ReservationCheckedIn"
+	ReservationCompleted   = "ReservationCompleted"
+	ReservationNoShow      = "ReservationNoShow"
+)
+
+// Event represents a domain event, events are delivered at least once so consumers deduplicate them by ID
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregateId"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// ReservationData represents the reservation carried by reservation events, Changes holds the changed fields with
+// their values before and after the change. Language is the language of the request that made the change.
+type ReservationData struct {
+	PNR           string          `json:"pnr"`
+	UserID        int64           `json:"userId"`
+	Destination   string          `json:"destination"`
+	CheckInDate   string          `json:"checkInDate"`
+	CheckOutDate  string          `json:"checkOutDate"`
+	Accommodation string          `json:"accommodation"`
+	GuestCount    int             `json:"guestCount"`
+	IsActive      bool            `json:"isActive"`
+	Status        string          `json:"status"`
+	Version       int64           `json:"version"`
+	Language      string          `json:"language,omitempty"`
+	Changes       json.RawMessage `json:"changes,omitempty"`
+}
+
+// Publisher defines behaviors of event publishers
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// compile-time proofs of publisher interface implementation
+var (
+	_ Publisher = (*Bus)(nil)
+	_ Publisher = (*FilePublisher)(nil)
+	_ Publisher = (*HTTPPublisher)(nil)
+)
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newEvent(id string) Event {
+	return Event{
+		ID:          id,
+		Type:        ReservationCreated,
+		AggregateID: "XYZ23456",
+		OccurredAt:  time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC),
+		Data:        json.RawMessage(`{"pnr":"XYZ23456"}`),
+	}
+}
+
+func TestHTTPPublisher_Publish(t *testing.T) {
+	var got Event
+	status := http.StatusAccepted
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, ReservationCreated, r.Header.Get(HeaderEventType))
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		assert.Equal(t, got.ID, r.Header.Get(HeaderEventID))
+
+		rw.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	p := NewHTTPPublisher(srv.URL, time.Second)
+
+	require.NoError(t, p.Publish(context.Background(), newEvent("2f1c8a3e")))
+	assert.Equal(t, newEvent("2f1c8a3e"), got)
+
+	status = http.StatusInternalServerError
+
+	err := p.Publish(context.Background(), newEvent("5b7d9e1f"))
+	assert.True(t, errors.Is(err, ErrUnexpectedStatus))
+}
+
+func TestFilePublisher_Publish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	p, err := NewFilePublisher(path)
+	require.NoError(t, err)
+
+	require.NoError(t, p.Publish(context.Background(), newEvent("2f1c8a3e")))
+	require.NoError(t, p.Publish(context.Background(), newEvent("5b7d9e1f")))
+	require.NoError(t, p.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var ids []string
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &e))
+
+		ids = append(ids, e.ID)
+	}
+
+	assert.Equal(t, []string{"2f1c8a3e", "5b7d9e1f"}, ids)
+}
+
+func TestBus_Publish(t *testing.T) {
+	bus := NewBus()
+
+	var handled []string
+	bus.Subscribe(func(_ context.Context, e Event) error {
+		handled = append(handled, e.ID)
+		return nil
+	})
+
+	failing := errors.New("endpoint is down")
+	bus.Subscribe(func(context.Context, Event) error {
+		return failing
+	})
+
+	err := bus.Publish(context.Background(), newEvent("2f1c8a3e"))
+
+	assert.True(t, errors.Is(err, failing))
+	assert.Equal(t, []string{"2f1c8a3e"}, handled)
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// FilePublisher appends events to a file as newline delimited json
+type FilePublisher struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFilePublisher creates and returns file publisher appending to the file at path
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilePublisher{f: f}, nil
+}
+
+// Publish appends the event as a line
+func (p *FilePublisher) Publish(_ context.Context, e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.f.Write(append(line, '\n'))
+
+	return err
+}
+
+// Close closes the file
+func (p *FilePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.f.Close()
+}
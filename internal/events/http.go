@@ -0,0 +1,68 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// http headers sent with events
+const (
+	HeaderEventID   = "X-Event-ID"
+	HeaderEventType = "X-Event-Type"
+)
+
+// ErrUnexpectedStatus is returned when the endpoint does not answer an event with a 2xx status
+var ErrUnexpectedStatus = errors.New("unexpected http status")
+
+// HTTPPublisher posts events as json to an endpoint
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPPublisher creates and returns http publisher posting to url, waiting at most timeout for every event
+func NewHTTPPublisher(url string, timeout time.Duration) *HTTPPublisher {
+	return &HTTPPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish posts the event, any status other than 2xx fails it
+func (p *HTTPPublisher) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, e.ID)
+	req.Header.Set(HeaderEventType, e.Type)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	// the body is drained so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%w %d from %s", ErrUnexpectedStatus, res.StatusCode, p.url)
+	}
+
+	return nil
+}
@@ -4,4 +4,69 @@ const (
 	CouldNotCreateReservation               = "could-not-create-reservation"
 	CouldNotChangeReservationCheckInDate    = "could-not-change-reservation-check-in-date"
 	CouldNotCheckInGreaterThanCheckoutError = "could-not-check-in-greater-than-check-out"
+	IdempotencyKeyMismatch                  = "idempotency-key-mismatch"
+	IdempotencyKeyInProgress                = "idempotency-key-in-progress"
+	ReservationPreconditionRequired         = "reservation-precondition-required"
+	ReservationModified                     = "reservation-modified"
+	NotFound                                = "default-not-found-error-message"
+	Forbidden                               = "default-forbidden-error-message"
+	Conflict                                = "default-conflict-error-message"
+	ConstraintViolation                     = "default-constraint-violation-error-message"
+	ServiceUnavailable                      = "default-service-unavailable-error-message"
+	InvalidCredentials                      = "invalid-credentials"
+	ReservationAlreadyCancelled             = "reservation-already-cancelled"
+	CouldNotCancelPastReservation           = "could-not-cancel-past-reservation"
+	ReservationAlreadyCheckedIn             = "reservation-already-checked-in"
+	CouldNotCheckInReservation              = "could-not-check-in-reservation"
+	TooManyRequests                         = "too-many-requests"
+)
+
+// validation message keys
+const (
+	ValidationError = "validation-error"
+
+	// ValidationFieldPrefix prefixes per-rule field messages, e.g. validation-field-required
+	ValidationFieldPrefix  = "validation-field-"
+	ValidationFieldInvalid = "validation-field-invalid"
+)
+
+// email message keys, bodies and subjects are rendered with the reservation as template data
+const (
+	EmailReservationCreatedSubject   = "email-reservation-created-subject"
+	EmailReservationCreatedBody      = "email-reservation-created-body"
+	EmailReservationUpdatedSubject   = "email-reservation-updated-subject"
+	EmailReservationUpdatedBody      = "email-reservation-updated-body"
+	EmailReservationCancelledSubject = "email-reservation-cancelled-subject"
+	EmailReservationCancelledBody    = "email-reservation-cancelled-body"
+	EmailReservationReminderSubject  = "email-reservation-reminder-subject"
+	EmailReservationReminderBody     = "email-reservation-reminder-body"
+)
+
+// calendar message keys, events are rendered with the reservation as template data
+const (
+	CalendarName             = "calendar-name"
+	CalendarEventSummary     = "calendar-event-summary"
+	CalendarEventDescription = "calendar-event-description"
+)
+
+// document message keys of reservation confirmations and invoices
+const (
+	DocumentTitle            = "document-title"
+	DocumentPNR              = "document-pnr"
+	DocumentGuest            = "document-guest"
+	DocumentDestination      = "document-destination"
+	DocumentCheckIn          = "document-check-in"
+	DocumentCheckOut         = "document-check-out"
+	DocumentAccommodation    = "document-accommodation"
+	DocumentGuestCount       = "document-guest-count"
+	DocumentInvoiceNumber    = "document-invoice-number"
+	DocumentInvoiceDate      = "document-invoice-date"
+	DocumentDescription      = "document-description"
+	DocumentAmount           = "document-amount"
+	DocumentNights           = "document-nights"
+	DocumentSubtotal         = "document-subtotal"
+	DocumentVAT              = "document-vat"
+	DocumentAccommodationTax = "document-accommodation-tax"
+	DocumentTotal            = "document-total"
+	DocumentFooter           = "document-footer"
 )
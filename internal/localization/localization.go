@@ -2,6 +2,7 @@ package localization
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"golang.org/x/text/language"
@@ -9,11 +10,18 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 )
 
 // global bundle
 var bundle *i18n.Bundle
 
+// bundleLoaded reports whether every language file has been loaded into the bundle
+var bundleLoaded atomic.Bool
+
+// ErrBundleNotLoaded is returned while language files are not loaded
+var ErrBundleNotLoaded = errors.New("localization bundle is not loaded")
+
 // default language
 var defaultLanguage = language.Turkish
 
@@ -24,6 +32,8 @@ type Localizer interface {
 
 // InitializeBundle initializes bundle
 func InitializeBundle(l envvars.Localization) error {
+	bundleLoaded.Store(false)
+
 	bundle = i18n.NewBundle(defaultLanguage)
 
 	currentDir, err := os.Getwd()
@@ -49,6 +59,17 @@ func InitializeBundle(l envvars.Localization) error {
 		}
 	}
 
+	bundleLoaded.Store(true)
+
+	return nil
+}
+
+// CheckBundle returns ErrBundleNotLoaded unless the bundle is initialized with its language files
+func CheckBundle() error {
+	if !bundleLoaded.Load() {
+		return ErrBundleNotLoaded
+	}
+
 	return nil
 }
 
@@ -89,3 +110,40 @@ func Localize(l *i18n.Localizer, messageID string, defaultMessage string) string
 
 	return localizedMessage
 }
+
+// LocalizeWithTemplateData returns localized message rendered with template data or default message
+func LocalizeWithTemplateData(l *i18n.Localizer, messageID string, templateData map[string]interface{}, defaultMessage string) string {
+	localizedMessage, err := l.Localize(&i18n.LocalizeConfig{
+		MessageID:    messageID,
+		TemplateData: templateData,
+	})
+	if err != nil {
+		return defaultMessage
+	}
+
+	return localizedMessage
+}
+
+// LocalizeMessage returns the message rendered with template data in the language, the default language is used for
+// unknown languages. Unlike Localize it fails for missing messages.
+func LocalizeMessage(languageCode, messageID string, templateData map[string]interface{}) (string, error) {
+	if err := CheckBundle(); err != nil {
+		return "", err
+	}
+
+	return NewLocalizerWithLanguageCode(languageCode).Localize(&i18n.LocalizeConfig{
+		MessageID:    messageID,
+		TemplateData: templateData,
+	})
+}
+
+// PreferredLanguage returns the most preferred language of an Accept-Language header value, it is empty when the value
+// names no valid language
+func PreferredLanguage(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+
+	return tags[0].String()
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/go-kit/log"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces values of sensitive fields
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively as substrings of log keys
+var sensitiveKeys = []string{
+	"token",
+	"password",
+	"secret",
+	"authorization",
+	"cookie",
+	"apikey",
+	"api_key",
+	"credential",
+}
+
+// jwtPattern matches json web tokens embedded in any logged value
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// compile-time proof of logger interface implementation
+var _ log.Logger = (*RedactingLogger)(nil)
+
+// RedactingLogger represents logger removing secrets before they are written
+type RedactingLogger struct {
+	next log.Logger
+}
+
+// NewRedactingLogger creates and returns logger redacting values of sensitive keys and tokens found in values
+func NewRedactingLogger(next log.Logger) *RedactingLogger {
+	return &RedactingLogger{
+		next: next,
+	}
+}
+
+// Log redacts key values and passes them to the next logger
+func (rl *RedactingLogger) Log(keyvals ...interface{}) error {
+	redacted := make([]interface{}, len(keyvals))
+	copy(redacted, keyvals)
+
+	for i := 0; i+1 < len(redacted); i += 2 {
+		if sensitiveKey(fmt.Sprint(redacted[i])) {
+			redacted[i+1] = Redacted
+			continue
+		}
+
+		redacted[i+1] = redactValue(redacted[i+1])
+	}
+
+	return rl.next.Log(redacted...)
+}
+
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactValue(v interface{}) interface{} {
+	var s string
+
+	switch t := v.(type) {
+	case string:
+		s = t
+	case error:
+		s = t.Error()
+	case fmt.Stringer:
+		s = t.String()
+	default:
+		return v
+	}
+
+	if !jwtPattern.MatchString(s) {
+		return v
+	}
+
+	return jwtPattern.ReplaceAllString(s, Redacted)
+}
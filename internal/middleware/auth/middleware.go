@@ -10,6 +10,8 @@ import (
 	"hotel-california-backend/configs/envvars"
 	apierror "hotel-california-backend/internal/api-error"
 	"hotel-california-backend/internal/middleware"
+	"hotel-california-backend/internal/requestid"
+	"hotel-california-backend/internal/tracing"
 	"strconv"
 	"time"
 )
@@ -43,6 +45,16 @@ func (m *AuthMiddleware) Health(ctx context.Context, req hotelcalifornia.HealthR
 	return m.next.Health(ctx, req)
 }
 
+// Liveness represents auth middleware's liveness method
+func (m *AuthMiddleware) Liveness(ctx context.Context, req hotelcalifornia.LivenessRequest) (res hotelcalifornia.LivenessResponse) {
+	return m.next.Liveness(ctx, req)
+}
+
+// Readiness represents auth middleware's readiness method
+func (m *AuthMiddleware) Readiness(ctx context.Context, req hotelcalifornia.ReadinessRequest) (res hotelcalifornia.ReadinessResponse) {
+	return m.next.Readiness(ctx, req)
+}
+
 // SignIn represents auth middleware's sign in method
 func (m *AuthMiddleware) SignIn(ctx context.Context, req hotelcalifornia.SignInRequest) hotelcalifornia.SignInResponse {
 	return m.next.SignIn(ctx, req)
@@ -50,12 +62,11 @@ func (m *AuthMiddleware) SignIn(ctx context.Context, req hotelcalifornia.SignInR
 
 func (m *AuthMiddleware) CreateReservation(ctx context.Context, req hotelcalifornia.CreateReservationRequest) (res hotelcalifornia.CreateReservationResponse) {
 	token := req.Token
-	userId, err := m.isTokenValid(token)
+	userId, err := m.isTokenValid(ctx, token)
 	if err != nil {
-		m.log(err, map[string]interface{}{
+		m.log(ctx, err, map[string]interface{}{
 			"method": "CreateReservation",
 			"action": "isTokenValid",
-			"token":  token,
 			"error":  err.Error(),
 		})
 
@@ -70,12 +81,11 @@ func (m *AuthMiddleware) CreateReservation(ctx context.Context, req hotelcalifor
 
 func (m *AuthMiddleware) UpdateReservation(ctx context.Context, req hotelcalifornia.UpdateReservationRequest) (res hotelcalifornia.UpdateReservationResponse) {
 	token := req.Token
-	userId, err := m.isTokenValid(token)
+	userId, err := m.isTokenValid(ctx, token)
 	if err != nil {
-		m.log(err, map[string]interface{}{
+		m.log(ctx, err, map[string]interface{}{
 			"method": "UpdateReservation",
 			"action": "isTokenValid",
-			"token":  token,
 			"error":  err.Error(),
 		})
 
@@ -88,14 +98,70 @@ func (m *AuthMiddleware) UpdateReservation(ctx context.Context, req hotelcalifor
 	return m.next.UpdateReservation(ctx, req)
 }
 
+func (m *AuthMiddleware) CancelReservation(ctx context.Context, req hotelcalifornia.CancelReservationRequest) (res hotelcalifornia.CancelReservationResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "CancelReservation",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.CancelReservation(ctx, req)
+}
+
+func (m *AuthMiddleware) ConfirmReservation(ctx context.Context, req hotelcalifornia.ConfirmReservationRequest) (res hotelcalifornia.ConfirmReservationResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "ConfirmReservation",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.ConfirmReservation(ctx, req)
+}
+
+func (m *AuthMiddleware) FindReservationHistory(ctx context.Context, req hotelcalifornia.FindReservationHistoryRequest) (res hotelcalifornia.FindReservationHistoryResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "FindReservationHistory",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.FindReservationHistory(ctx, req)
+}
+
 func (m *AuthMiddleware) FindReservation(ctx context.Context, req hotelcalifornia.FindReservationRequest) (res hotelcalifornia.FindReservationResponse) {
 	token := req.Token
-	userId, err := m.isTokenValid(token)
+	userId, err := m.isTokenValid(ctx, token)
 	if err != nil {
-		m.log(err, map[string]interface{}{
+		m.log(ctx, err, map[string]interface{}{
 			"method": "FindReservation",
 			"action": "isTokenValid",
-			"token":  token,
 			"error":  err.Error(),
 		})
 
@@ -110,12 +176,11 @@ func (m *AuthMiddleware) FindReservation(ctx context.Context, req hotelcaliforni
 
 func (m *AuthMiddleware) FindReservations(ctx context.Context, req hotelcalifornia.FindReservationsRequest) (res hotelcalifornia.FindReservationsResponse) {
 	token := req.Token
-	userId, err := m.isTokenValid(token)
+	userId, err := m.isTokenValid(ctx, token)
 	if err != nil {
-		m.log(err, map[string]interface{}{
+		m.log(ctx, err, map[string]interface{}{
 			"method": "FindReservations",
 			"action": "isTokenValid",
-			"token":  token,
 			"error":  err.Error(),
 		})
 
@@ -128,7 +193,239 @@ func (m *AuthMiddleware) FindReservations(ctx context.Context, req hotelcaliforn
 	return m.next.FindReservations(ctx, req)
 }
 
-func (m *AuthMiddleware) isTokenValid(tokenString string) (int64, error) {
+func (m *AuthMiddleware) CreateWebhookSubscription(ctx context.Context, req hotelcalifornia.CreateWebhookSubscriptionRequest) (res hotelcalifornia.CreateWebhookSubscriptionResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "CreateWebhookSubscription",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.CreateWebhookSubscription(ctx, req)
+}
+
+func (m *AuthMiddleware) FindWebhookSubscriptions(ctx context.Context, req hotelcalifornia.FindWebhookSubscriptionsRequest) (res hotelcalifornia.FindWebhookSubscriptionsResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "FindWebhookSubscriptions",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.FindWebhookSubscriptions(ctx, req)
+}
+
+func (m *AuthMiddleware) DeleteWebhookSubscription(ctx context.Context, req hotelcalifornia.DeleteWebhookSubscriptionRequest) (res hotelcalifornia.DeleteWebhookSubscriptionResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "DeleteWebhookSubscription",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.DeleteWebhookSubscription(ctx, req)
+}
+
+func (m *AuthMiddleware) FindWebhookDeliveries(ctx context.Context, req hotelcalifornia.FindWebhookDeliveriesRequest) (res hotelcalifornia.FindWebhookDeliveriesResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "FindWebhookDeliveries",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.FindWebhookDeliveries(ctx, req)
+}
+
+func (m *AuthMiddleware) RedeliverWebhookDelivery(ctx context.Context, req hotelcalifornia.RedeliverWebhookDeliveryRequest) (res hotelcalifornia.RedeliverWebhookDeliveryResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "RedeliverWebhookDelivery",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.RedeliverWebhookDelivery(ctx, req)
+}
+
+func (m *AuthMiddleware) CheckInReservation(ctx context.Context, req hotelcalifornia.CheckInReservationRequest) (res hotelcalifornia.CheckInReservationResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "CheckInReservation",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.CheckInReservation(ctx, req)
+}
+
+// ExportReservations represents auth middleware's export reservations method, a request with a calendar token is
+// authenticated by the service with the token
+func (m *AuthMiddleware) ExportReservations(ctx context.Context, req hotelcalifornia.ExportReservationsRequest) (res hotelcalifornia.ExportReservationsResponse) {
+	if req.CalendarToken != "" {
+		req.UserId = 0
+
+		return m.next.ExportReservations(ctx, req)
+	}
+
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "ExportReservations",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.ExportReservations(ctx, req)
+}
+
+func (m *AuthMiddleware) ExportReservation(ctx context.Context, req hotelcalifornia.ExportReservationRequest) (res hotelcalifornia.ExportReservationResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "ExportReservation",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.ExportReservation(ctx, req)
+}
+
+func (m *AuthMiddleware) CreateCalendarSubscription(ctx context.Context, req hotelcalifornia.CreateCalendarSubscriptionRequest) (res hotelcalifornia.CreateCalendarSubscriptionResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "CreateCalendarSubscription",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.CreateCalendarSubscription(ctx, req)
+}
+
+func (m *AuthMiddleware) RevokeCalendarSubscription(ctx context.Context, req hotelcalifornia.RevokeCalendarSubscriptionRequest) (res hotelcalifornia.RevokeCalendarSubscriptionResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "RevokeCalendarSubscription",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.RevokeCalendarSubscription(ctx, req)
+}
+
+func (m *AuthMiddleware) ExportReservationConfirmation(ctx context.Context, req hotelcalifornia.ExportReservationConfirmationRequest) (res hotelcalifornia.ExportReservationConfirmationResponse) {
+	token := req.Token
+	userId, err := m.isTokenValid(ctx, token)
+	if err != nil {
+		m.log(ctx, err, map[string]interface{}{
+			"method": "ExportReservationConfirmation",
+			"action": "isTokenValid",
+			"error":  err.Error(),
+		})
+
+		res.Result = apierror.DefaultUnauthorizedError
+		return res
+	}
+
+	req.UserId = userId
+
+	return m.next.ExportReservationConfirmation(ctx, req)
+}
+
+// LookupReservation represents auth middleware's lookup reservation method, reservations are looked up without signing
+// in
+func (m *AuthMiddleware) LookupReservation(ctx context.Context, req hotelcalifornia.LookupReservationRequest) hotelcalifornia.LookupReservationResponse {
+	return m.next.LookupReservation(ctx, req)
+}
+
+func (m *AuthMiddleware) isTokenValid(ctx context.Context, tokenString string) (userid int64, err error) {
+	_, span := tracing.Tracer().Start(ctx, "Auth.ValidateToken")
+	defer func() {
+		if err != nil {
+			tracing.SpanError(span, err)
+		}
+
+		span.End()
+	}()
+
 	// Parse the token
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return []byte(m.jw.Secret), nil
@@ -152,7 +449,7 @@ func (m *AuthMiddleware) isTokenValid(tokenString string) (int64, error) {
 	}
 
 	// Extract user ID from claims
-	userid, err := strconv.ParseInt(claims["sub"].(string), 10, 64)
+	userid, err = strconv.ParseInt(claims["sub"].(string), 10, 64)
 	if err != nil {
 		return 0, errParsingUserId
 	}
@@ -161,8 +458,10 @@ func (m *AuthMiddleware) isTokenValid(tokenString string) (int64, error) {
 	return userid, nil
 }
 
-func (s *AuthMiddleware) log(err error, additionalParams map[string]interface{}) {
-	logParams := make([]interface{}, 0, 2+len(additionalParams)*2)
+func (s *AuthMiddleware) log(ctx context.Context, err error, additionalParams map[string]interface{}) {
+	logParams := make([]interface{}, 0, 4+len(additionalParams)*2)
+
+	logParams = append(logParams, "request_id", requestid.FromContext(ctx))
 
 	for k, v := range additionalParams {
 		logParams = append(logParams, k, v)
@@ -0,0 +1,271 @@
+package loggingmiddleware
+
+import (
+	"context"
+	"errors"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	hotelcalifornia "hotel-california-backend"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/middleware"
+	"hotel-california-backend/internal/requestid"
+	"net/http"
+	"time"
+)
+
+// LoggingMiddleware represents logging service middleware
+type LoggingMiddleware struct {
+	l    log.Logger
+	next hotelcalifornia.Service
+}
+
+// NewLoggingMiddleware creates and returns logging middleware, it logs every call with its request id, user, pnr,
+// duration and result code
+func NewLoggingMiddleware(l log.Logger) middleware.Middleware {
+	return func(next hotelcalifornia.Service) hotelcalifornia.Service {
+		return &LoggingMiddleware{
+			l:    l,
+			next: next,
+		}
+	}
+}
+
+// Health represents logging middleware's health method
+func (m *LoggingMiddleware) Health(ctx context.Context, req hotelcalifornia.HealthRequest) (res hotelcalifornia.HealthResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "Health", 0, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.Health(ctx, req)
+}
+
+// Liveness represents logging middleware's liveness method
+func (m *LoggingMiddleware) Liveness(ctx context.Context, req hotelcalifornia.LivenessRequest) (res hotelcalifornia.LivenessResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "Liveness", 0, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.Liveness(ctx, req)
+}
+
+// Readiness represents logging middleware's readiness method
+func (m *LoggingMiddleware) Readiness(ctx context.Context, req hotelcalifornia.ReadinessRequest) (res hotelcalifornia.ReadinessResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "Readiness", 0, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.Readiness(ctx, req)
+}
+
+// SignIn represents logging middleware's sign in method
+func (m *LoggingMiddleware) SignIn(ctx context.Context, req hotelcalifornia.SignInRequest) (res hotelcalifornia.SignInResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "SignIn", 0, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.SignIn(ctx, req)
+}
+
+// CreateReservation represents logging middleware's create reservation method
+func (m *LoggingMiddleware) CreateReservation(ctx context.Context, req hotelcalifornia.CreateReservationRequest) (res hotelcalifornia.CreateReservationResponse) {
+	defer func(begin time.Time) {
+		var pnr string
+		if res.Data != nil {
+			pnr = res.Data.PNR
+		}
+
+		m.log(ctx, "CreateReservation", req.UserId, pnr, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CreateReservation(ctx, req)
+}
+
+// UpdateReservation represents logging middleware's update reservation method
+func (m *LoggingMiddleware) UpdateReservation(ctx context.Context, req hotelcalifornia.UpdateReservationRequest) (res hotelcalifornia.UpdateReservationResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "UpdateReservation", req.UserId, req.PNR, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.UpdateReservation(ctx, req)
+}
+
+// CancelReservation represents logging middleware's cancel reservation method
+func (m *LoggingMiddleware) CancelReservation(ctx context.Context, req hotelcalifornia.CancelReservationRequest) (res hotelcalifornia.CancelReservationResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "CancelReservation", req.UserId, req.PNR, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CancelReservation(ctx, req)
+}
+
+// ConfirmReservation represents logging middleware's confirm reservation method
+func (m *LoggingMiddleware) ConfirmReservation(ctx context.Context, req hotelcalifornia.ConfirmReservationRequest) (res hotelcalifornia.ConfirmReservationResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "ConfirmReservation", req.UserId, req.PNR, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.ConfirmReservation(ctx, req)
+}
+
+// FindReservationHistory represents logging middleware's find reservation history method
+func (m *LoggingMiddleware) FindReservationHistory(ctx context.Context, req hotelcalifornia.FindReservationHistoryRequest) (res hotelcalifornia.FindReservationHistoryResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "FindReservationHistory", req.UserId, req.PNR, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindReservationHistory(ctx, req)
+}
+
+// FindReservation represents logging middleware's find reservation method
+func (m *LoggingMiddleware) FindReservation(ctx context.Context, req hotelcalifornia.FindReservationRequest) (res hotelcalifornia.FindReservationResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "FindReservation", req.UserId, req.PNR, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindReservation(ctx, req)
+}
+
+// FindReservations represents logging middleware's find reservations method
+func (m *LoggingMiddleware) FindReservations(ctx context.Context, req hotelcalifornia.FindReservationsRequest) (res hotelcalifornia.FindReservationsResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "FindReservations", req.UserId, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindReservations(ctx, req)
+}
+
+// CreateWebhookSubscription represents logging middleware's create webhook subscription method
+func (m *LoggingMiddleware) CreateWebhookSubscription(ctx context.Context, req hotelcalifornia.CreateWebhookSubscriptionRequest) (res hotelcalifornia.CreateWebhookSubscriptionResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "CreateWebhookSubscription", req.UserId, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CreateWebhookSubscription(ctx, req)
+}
+
+// FindWebhookSubscriptions represents logging middleware's find webhook subscriptions method
+func (m *LoggingMiddleware) FindWebhookSubscriptions(ctx context.Context, req hotelcalifornia.FindWebhookSubscriptionsRequest) (res hotelcalifornia.FindWebhookSubscriptionsResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "FindWebhookSubscriptions", req.UserId, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindWebhookSubscriptions(ctx, req)
+}
+
+// DeleteWebhookSubscription represents logging middleware's delete webhook subscription method
+func (m *LoggingMiddleware) DeleteWebhookSubscription(ctx context.Context, req hotelcalifornia.DeleteWebhookSubscriptionRequest) (res hotelcalifornia.DeleteWebhookSubscriptionResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "DeleteWebhookSubscription", req.UserId, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.DeleteWebhookSubscription(ctx, req)
+}
+
+// FindWebhookDeliveries represents logging middleware's find webhook deliveries method
+func (m *LoggingMiddleware) FindWebhookDeliveries(ctx context.Context, req hotelcalifornia.FindWebhookDeliveriesRequest) (res hotelcalifornia.FindWebhookDeliveriesResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "FindWebhookDeliveries", req.UserId, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindWebhookDeliveries(ctx, req)
+}
+
+// RedeliverWebhookDelivery represents logging middleware's redeliver webhook delivery method
+func (m *LoggingMiddleware) RedeliverWebhookDelivery(ctx context.Context, req hotelcalifornia.RedeliverWebhookDeliveryRequest) (res hotelcalifornia.RedeliverWebhookDeliveryResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "RedeliverWebhookDelivery", req.UserId, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.RedeliverWebhookDelivery(ctx, req)
+}
+
+// CheckInReservation represents logging middleware's check in reservation method
+func (m *LoggingMiddleware) CheckInReservation(ctx context.Context, req hotelcalifornia.CheckInReservationRequest) (res hotelcalifornia.CheckInReservationResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "CheckInReservation", req.UserId, req.PNR, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CheckInReservation(ctx, req)
+}
+
+// ExportReservations represents logging middleware's export reservations method
+func (m *LoggingMiddleware) ExportReservations(ctx context.Context, req hotelcalifornia.ExportReservationsRequest) (res hotelcalifornia.ExportReservationsResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "ExportReservations", req.UserId, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.ExportReservations(ctx, req)
+}
+
+// ExportReservation represents logging middleware's export reservation method
+func (m *LoggingMiddleware) ExportReservation(ctx context.Context, req hotelcalifornia.ExportReservationRequest) (res hotelcalifornia.ExportReservationResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "ExportReservation", req.UserId, req.PNR, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.ExportReservation(ctx, req)
+}
+
+// CreateCalendarSubscription represents logging middleware's create calendar subscription method
+func (m *LoggingMiddleware) CreateCalendarSubscription(ctx context.Context, req hotelcalifornia.CreateCalendarSubscriptionRequest) (res hotelcalifornia.CreateCalendarSubscriptionResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "CreateCalendarSubscription", req.UserId, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CreateCalendarSubscription(ctx, req)
+}
+
+// RevokeCalendarSubscription represents logging middleware's revoke calendar subscription method
+func (m *LoggingMiddleware) RevokeCalendarSubscription(ctx context.Context, req hotelcalifornia.RevokeCalendarSubscriptionRequest) (res hotelcalifornia.RevokeCalendarSubscriptionResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "RevokeCalendarSubscription", req.UserId, "", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.RevokeCalendarSubscription(ctx, req)
+}
+
+// ExportReservationConfirmation represents logging middleware's export reservation confirmation method
+func (m *LoggingMiddleware) ExportReservationConfirmation(ctx context.Context, req hotelcalifornia.ExportReservationConfirmationRequest) (res hotelcalifornia.ExportReservationConfirmationResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "ExportReservationConfirmation", req.UserId, req.PNR, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.ExportReservationConfirmation(ctx, req)
+}
+
+// LookupReservation represents logging middleware's lookup reservation method, the last name is not logged
+func (m *LoggingMiddleware) LookupReservation(ctx context.Context, req hotelcalifornia.LookupReservationRequest) (res hotelcalifornia.LookupReservationResponse) {
+	defer func(begin time.Time) {
+		m.log(ctx, "LookupReservation", 0, req.PNR, begin, res.APIError())
+	}(time.Now())
+
+	return m.next.LookupReservation(ctx, req)
+}
+
+func (m *LoggingMiddleware) log(ctx context.Context, method string, userID int64, pnr string, begin time.Time, err error) {
+	code, statusCode := 0, http.StatusOK
+
+	if err != nil {
+		code, statusCode = apierror.CodeInternalServerError, http.StatusInternalServerError
+
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			code, statusCode = apiErr.Code, apiErr.StatusCode
+		}
+	}
+
+	logger := level.Info(m.l)
+	if statusCode >= http.StatusInternalServerError {
+		logger = level.Error(m.l)
+	}
+
+	_ = logger.Log(
+		"method", method,
+		"request_id", requestid.FromContext(ctx),
+		"user_id", userID,
+		"pnr", pnr,
+		"took", time.Since(begin),
+		"code", code,
+		"status_code", statusCode,
+	)
+}
@@ -0,0 +1,258 @@
+package metricsmiddleware
+
+import (
+	"context"
+	"errors"
+	"github.com/go-kit/kit/metrics"
+	hotelcalifornia "hotel-california-backend"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/middleware"
+	"strconv"
+	"time"
+)
+
+// MetricsMiddleware represents metrics service middleware
+type MetricsMiddleware struct {
+	requests metrics.Counter
+	errors   metrics.Counter
+	latency  metrics.Histogram
+	next     hotelcalifornia.Service
+}
+
+// NewMetricsMiddleware creates and returns metrics middleware, requests are counted by method,
+// errors by method and api error name and latency is observed by method and success
+func NewMetricsMiddleware(requests, errors metrics.Counter, latency metrics.Histogram) middleware.Middleware {
+	return func(next hotelcalifornia.Service) hotelcalifornia.Service {
+		return &MetricsMiddleware{
+			requests: requests,
+			errors:   errors,
+			latency:  latency,
+			next:     next,
+		}
+	}
+}
+
+// Health represents metrics middleware's health method
+func (m *MetricsMiddleware) Health(ctx context.Context, req hotelcalifornia.HealthRequest) (res hotelcalifornia.HealthResponse) {
+	defer func(begin time.Time) {
+		m.observe("Health", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.Health(ctx, req)
+}
+
+// Liveness represents metrics middleware's liveness method
+func (m *MetricsMiddleware) Liveness(ctx context.Context, req hotelcalifornia.LivenessRequest) (res hotelcalifornia.LivenessResponse) {
+	defer func(begin time.Time) {
+		m.observe("Liveness", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.Liveness(ctx, req)
+}
+
+// Readiness represents metrics middleware's readiness method
+func (m *MetricsMiddleware) Readiness(ctx context.Context, req hotelcalifornia.ReadinessRequest) (res hotelcalifornia.ReadinessResponse) {
+	defer func(begin time.Time) {
+		m.observe("Readiness", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.Readiness(ctx, req)
+}
+
+// SignIn represents metrics middleware's sign in method
+func (m *MetricsMiddleware) SignIn(ctx context.Context, req hotelcalifornia.SignInRequest) (res hotelcalifornia.SignInResponse) {
+	defer func(begin time.Time) {
+		m.observe("SignIn", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.SignIn(ctx, req)
+}
+
+// CreateReservation represents metrics middleware's create reservation method
+func (m *MetricsMiddleware) CreateReservation(ctx context.Context, req hotelcalifornia.CreateReservationRequest) (res hotelcalifornia.CreateReservationResponse) {
+	defer func(begin time.Time) {
+		m.observe("CreateReservation", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CreateReservation(ctx, req)
+}
+
+// UpdateReservation represents metrics middleware's update reservation method
+func (m *MetricsMiddleware) UpdateReservation(ctx context.Context, req hotelcalifornia.UpdateReservationRequest) (res hotelcalifornia.UpdateReservationResponse) {
+	defer func(begin time.Time) {
+		m.observe("UpdateReservation", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.UpdateReservation(ctx, req)
+}
+
+// CancelReservation represents metrics middleware's cancel reservation method
+func (m *MetricsMiddleware) CancelReservation(ctx context.Context, req hotelcalifornia.CancelReservationRequest) (res hotelcalifornia.CancelReservationResponse) {
+	defer func(begin time.Time) {
+		m.observe("CancelReservation", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CancelReservation(ctx, req)
+}
+
+// ConfirmReservation represents metrics middleware's confirm reservation method
+func (m *MetricsMiddleware) ConfirmReservation(ctx context.Context, req hotelcalifornia.ConfirmReservationRequest) (res hotelcalifornia.ConfirmReservationResponse) {
+	defer func(begin time.Time) {
+		m.observe("ConfirmReservation", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.ConfirmReservation(ctx, req)
+}
+
+// FindReservationHistory represents metrics middleware's find reservation history method
+func (m *MetricsMiddleware) FindReservationHistory(ctx context.Context, req hotelcalifornia.FindReservationHistoryRequest) (res hotelcalifornia.FindReservationHistoryResponse) {
+	defer func(begin time.Time) {
+		m.observe("FindReservationHistory", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindReservationHistory(ctx, req)
+}
+
+// FindReservation represents metrics middleware's find reservation method
+func (m *MetricsMiddleware) FindReservation(ctx context.Context, req hotelcalifornia.FindReservationRequest) (res hotelcalifornia.FindReservationResponse) {
+	defer func(begin time.Time) {
+		m.observe("FindReservation", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindReservation(ctx, req)
+}
+
+// FindReservations represents metrics middleware's find reservations method
+func (m *MetricsMiddleware) FindReservations(ctx context.Context, req hotelcalifornia.FindReservationsRequest) (res hotelcalifornia.FindReservationsResponse) {
+	defer func(begin time.Time) {
+		m.observe("FindReservations", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindReservations(ctx, req)
+}
+
+// CreateWebhookSubscription represents metrics middleware's create webhook subscription method
+func (m *MetricsMiddleware) CreateWebhookSubscription(ctx context.Context, req hotelcalifornia.CreateWebhookSubscriptionRequest) (res hotelcalifornia.CreateWebhookSubscriptionResponse) {
+	defer func(begin time.Time) {
+		m.observe("CreateWebhookSubscription", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CreateWebhookSubscription(ctx, req)
+}
+
+// FindWebhookSubscriptions represents metrics middleware's find webhook subscriptions method
+func (m *MetricsMiddleware) FindWebhookSubscriptions(ctx context.Context, req hotelcalifornia.FindWebhookSubscriptionsRequest) (res hotelcalifornia.FindWebhookSubscriptionsResponse) {
+	defer func(begin time.Time) {
+		m.observe("FindWebhookSubscriptions", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindWebhookSubscriptions(ctx, req)
+}
+
+// DeleteWebhookSubscription represents metrics middleware's delete webhook subscription method
+func (m *MetricsMiddleware) DeleteWebhookSubscription(ctx context.Context, req hotelcalifornia.DeleteWebhookSubscriptionRequest) (res hotelcalifornia.DeleteWebhookSubscriptionResponse) {
+	defer func(begin time.Time) {
+		m.observe("DeleteWebhookSubscription", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.DeleteWebhookSubscription(ctx, req)
+}
+
+// FindWebhookDeliveries represents metrics middleware's find webhook deliveries method
+func (m *MetricsMiddleware) FindWebhookDeliveries(ctx context.Context, req hotelcalifornia.FindWebhookDeliveriesRequest) (res hotelcalifornia.FindWebhookDeliveriesResponse) {
+	defer func(begin time.Time) {
+		m.observe("FindWebhookDeliveries", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.FindWebhookDeliveries(ctx, req)
+}
+
+// RedeliverWebhookDelivery represents metrics middleware's redeliver webhook delivery method
+func (m *MetricsMiddleware) RedeliverWebhookDelivery(ctx context.Context, req hotelcalifornia.RedeliverWebhookDeliveryRequest) (res hotelcalifornia.RedeliverWebhookDeliveryResponse) {
+	defer func(begin time.Time) {
+		m.observe("RedeliverWebhookDelivery", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.RedeliverWebhookDelivery(ctx, req)
+}
+
+// CheckInReservation represents metrics middleware's check in reservation method
+func (m *MetricsMiddleware) CheckInReservation(ctx context.Context, req hotelcalifornia.CheckInReservationRequest) (res hotelcalifornia.CheckInReservationResponse) {
+	defer func(begin time.Time) {
+		m.observe("CheckInReservation", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CheckInReservation(ctx, req)
+}
+
+// ExportReservations represents metrics middleware's export reservations method
+func (m *MetricsMiddleware) ExportReservations(ctx context.Context, req hotelcalifornia.ExportReservationsRequest) (res hotelcalifornia.ExportReservationsResponse) {
+	defer func(begin time.Time) {
+		m.observe("ExportReservations", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.ExportReservations(ctx, req)
+}
+
+// ExportReservation represents metrics middleware's export reservation method
+func (m *MetricsMiddleware) ExportReservation(ctx context.Context, req hotelcalifornia.ExportReservationRequest) (res hotelcalifornia.ExportReservationResponse) {
+	defer func(begin time.Time) {
+		m.observe("ExportReservation", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.ExportReservation(ctx, req)
+}
+
+// CreateCalendarSubscription represents metrics middleware's create calendar subscription method
+func (m *MetricsMiddleware) CreateCalendarSubscription(ctx context.Context, req hotelcalifornia.CreateCalendarSubscriptionRequest) (res hotelcalifornia.CreateCalendarSubscriptionResponse) {
+	defer func(begin time.Time) {
+		m.observe("CreateCalendarSubscription", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.CreateCalendarSubscription(ctx, req)
+}
+
+// RevokeCalendarSubscription represents metrics middleware's revoke calendar subscription method
+func (m *MetricsMiddleware) RevokeCalendarSubscription(ctx context.Context, req hotelcalifornia.RevokeCalendarSubscriptionRequest) (res hotelcalifornia.RevokeCalendarSubscriptionResponse) {
+	defer func(begin time.Time) {
+		m.observe("RevokeCalendarSubscription", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.RevokeCalendarSubscription(ctx, req)
+}
+
+// ExportReservationConfirmation represents metrics middleware's export reservation confirmation method
+func (m *MetricsMiddleware) ExportReservationConfirmation(ctx context.Context, req hotelcalifornia.ExportReservationConfirmationRequest) (res hotelcalifornia.ExportReservationConfirmationResponse) {
+	defer func(begin time.Time) {
+		m.observe("ExportReservationConfirmation", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.ExportReservationConfirmation(ctx, req)
+}
+
+// LookupReservation represents metrics middleware's lookup reservation method
+func (m *MetricsMiddleware) LookupReservation(ctx context.Context, req hotelcalifornia.LookupReservationRequest) (res hotelcalifornia.LookupReservationResponse) {
+	defer func(begin time.Time) {
+		m.observe("LookupReservation", begin, res.APIError())
+	}(time.Now())
+
+	return m.next.LookupReservation(ctx, req)
+}
+
+func (m *MetricsMiddleware) observe(method string, begin time.Time, err error) {
+	m.requests.With("method", method).Add(1)
+	m.latency.With("method", method, "success", strconv.FormatBool(err == nil)).Observe(time.Since(begin).Seconds())
+
+	if err == nil {
+		return
+	}
+
+	name := apierror.NameInternalServerError
+
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		name = apiErr.Name
+	}
+
+	m.errors.With("method", method, "error", name).Add(1)
+}
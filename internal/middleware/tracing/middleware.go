@@ -0,0 +1,309 @@
+package tracingmiddleware
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	hotelcalifornia "hotel-california-backend"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/middleware"
+	"hotel-california-backend/internal/tracing"
+)
+
+// TracingMiddleware represents tracing service middleware
+type TracingMiddleware struct {
+	tracer trace.Tracer
+	next   hotelcalifornia.Service
+}
+
+// NewTracingMiddleware creates and returns tracing middleware
+func NewTracingMiddleware() middleware.Middleware {
+	return func(next hotelcalifornia.Service) hotelcalifornia.Service {
+		return &TracingMiddleware{
+			tracer: tracing.Tracer(),
+			next:   next,
+		}
+	}
+}
+
+// Health represents tracing middleware's health method
+func (m *TracingMiddleware) Health(ctx context.Context, req hotelcalifornia.HealthRequest) (res hotelcalifornia.HealthResponse) {
+	ctx, span := m.start(ctx, "Health")
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.Health(ctx, req)
+}
+
+// Liveness represents tracing middleware's liveness method
+func (m *TracingMiddleware) Liveness(ctx context.Context, req hotelcalifornia.LivenessRequest) (res hotelcalifornia.LivenessResponse) {
+	ctx, span := m.start(ctx, "Liveness")
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.Liveness(ctx, req)
+}
+
+// Readiness represents tracing middleware's readiness method
+func (m *TracingMiddleware) Readiness(ctx context.Context, req hotelcalifornia.ReadinessRequest) (res hotelcalifornia.ReadinessResponse) {
+	ctx, span := m.start(ctx, "Readiness")
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.Readiness(ctx, req)
+}
+
+// SignIn represents tracing middleware's sign in method
+func (m *TracingMiddleware) SignIn(ctx context.Context, req hotelcalifornia.SignInRequest) (res hotelcalifornia.SignInResponse) {
+	ctx, span := m.start(ctx, "SignIn")
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.SignIn(ctx, req)
+}
+
+// CreateReservation represents tracing middleware's create reservation method
+func (m *TracingMiddleware) CreateReservation(ctx context.Context, req hotelcalifornia.CreateReservationRequest) (res hotelcalifornia.CreateReservationResponse) {
+	ctx, span := m.start(ctx, "CreateReservation")
+	defer func() {
+		if res.Data != nil {
+			span.SetAttributes(attribute.String("reservation.pnr", res.Data.PNR))
+		}
+
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.CreateReservation(ctx, req)
+}
+
+// UpdateReservation represents tracing middleware's update reservation method
+func (m *TracingMiddleware) UpdateReservation(ctx context.Context, req hotelcalifornia.UpdateReservationRequest) (res hotelcalifornia.UpdateReservationResponse) {
+	ctx, span := m.start(ctx, "UpdateReservation", attribute.String("reservation.pnr", req.PNR))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.UpdateReservation(ctx, req)
+}
+
+// CancelReservation represents tracing middleware's cancel reservation method
+func (m *TracingMiddleware) CancelReservation(ctx context.Context, req hotelcalifornia.CancelReservationRequest) (res hotelcalifornia.CancelReservationResponse) {
+	ctx, span := m.start(ctx, "CancelReservation", attribute.String("reservation.pnr", req.PNR))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.CancelReservation(ctx, req)
+}
+
+// ConfirmReservation represents tracing middleware's confirm reservation method
+func (m *TracingMiddleware) ConfirmReservation(ctx context.Context, req hotelcalifornia.ConfirmReservationRequest) (res hotelcalifornia.ConfirmReservationResponse) {
+	ctx, span := m.start(ctx, "ConfirmReservation", attribute.String("reservation.pnr", req.PNR))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.ConfirmReservation(ctx, req)
+}
+
+// FindReservationHistory represents tracing middleware's find reservation history method
+func (m *TracingMiddleware) FindReservationHistory(ctx context.Context, req hotelcalifornia.FindReservationHistoryRequest) (res hotelcalifornia.FindReservationHistoryResponse) {
+	ctx, span := m.start(ctx, "FindReservationHistory", attribute.String("reservation.pnr", req.PNR))
+	defer func() {
+		if res.Data != nil {
+			span.SetAttributes(attribute.Int("reservation.history.count", len(res.Data.Entries)))
+		}
+
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.FindReservationHistory(ctx, req)
+}
+
+// FindReservation represents tracing middleware's find reservation method
+func (m *TracingMiddleware) FindReservation(ctx context.Context, req hotelcalifornia.FindReservationRequest) (res hotelcalifornia.FindReservationResponse) {
+	ctx, span := m.start(ctx, "FindReservation", attribute.String("reservation.pnr", req.PNR))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.FindReservation(ctx, req)
+}
+
+// FindReservations represents tracing middleware's find reservations method
+func (m *TracingMiddleware) FindReservations(ctx context.Context, req hotelcalifornia.FindReservationsRequest) (res hotelcalifornia.FindReservationsResponse) {
+	ctx, span := m.start(ctx, "FindReservations")
+	defer func() {
+		if res.Data != nil {
+			span.SetAttributes(attribute.Int("reservations.count", len(res.Data.Reservations)))
+		}
+
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.FindReservations(ctx, req)
+}
+
+// CreateWebhookSubscription represents tracing middleware's create webhook subscription method
+func (m *TracingMiddleware) CreateWebhookSubscription(ctx context.Context, req hotelcalifornia.CreateWebhookSubscriptionRequest) (res hotelcalifornia.CreateWebhookSubscriptionResponse) {
+	ctx, span := m.start(ctx, "CreateWebhookSubscription")
+	defer func() {
+		if res.Data != nil {
+			span.SetAttributes(attribute.Int64("webhook.subscription.id", res.Data.ID))
+		}
+
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.CreateWebhookSubscription(ctx, req)
+}
+
+// FindWebhookSubscriptions represents tracing middleware's find webhook subscriptions method
+func (m *TracingMiddleware) FindWebhookSubscriptions(ctx context.Context, req hotelcalifornia.FindWebhookSubscriptionsRequest) (res hotelcalifornia.FindWebhookSubscriptionsResponse) {
+	ctx, span := m.start(ctx, "FindWebhookSubscriptions")
+	defer func() {
+		if res.Data != nil {
+			span.SetAttributes(attribute.Int("webhook.subscriptions.count", len(res.Data.Subscriptions)))
+		}
+
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.FindWebhookSubscriptions(ctx, req)
+}
+
+// DeleteWebhookSubscription represents tracing middleware's delete webhook subscription method
+func (m *TracingMiddleware) DeleteWebhookSubscription(ctx context.Context, req hotelcalifornia.DeleteWebhookSubscriptionRequest) (res hotelcalifornia.DeleteWebhookSubscriptionResponse) {
+	ctx, span := m.start(ctx, "DeleteWebhookSubscription", attribute.Int64("webhook.subscription.id", req.ID))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.DeleteWebhookSubscription(ctx, req)
+}
+
+// FindWebhookDeliveries represents tracing middleware's find webhook deliveries method
+func (m *TracingMiddleware) FindWebhookDeliveries(ctx context.Context, req hotelcalifornia.FindWebhookDeliveriesRequest) (res hotelcalifornia.FindWebhookDeliveriesResponse) {
+	ctx, span := m.start(ctx, "FindWebhookDeliveries")
+	defer func() {
+		if res.Data != nil {
+			span.SetAttributes(attribute.Int("webhook.deliveries.count", len(res.Data.Deliveries)))
+		}
+
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.FindWebhookDeliveries(ctx, req)
+}
+
+// RedeliverWebhookDelivery represents tracing middleware's redeliver webhook delivery method
+func (m *TracingMiddleware) RedeliverWebhookDelivery(ctx context.Context, req hotelcalifornia.RedeliverWebhookDeliveryRequest) (res hotelcalifornia.RedeliverWebhookDeliveryResponse) {
+	ctx, span := m.start(ctx, "RedeliverWebhookDelivery", attribute.Int64("webhook.delivery.id", req.ID))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.RedeliverWebhookDelivery(ctx, req)
+}
+
+// CheckInReservation represents tracing middleware's check in reservation method
+func (m *TracingMiddleware) CheckInReservation(ctx context.Context, req hotelcalifornia.CheckInReservationRequest) (res hotelcalifornia.CheckInReservationResponse) {
+	ctx, span := m.start(ctx, "CheckInReservation", attribute.String("reservation.pnr", req.PNR))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.CheckInReservation(ctx, req)
+}
+
+// ExportReservations represents tracing middleware's export reservations method
+func (m *TracingMiddleware) ExportReservations(ctx context.Context, req hotelcalifornia.ExportReservationsRequest) (res hotelcalifornia.ExportReservationsResponse) {
+	ctx, span := m.start(ctx, "ExportReservations")
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.ExportReservations(ctx, req)
+}
+
+// ExportReservation represents tracing middleware's export reservation method
+func (m *TracingMiddleware) ExportReservation(ctx context.Context, req hotelcalifornia.ExportReservationRequest) (res hotelcalifornia.ExportReservationResponse) {
+	ctx, span := m.start(ctx, "ExportReservation", attribute.String("reservation.pnr", req.PNR))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.ExportReservation(ctx, req)
+}
+
+// CreateCalendarSubscription represents tracing middleware's create calendar subscription method
+func (m *TracingMiddleware) CreateCalendarSubscription(ctx context.Context, req hotelcalifornia.CreateCalendarSubscriptionRequest) (res hotelcalifornia.CreateCalendarSubscriptionResponse) {
+	ctx, span := m.start(ctx, "CreateCalendarSubscription")
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.CreateCalendarSubscription(ctx, req)
+}
+
+// RevokeCalendarSubscription represents tracing middleware's revoke calendar subscription method
+func (m *TracingMiddleware) RevokeCalendarSubscription(ctx context.Context, req hotelcalifornia.RevokeCalendarSubscriptionRequest) (res hotelcalifornia.RevokeCalendarSubscriptionResponse) {
+	ctx, span := m.start(ctx, "RevokeCalendarSubscription")
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.RevokeCalendarSubscription(ctx, req)
+}
+
+// ExportReservationConfirmation represents tracing middleware's export reservation confirmation method
+func (m *TracingMiddleware) ExportReservationConfirmation(ctx context.Context, req hotelcalifornia.ExportReservationConfirmationRequest) (res hotelcalifornia.ExportReservationConfirmationResponse) {
+	ctx, span := m.start(ctx, "ExportReservationConfirmation", attribute.String("reservation.pnr", req.PNR))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.ExportReservationConfirmation(ctx, req)
+}
+
+// LookupReservation represents tracing middleware's lookup reservation method
+func (m *TracingMiddleware) LookupReservation(ctx context.Context, req hotelcalifornia.LookupReservationRequest) (res hotelcalifornia.LookupReservationResponse) {
+	ctx, span := m.start(ctx, "LookupReservation", attribute.String("reservation.pnr", req.PNR))
+	defer func() {
+		m.end(span, res.APIError())
+	}()
+
+	return m.next.LookupReservation(ctx, req)
+}
+
+func (m *TracingMiddleware) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return m.tracer.Start(ctx, "Service."+method, trace.WithAttributes(attrs...))
+}
+
+func (m *TracingMiddleware) end(span trace.Span, err error) {
+	defer span.End()
+
+	if err == nil {
+		return
+	}
+
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		span.SetAttributes(
+			attribute.String("error.name", apiErr.Name),
+			attribute.Int("error.code", apiErr.Code),
+		)
+
+		err = errors.New(apiErr.Name)
+		if apiErr.BaseError != nil {
+			err = apiErr.BaseError
+		}
+	}
+
+	tracing.SpanError(span, err)
+}
@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"time"
+)
+
+// lockName names the database wide lock held while migrations run
+const lockName = "hotel_california_schema_migrations"
+
+// lockTimeoutSeconds is how long a migrator waits for another one to finish
+const lockTimeoutSeconds = 60
+
+// ErrLockTimeout is returned when another migrator holds the lock for too long
+var ErrLockTimeout = errors.New("timed out waiting for the migration lock")
+
+// Dialect defines what differs between databases when recording migrations
+type Dialect interface {
+	// Placeholder returns the bind parameter for the nth (1-based) argument of a statement
+	Placeholder(n int) string
+	// Lock blocks until conn holds the migration lock, so that instances starting together migrate once
+	Lock(ctx context.Context, conn *sql.Conn) error
+	// Unlock releases the migration lock held by conn
+	Unlock(ctx context.Context, conn *sql.Conn) error
+}
+
+// dialects
+var (
+	MySQL    Dialect = mysqlDialect{}
+	SQLite   Dialect = sqliteDialect{}
+	Postgres Dialect = postgresDialect{}
+)
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Placeholder(_ int) string {
+	return "?"
+}
+
+func (mysqlDialect) Lock(ctx context.Context, conn *sql.Conn) error {
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, lockTimeoutSeconds).Scan(&acquired); err != nil {
+		return err
+	}
+
+	if acquired.Int64 != 1 {
+		return ErrLockTimeout
+	}
+
+	return nil
+}
+
+func (mysqlDialect) Unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+
+	return err
+}
+
+// sqliteDialect relies on sqlite allowing a single writer, every migration runs in its own write transaction
+type sqliteDialect struct{}
+
+func (sqliteDialect) Placeholder(_ int) string {
+	return "?"
+}
+
+func (sqliteDialect) Lock(_ context.Context, _ *sql.Conn) error {
+	return nil
+}
+
+func (sqliteDialect) Unlock(_ context.Context, _ *sql.Conn) error {
+	return nil
+}
+
+// postgresDialect takes a session level advisory lock keyed by the hash of the lock name
+type postgresDialect struct{}
+
+// postgresLockRetryInterval is how often a postgres migrator tries to take the lock
+const postgresLockRetryInterval = 500 * time.Millisecond
+
+func (postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgresDialect) Lock(ctx context.Context, conn *sql.Conn) error {
+	deadline := time.Now().Add(lockTimeoutSeconds * time.Second)
+
+	for {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", postgresLockKey()).Scan(&acquired); err != nil {
+			return err
+		}
+
+		if acquired {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(postgresLockRetryInterval):
+		}
+	}
+}
+
+func (postgresDialect) Unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", postgresLockKey())
+
+	return err
+}
+
+func postgresLockKey() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(lockName))
+
+	return int64(h.Sum64())
+}
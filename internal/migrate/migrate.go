@@ -0,0 +1,481 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TableName is the table applied migrations are recorded in
+const TableName = "schema_migrations"
+
+// errors
+var (
+	ErrUnexpectedVersion = errors.New("database schema version is not the expected version")
+	ErrChecksumMismatch  = errors.New("applied migration differs from its script")
+	ErrUnknownVersion    = errors.New("unknown migration version")
+	ErrNoDownScript      = errors.New("migration has no down script")
+	ErrInvalidMigration  = errors.New("invalid migration file")
+)
+
+// filePattern matches migration file names, e.g. 0001_baseline.up.sql
+var filePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration represents a versioned schema change with its up and down scripts
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status represents a migration and whether it is applied to the database
+type Status struct {
+	Version          int64
+	Name             string
+	Applied          bool
+	AppliedAt        time.Time
+	ChecksumMismatch bool
+}
+
+// Migrator applies and rolls back migrations read from a file system, so that each dialect can ship its own scripts
+type Migrator struct {
+	db      *sql.DB
+	fsys    fs.FS
+	dialect Dialect
+}
+
+// conn defines what is shared by a database handle and a single connection
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// NewMigrator creates and returns migrator running the migrations found at the root of fsys
+func NewMigrator(db *sql.DB, fsys fs.FS, dialect Dialect) *Migrator {
+	return &Migrator{
+		db:      db,
+		fsys:    fsys,
+		dialect: dialect,
+	}
+}
+
+// Migrations returns migrations ordered by version
+func (m *Migrator) Migrations() ([]*Migration, error) {
+	return Load(m.fsys)
+}
+
+// Load reads migrations from the root of fsys and returns them ordered by version
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations failed, %s", err.Error())
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+
+		parts := filePattern.FindStringSubmatch(e.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("%w, %s", ErrInvalidMigration, e.Name())
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w, %s", ErrInvalidMigration, e.Name())
+		}
+
+		script, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s failed, %s", e.Name(), err.Error())
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = mg
+		}
+
+		if mg.Name != parts[2] {
+			return nil, fmt.Errorf("%w, version %d has more than one name", ErrInvalidMigration, version)
+		}
+
+		if parts[3] == "up" {
+			mg.Up = string(script)
+		} else {
+			mg.Down = string(script)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, fmt.Errorf("%w, version %d has no up script", ErrInvalidMigration, mg.Version)
+		}
+
+		sum := sha256.Sum256([]byte(mg.Up))
+		mg.Checksum = hex.EncodeToString(sum[:])
+
+		migrations = append(migrations, mg)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the version of the newest migration, 0 when there are none
+func (m *Migrator) Latest() (int64, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// Version returns the newest applied version, 0 when nothing is applied
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err := m.db.QueryRowContext(ctx, "SELECT MAX(version) FROM "+TableName).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version.Int64, nil
+}
+
+// Status returns every known and applied migration ordered by version
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*Status, 0, len(migrations))
+	for _, mg := range migrations {
+		st := &Status{
+			Version: mg.Version,
+			Name:    mg.Name,
+		}
+
+		if a, ok := applied[mg.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = a.appliedAt
+			st.ChecksumMismatch = a.checksum != mg.Checksum
+			delete(applied, mg.Version)
+		}
+
+		statuses = append(statuses, st)
+	}
+
+	// versions applied by a newer binary have no script here
+	for version, a := range applied {
+		statuses = append(statuses, &Status{
+			Version:   version,
+			Name:      a.name,
+			Applied:   true,
+			AppliedAt: a.appliedAt,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Check returns an error unless exactly the known migrations are applied with unchanged scripts
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	latest, err := m.Latest()
+	if err != nil {
+		return err
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version != latest {
+		return fmt.Errorf("%w, database is at %d, expected %d", ErrUnexpectedVersion, version, latest)
+	}
+
+	for _, st := range statuses {
+		if !st.Applied {
+			return fmt.Errorf("%w, %d_%s is not applied", ErrUnexpectedVersion, st.Version, st.Name)
+		}
+
+		if st.ChecksumMismatch {
+			return fmt.Errorf("%w, %d_%s", ErrChecksumMismatch, st.Version, st.Name)
+		}
+	}
+
+	return nil
+}
+
+// Up applies every pending migration and returns the applied ones
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	latest, err := m.Latest()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.To(ctx, latest)
+}
+
+// Down rolls back the newest applied migration and returns it, nil when nothing is applied
+func (m *Migrator) Down(ctx context.Context) ([]*Migration, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == 0 {
+		return nil, nil
+	}
+
+	target := int64(0)
+	for _, mg := range migrations {
+		if mg.Version < version {
+			target = mg.Version
+		}
+	}
+
+	return m.To(ctx, target)
+}
+
+// To applies or rolls back migrations until version is the newest applied one, 0 rolls back everything
+func (m *Migrator) To(ctx context.Context, version int64) (run []*Migration, err error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 && find(migrations, version) == nil {
+		return nil, fmt.Errorf("%w, %d", ErrUnknownVersion, version)
+	}
+
+	c, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = c.Close()
+	}()
+
+	if err = m.dialect.Lock(ctx, c); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if uerr := m.dialect.Unlock(context.Background(), c); uerr != nil && err == nil {
+			err = uerr
+		}
+	}()
+
+	// read after locking, another migrator may have just finished
+	applied, err := m.applied(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	for v, a := range applied {
+		mg := find(migrations, v)
+		if mg == nil {
+			return nil, fmt.Errorf("%w, %d is applied but has no script", ErrUnknownVersion, v)
+		}
+
+		if a.checksum != mg.Checksum {
+			return nil, fmt.Errorf("%w, %d_%s", ErrChecksumMismatch, mg.Version, mg.Name)
+		}
+	}
+
+	// roll back newest first
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mg := migrations[i]
+		if _, ok := applied[mg.Version]; !ok || mg.Version <= version {
+			continue
+		}
+
+		if mg.Down == "" {
+			return run, fmt.Errorf("%w, %d_%s", ErrNoDownScript, mg.Version, mg.Name)
+		}
+
+		if err = m.run(ctx, c, mg, mg.Down, false); err != nil {
+			return run, err
+		}
+
+		run = append(run, mg)
+	}
+
+	for _, mg := range migrations {
+		if _, ok := applied[mg.Version]; ok || mg.Version > version {
+			continue
+		}
+
+		if err = m.run(ctx, c, mg, mg.Up, true); err != nil {
+			return run, err
+		}
+
+		run = append(run, mg)
+	}
+
+	return run, nil
+}
+
+func (m *Migrator) run(ctx context.Context, c conn, mg *Migration, script string, up bool) error {
+	tx, err := c.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range Statements(script) {
+		if _, err = tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d_%s failed, %s", mg.Version, mg.Name, err.Error())
+		}
+	}
+
+	p := m.dialect.Placeholder
+
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO "+TableName+" (version, name, checksum, applied_at) VALUES ("+p(1)+", "+p(2)+", "+p(3)+", "+p(4)+")",
+			mg.Version, mg.Name, mg.Checksum, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM "+TableName+" WHERE version = "+p(1), mg.Version)
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+func (m *Migrator) applied(ctx context.Context, c conn) (map[int64]appliedMigration, error) {
+	if err := m.ensureTable(ctx, c); err != nil {
+		return nil, err
+	}
+
+	rows, err := c.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM "+TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var a appliedMigration
+
+		if err = rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, c conn) error {
+	_, err := c.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+TableName+` (
+	version BIGINT NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	checksum CHAR(64) NOT NULL,
+	applied_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (version)
+)`)
+
+	return err
+}
+
+// Statements splits a script into statements ending with a semicolon at the end of a line, skipping comment lines
+func Statements(script string) []string {
+	var statements []string
+	var b strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		b.WriteString(line)
+		b.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(b.String()), ";"))
+			b.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(b.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
+
+func find(migrations []*Migration, version int64) *Migration {
+	for _, mg := range migrations {
+		if mg.Version == version {
+			return mg
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_pnr_index.up.sql":   {Data: []byte("CREATE UNIQUE INDEX idx_reservations_pnr ON reservations (pnr);")},
+		"0002_add_pnr_index.down.sql": {Data: []byte("DROP INDEX idx_reservations_pnr ON reservations;")},
+		"0001_baseline.up.sql":        {Data: []byte("CREATE TABLE users (id BIGINT);")},
+		"README.md":                   {Data: []byte("not a migration")},
+	}
+
+	migrations, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "baseline", migrations[0].Name)
+	assert.Empty(t, migrations[0].Down)
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Equal(t, "add_pnr_index", migrations[1].Name)
+	assert.NotEmpty(t, migrations[1].Down)
+	assert.Len(t, migrations[1].Checksum, 64)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	_, err := Load(fstest.MapFS{
+		"baseline.up.sql": {Data: []byte("CREATE TABLE users (id BIGINT);")},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidMigration))
+
+	_, err = Load(fstest.MapFS{
+		"0001_baseline.down.sql": {Data: []byte("DROP TABLE users;")},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidMigration))
+}
+
+func TestStatements(t *testing.T) {
+	script := `-- users
+CREATE TABLE users (
+    id BIGINT,
+    name VARCHAR(255) DEFAULT 'a;b'
+);
+
+INSERT INTO users (id) VALUES (1);
+`
+
+	assert.Equal(t, []string{
+		"CREATE TABLE users (\n    id BIGINT,\n    name VARCHAR(255) DEFAULT 'a;b'\n)",
+		"INSERT INTO users (id) VALUES (1)",
+	}, Statements(script))
+}
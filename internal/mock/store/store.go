@@ -0,0 +1,287 @@
+package storemock
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"hotel-california-backend/internal/store"
+	"time"
+)
+
+// compile-time proof of mongo store interface implementation
+var _ store.Store = (*Store)(nil)
+
+// Store represents mock mongo store
+type Store struct {
+	mock.Mock
+}
+
+// NewStore returns mock store
+func NewStore() *Store {
+	return &Store{}
+}
+
+func (s *Store) SignIn(ctx context.Context, username, password string) (usr *store.User, err error) {
+	args := s.Called(ctx, username, password)
+	return args.Get(0).(*store.User), args.Error(1)
+}
+
+func (s *Store) CreateReservation(ctx context.Context, res *store.Reservation) error {
+	args := s.Called(ctx, res)
+	return args.Error(0)
+}
+
+func (s *Store) UpdateReservation(ctx context.Context, res *store.Reservation) error {
+	args := s.Called(ctx, res)
+	return args.Error(0)
+}
+
+func (s *Store) CancelReservation(ctx context.Context, res *store.Reservation) error {
+	args := s.Called(ctx, res)
+	return args.Error(0)
+}
+
+func (s *Store) DueReservationReminders(ctx context.Context, from, to time.Time, limit int) ([]*store.Reservation, error) {
+	args := s.Called(ctx, from, to, limit)
+	return args.Get(0).([]*store.Reservation), args.Error(1)
+}
+
+func (s *Store) RemindReservation(ctx context.Context, res *store.Reservation, remindedAt time.Time) (bool, error) {
+	args := s.Called(ctx, res, remindedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (s *Store) ExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*store.Reservation, error) {
+	args := s.Called(ctx, now, limit)
+	return args.Get(0).([]*store.Reservation), args.Error(1)
+}
+
+func (s *Store) ConfirmReservation(ctx context.Context, res *store.Reservation) error {
+	args := s.Called(ctx, res)
+	return args.Error(0)
+}
+
+func (s *Store) ExpireReservation(ctx context.Context, res *store.Reservation) error {
+	args := s.Called(ctx, res)
+	return args.Error(0)
+}
+
+func (s *Store) CheckInReservation(ctx context.Context, res *store.Reservation, at time.Time) error {
+	args := s.Called(ctx, res, at)
+	return args.Error(0)
+}
+
+func (s *Store) NoShowReservations(ctx context.Context, arrivedBy time.Time, limit int) ([]*store.Reservation, error) {
+	args := s.Called(ctx, arrivedBy, limit)
+	return args.Get(0).([]*store.Reservation), args.Error(1)
+}
+
+func (s *Store) MarkReservationNoShow(ctx context.Context, res *store.Reservation, penaltyAmount int64, penaltyCurrency string) error {
+	args := s.Called(ctx, res, penaltyAmount, penaltyCurrency)
+	return args.Error(0)
+}
+
+func (s *Store) CompletableReservations(ctx context.Context, now time.Time, limit int) ([]*store.Reservation, error) {
+	args := s.Called(ctx, now, limit)
+	return args.Get(0).([]*store.Reservation), args.Error(1)
+}
+
+func (s *Store) CompleteReservation(ctx context.Context, res *store.Reservation) error {
+	args := s.Called(ctx, res)
+	return args.Error(0)
+}
+
+func (s *Store) FindReservationByPNR(ctx context.Context, pnr string) (*store.Reservation, error) {
+	args := s.Called(ctx, pnr)
+	return args.Get(0).(*store.Reservation), args.Error(1)
+}
+
+func (s *Store) FindReservationAudits(ctx context.Context, pnr string) ([]*store.ReservationAudit, error) {
+	args := s.Called(ctx, pnr)
+	return args.Get(0).([]*store.ReservationAudit), args.Error(1)
+}
+
+func (s *Store) FindUser(ctx context.Context, id int64) (*store.User, error) {
+	args := s.Called(ctx, id)
+	return args.Get(0).(*store.User), args.Error(1)
+}
+
+func (s *Store) PendingOutboxEvents(ctx context.Context, limit int) ([]*store.OutboxEvent, error) {
+	args := s.Called(ctx, limit)
+	return args.Get(0).([]*store.OutboxEvent), args.Error(1)
+}
+
+func (s *Store) MarkOutboxEventPublished(ctx context.Context, id int64, publishedAt time.Time) error {
+	args := s.Called(ctx, id, publishedAt)
+	return args.Error(0)
+}
+
+func (s *Store) MarkOutboxDeliveryPublished(ctx context.Context, id int64, publisher string) error {
+	args := s.Called(ctx, id, publisher)
+	return args.Error(0)
+}
+
+func (s *Store) MarkOutboxDeliveryFailed(ctx context.Context, id int64, publisher, lastError string, maxAttempts int) (string, error) {
+	args := s.Called(ctx, id, publisher, lastError, maxAttempts)
+	return args.String(0), args.Error(1)
+}
+
+func (s *Store) DeletePublishedOutboxEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := s.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (s *Store) CreateWebhookSubscription(ctx context.Context, sub *store.WebhookSubscription) error {
+	args := s.Called(ctx, sub)
+	return args.Error(0)
+}
+
+func (s *Store) FindWebhookSubscriptions(ctx context.Context) ([]*store.WebhookSubscription, error) {
+	args := s.Called(ctx)
+	return args.Get(0).([]*store.WebhookSubscription), args.Error(1)
+}
+
+func (s *Store) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	args := s.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (s *Store) CreateNotification(ctx context.Context, n *store.Notification) error {
+	args := s.Called(ctx, n)
+	return args.Error(0)
+}
+
+func (s *Store) DueNotifications(ctx context.Context, now time.Time, limit int) ([]*store.Notification, error) {
+	args := s.Called(ctx, now, limit)
+	return args.Get(0).([]*store.Notification), args.Error(1)
+}
+
+func (s *Store) ClaimNotification(ctx context.Context, n *store.Notification, until time.Time) (bool, error) {
+	args := s.Called(ctx, n, until)
+	return args.Bool(0), args.Error(1)
+}
+
+func (s *Store) MarkNotificationSent(ctx context.Context, id int64, at time.Time) error {
+	args := s.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (s *Store) DeleteNotifications(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := s.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, d *store.WebhookDelivery) error {
+	args := s.Called(ctx, d)
+	return args.Error(0)
+}
+
+func (s *Store) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*store.WebhookDelivery, error) {
+	args := s.Called(ctx, now, limit)
+	return args.Get(0).([]*store.WebhookDelivery), args.Error(1)
+}
+
+func (s *Store) ClaimWebhookDelivery(ctx context.Context, d *store.WebhookDelivery, until time.Time) (bool, error) {
+	args := s.Called(ctx, d, until)
+	return args.Bool(0), args.Error(1)
+}
+
+func (s *Store) RecordWebhookAttempt(ctx context.Context, d *store.WebhookDelivery, attempt *store.WebhookDeliveryAttempt) error {
+	args := s.Called(ctx, d, attempt)
+	return args.Error(0)
+}
+
+func (s *Store) FindWebhookDeliveries(ctx context.Context, q store.WebhookDeliveryQuery) ([]*store.WebhookDelivery, error) {
+	args := s.Called(ctx, q)
+	return args.Get(0).([]*store.WebhookDelivery), args.Error(1)
+}
+
+func (s *Store) RedeliverWebhookDelivery(ctx context.Context, id int64, now time.Time) error {
+	args := s.Called(ctx, id, now)
+	return args.Error(0)
+}
+
+func (s *Store) EnsureJob(ctx context.Context, job *store.Job) error {
+	args := s.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (s *Store) AcquireJob(ctx context.Context, name, owner string, now, lockedUntil time.Time) (*store.Job, error) {
+	args := s.Called(ctx, name, owner, now, lockedUntil)
+	return args.Get(0).(*store.Job), args.Error(1)
+}
+
+func (s *Store) CompleteJob(ctx context.Context, job *store.Job) error {
+	args := s.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (s *Store) CreateCalendarToken(ctx context.Context, token *store.CalendarToken) error {
+	args := s.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (s *Store) FindCalendarToken(ctx context.Context, tokenHash string) (*store.CalendarToken, error) {
+	args := s.Called(ctx, tokenHash)
+	return args.Get(0).(*store.CalendarToken), args.Error(1)
+}
+
+func (s *Store) IssueInvoice(ctx context.Context, inv *store.Invoice) error {
+	args := s.Called(ctx, inv)
+	return args.Error(0)
+}
+
+func (s *Store) FindInvoice(ctx context.Context, reservationID int64) (*store.Invoice, error) {
+	args := s.Called(ctx, reservationID)
+	return args.Get(0).(*store.Invoice), args.Error(1)
+}
+
+func (s *Store) RevokeCalendarTokens(ctx context.Context, userID int64, revokedAt time.Time) (int64, error) {
+	args := s.Called(ctx, userID, revokedAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (s *Store) FindReservation(ctx context.Context, pnr string, userID int64) (*store.Reservation, error) {
+	args := s.Called(ctx, pnr, userID)
+	return args.Get(0).(*store.Reservation), args.Error(1)
+}
+
+func (s *Store) FindReservations(ctx context.Context, q store.ReservationQuery) ([]*store.Reservation, int64, error) {
+	args := s.Called(ctx, q)
+	return args.Get(0).([]*store.Reservation), args.Get(1).(int64), args.Error(2)
+}
+
+func (s *Store) AcquireIdempotencyKey(ctx context.Context, key *store.IdempotencyKey) (*store.IdempotencyKey, error) {
+	args := s.Called(ctx, key)
+	return args.Get(0).(*store.IdempotencyKey), args.Error(1)
+}
+
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, id int64, response string) error {
+	args := s.Called(ctx, id, response)
+	return args.Error(0)
+}
+
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, id int64) error {
+	args := s.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (s *Store) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time, limit int) (int64, error) {
+	args := s.Called(ctx, now, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (s *Store) Ping(ctx context.Context) error {
+	args := s.Called(ctx)
+	return args.Error(0)
+}
+
+func (s *Store) Stats() sql.DBStats {
+	args := s.Called()
+	return args.Get(0).(sql.DBStats)
+}
+
+func (s *Store) Close() error {
+	args := s.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// FileSender drops messages as .eml files into a directory instead of sending them, it is meant for development
+type FileSender struct {
+	dir string
+	seq atomic.Int64
+}
+
+// NewFileSender creates the directory if missing and returns file sender writing into it
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileSender{dir: dir}, nil
+}
+
+// Send writes the message to a file named after its date and recipient
+func (s *FileSender) Send(_ context.Context, m Message) error {
+	to, err := address(m.To)
+	if err != nil {
+		return err
+	}
+
+	msg, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+
+	// the sequence keeps messages sent in the same nanosecond apart
+	name := fmt.Sprintf("%d-%d-%s.eml", m.Date.UnixNano(), s.seq.Add(1), strings.NewReplacer("@", "_at_", "/", "_").Replace(to))
+
+	return os.WriteFile(filepath.Join(s.dir, name), msg, 0o644)
+}
@@ -0,0 +1,74 @@
+// Package notification sends guests emails about their reservations
+package notification
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"time"
+)
+
+// Message represents a plain text email
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+	Date    time.Time
+}
+
+// Sender defines behaviors of email senders
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// compile-time proofs of sender interface implementation
+var (
+	_ Sender = (*SMTPSender)(nil)
+	_ Sender = (*FileSender)(nil)
+)
+
+// Bytes returns the message in RFC 5322 format, the subject and the body are encoded so that non-ascii text
+// survives every relay
+func (m Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	headers := [][2]string{
+		{"From", m.From},
+		{"To", m.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", m.Date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+
+	for _, h := range headers {
+		buf.WriteString(h[0] + ": " + h[1] + "\r\n")
+	}
+
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write(bytes.ReplaceAll([]byte(m.Body), []byte("\n"), []byte("\r\n"))); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// address returns the bare address of a header value like "Name <name@example.com>"
+func address(value string) (string, error) {
+	a, err := mail.ParseAddress(value)
+	if err != nil {
+		return "", err
+	}
+
+	return a.Address, nil
+}
@@ -0,0 +1,319 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"hotel-california-backend/internal/events"
+	"hotel-california-backend/internal/localization"
+	"hotel-california-backend/internal/store"
+	"net/mail"
+	"sync"
+	"time"
+)
+
+// defaults used for zero options
+const (
+	defaultQueueSize   = 1000
+	defaultWorkers     = 2
+	defaultMaxAttempts = 3
+	defaultRetryDelay  = time.Second
+	defaultInterval    = time.Minute
+	defaultLease       = 10 * time.Minute
+)
+
+// email message keys by event type
+var templates = map[string]struct{ subject, body string }{
+	events.ReservationCreated:     {localization.EmailReservationCreatedSubject, localization.EmailReservationCreatedBody},
+	events.ReservationUpdated:     {localization.EmailReservationUpdatedSubject, localization.EmailReservationUpdatedBody},
+	events.ReservationCancelled:   {localization.EmailReservationCancelledSubject, localization.EmailReservationCancelledBody},
+	events.ReservationReminderDue: {localization.EmailReservationReminderSubject, localization.EmailReservationReminderBody},
+}
+
+// Options represents notifier options, a message failing to send is attempted MaxAttempts times RetryDelay apart.
+// Notifications not sent are claimed every Interval once their Lease expires, the lease must outlast the attempts.
+type Options struct {
+	From        string
+	QueueSize   int
+	Workers     int
+	MaxAttempts int
+	RetryDelay  time.Duration
+	Interval    time.Duration
+	Lease       time.Duration
+}
+
+// Notifier emails guests when their reservations are created, modified or cancelled, and before they check in. Messages are rendered when
+// events are handled and sent by background workers, so that the relay does not wait for the mail server.
+type Notifier struct {
+	l      log.Logger
+	ms     store.Store
+	sender Sender
+	opts   Options
+	queue  chan *store.Notification
+	now    func() time.Time
+}
+
+// NewNotifier creates and returns notifier
+func NewNotifier(l log.Logger, ms store.Store, sender Sender, opts Options) *Notifier {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultRetryDelay
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+
+	if opts.Lease <= 0 {
+		opts.Lease = defaultLease
+	}
+
+	return &Notifier{
+		l:      l,
+		ms:     ms,
+		sender: sender,
+		opts:   opts,
+		queue:  make(chan *store.Notification, opts.QueueSize),
+		now:    time.Now,
+	}
+}
+
+// Handle renders the email of a reservation event in the language the change was made in and queues it, it is
+// subscribed to the event bus. Users are emailed at their user name, users whose user name is not an email address
+// are skipped. Events are published at least once, so the notification of an event is recorded with the message
+// before it is queued and events already notified of are skipped. A message that can not be queued stays recorded
+// and is sent once its lease expires.
+func (n *Notifier) Handle(ctx context.Context, e events.Event) error {
+	t, ok := templates[e.Type]
+	if !ok {
+		return nil
+	}
+
+	var data events.ReservationData
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return err
+	}
+
+	usr, err := n.ms.FindUser(ctx, data.UserID)
+	if err != nil {
+		return err
+	}
+
+	to, err := mail.ParseAddress(usr.Username)
+	if err != nil {
+		_ = level.Warn(n.l).Log(
+			"action", "Handle",
+			"event", e.Type,
+			"userId", usr.ID,
+			"error", "user name is not an email address, "+err.Error(),
+		)
+
+		return nil
+	}
+
+	templateData := map[string]interface{}{
+		"FirstName":     usr.FirstName,
+		"LastName":      usr.LastName,
+		"PNR":           data.PNR,
+		"Destination":   data.Destination,
+		"CheckInDate":   data.CheckInDate,
+		"CheckOutDate":  data.CheckOutDate,
+		"Accommodation": data.Accommodation,
+		"GuestCount":    data.GuestCount,
+	}
+
+	subject, err := localization.LocalizeMessage(data.Language, t.subject, templateData)
+	if err != nil {
+		return err
+	}
+
+	body, err := localization.LocalizeMessage(data.Language, t.body, templateData)
+	if err != nil {
+		return err
+	}
+
+	now := n.now()
+
+	// the notification is claimed by this instance until its lease expires
+	nt := &store.Notification{
+		EventID:       e.ID,
+		EventType:     e.Type,
+		Recipient:     to.Address,
+		Subject:       subject,
+		Body:          body,
+		NextAttemptAt: now.Add(n.opts.Lease),
+		CreatedAt:     now,
+	}
+
+	err = n.ms.CreateNotification(ctx, nt)
+	if errors.Is(err, store.ErrConflict) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	n.enqueue(nt)
+
+	return nil
+}
+
+// Run sends queued messages until ctx is done. Notifications not sent, by an instance that stopped or after the last
+// attempt failed, are claimed every interval once their lease expires, starting when it is called.
+func (n *Notifier) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < n.opts.Workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			n.work(ctx)
+		}()
+	}
+
+	t := time.NewTicker(n.opts.Interval)
+	defer t.Stop()
+
+	for {
+		if err := n.Claim(ctx); err != nil {
+			_ = level.Error(n.l).Log("action", "Claim", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Claim queues due notifications as long as the queue has room, notifications claimed by another instance are
+// skipped
+func (n *Notifier) Claim(ctx context.Context) error {
+	limit := cap(n.queue) - len(n.queue)
+	if limit <= 0 {
+		return nil
+	}
+
+	ns, err := n.ms.DueNotifications(ctx, n.now(), limit)
+	if err != nil {
+		return err
+	}
+
+	for _, nt := range ns {
+		claimed, err := n.ms.ClaimNotification(ctx, nt, n.now().Add(n.opts.Lease))
+		if err != nil {
+			return err
+		}
+
+		if claimed && !n.enqueue(nt) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Drain sends the messages still queued once each until ctx is done, it is called with the shutdown context after Run
+// returns. Messages not sent stay recorded and are sent after the next start once their lease expires.
+func (n *Notifier) Drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		select {
+		case nt := <-n.queue:
+			n.attempt(ctx, nt, n.opts.MaxAttempts)
+		default:
+			return
+		}
+	}
+}
+
+func (n *Notifier) enqueue(nt *store.Notification) bool {
+	select {
+	case n.queue <- nt:
+		return true
+	default:
+		_ = level.Warn(n.l).Log(
+			"action", "Enqueue",
+			"event", nt.EventType,
+			"eventId", nt.EventID,
+			"error", "notification queue is full, the message is sent once its lease expires",
+		)
+
+		return false
+	}
+}
+
+func (n *Notifier) work(ctx context.Context) {
+	for {
+		select {
+		case nt := <-n.queue:
+			n.send(ctx, nt)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, nt *store.Notification) {
+	for attempt := 1; attempt <= n.opts.MaxAttempts; attempt++ {
+		if n.attempt(ctx, nt, attempt) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(n.opts.RetryDelay * time.Duration(attempt)):
+		}
+	}
+}
+
+func (n *Notifier) attempt(ctx context.Context, nt *store.Notification, attempt int) bool {
+	m := Message{
+		From:    n.opts.From,
+		To:      nt.Recipient,
+		Subject: nt.Subject,
+		Body:    nt.Body,
+		Date:    nt.CreatedAt,
+	}
+
+	err := n.sender.Send(ctx, m)
+	if err != nil {
+		_ = level.Error(n.l).Log(
+			"action", "Send",
+			"subject", m.Subject,
+			"attempt", attempt,
+			"error", err.Error(),
+		)
+
+		return false
+	}
+
+	// the message is sent, so it is marked even when ctx is done meanwhile
+	if err = n.ms.MarkNotificationSent(context.WithoutCancel(ctx), nt.ID, n.now()); err != nil {
+		_ = level.Error(n.l).Log(
+			"action", "MarkNotificationSent",
+			"eventId", nt.EventID,
+			"error", err.Error(),
+		)
+	}
+
+	return true
+}
@@ -0,0 +1,235 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"hotel-california-backend/configs/envvars"
+	"hotel-california-backend/internal/events"
+	"hotel-california-backend/internal/localization"
+	storemock "hotel-california-backend/internal/mock/store"
+	"hotel-california-backend/internal/store"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStandIn accepts a single message per connection and hands it over on messages
+type smtpStandIn struct {
+	ln       net.Listener
+	messages chan string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &smtpStandIn{ln: ln, messages: make(chan string, 1)}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 end with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+
+				data.WriteString(l)
+			}
+
+			s.messages <- data.String()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func initializeBundle(t *testing.T) {
+	err := localization.InitializeBundle(envvars.Localization{
+		LanguageFilesDirectory: "../localization/language-files",
+	})
+	require.NoError(t, err)
+}
+
+func reservationEvent(t *testing.T, eventType, language string) events.Event {
+	data, err := json.Marshal(events.ReservationData{
+		PNR:           "HC7K2M9Q",
+		UserID:        1,
+		Destination:   "İzmir",
+		CheckInDate:   "2024-03-24",
+		CheckOutDate:  "2024-03-30",
+		Accommodation: "beach",
+		GuestCount:    2,
+		Language:      language,
+	})
+	require.NoError(t, err)
+
+	return events.Event{ID: "2f1c8a3e", Type: eventType, AggregateID: "HC7K2M9Q", Data: data}
+}
+
+func TestNotifier_SendsLocalizedEmailOverSMTP(t *testing.T) {
+	initializeBundle(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := newSMTPStandIn(t)
+	port := srv.ln.Addr().(*net.TCPAddr).Port
+
+	ms := storemock.NewStore()
+	ms.On("FindUser", ctx, int64(1)).Return(&store.User{ID: 1, FirstName: "Erhan", LastName: "Yılmaz", Username: "erhan@example.com"}, nil)
+	ms.On("CreateNotification", ctx, mock.Anything).Return(nil)
+	ms.On("DueNotifications", ctx, mock.Anything, mock.Anything).Return([]*store.Notification{}, nil)
+	ms.On("MarkNotificationSent", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	n := NewNotifier(log.NewNopLogger(), ms, NewSMTPSender("127.0.0.1", port, "", "", time.Second), Options{
+		From: "Hotel California <no-reply@hotel-california.local>",
+	})
+
+	go n.Run(ctx)
+
+	require.NoError(t, n.Handle(ctx, reservationEvent(t, events.ReservationCancelled, "tr")))
+
+	var raw string
+	select {
+	case raw = <-srv.messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+
+	assert.Equal(t, "erhan@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "HC7K2M9Q numaralı rezervasyonunuz iptal edildi", subject)
+	assert.Contains(t, string(body), "Sayın Erhan Yılmaz")
+	assert.Contains(t, string(body), "İzmir için 2024-03-24 - 2024-03-30 tarihli")
+}
+
+func TestNotifier_FileSender(t *testing.T) {
+	initializeBundle(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	dir := t.TempDir()
+	fs, err := NewFileSender(dir)
+	require.NoError(t, err)
+
+	ms := storemock.NewStore()
+	ms.On("FindUser", ctx, int64(1)).Return(&store.User{ID: 1, FirstName: "Erhan", LastName: "Yilmaz", Username: "erhan@example.com"}, nil)
+	ms.On("CreateNotification", ctx, mock.MatchedBy(func(n *store.Notification) bool {
+		return n.EventID == "2f1c8a3e" && n.Recipient == "erhan@example.com"
+	})).Return(nil).Once()
+	ms.On("CreateNotification", ctx, mock.Anything).Return(store.ErrConflict)
+	ms.On("MarkNotificationSent", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	n := NewNotifier(log.NewNopLogger(), ms, fs, Options{From: "no-reply@hotel-california.local"})
+
+	require.NoError(t, n.Handle(ctx, reservationEvent(t, events.ReservationCreated, "en")))
+
+	// an event published again is not emailed twice
+	require.NoError(t, n.Handle(ctx, reservationEvent(t, events.ReservationCreated, "en")))
+
+	// queued messages are sent when the notifier stops
+	cancel()
+	n.Drain(context.Background())
+
+	ms.AssertExpectations(t)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*erhan_at_example.com.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	raw, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "Subject: Your reservation HC7K2M9Q is confirmed")
+}
+
+func TestNotifier_SkipsUserWithoutEmailAddress(t *testing.T) {
+	initializeBundle(t)
+
+	ctx := context.Background()
+
+	ms := storemock.NewStore()
+	ms.On("FindUser", ctx, int64(1)).Return(&store.User{ID: 1, FirstName: "Erhan", LastName: "Yilmaz", Username: "erhan"}, nil)
+
+	n := NewNotifier(log.NewNopLogger(), ms, NewSMTPSender("127.0.0.1", 1, "", "", time.Second), Options{})
+
+	require.NoError(t, n.Handle(ctx, reservationEvent(t, events.ReservationCreated, "en")))
+	ms.AssertNotCalled(t, "CreateNotification", mock.Anything, mock.Anything)
+}
+
+func TestNotifier_ClaimQueuesDueNotifications(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 24, 12, 0, 0, 0, time.UTC)
+
+	sent := &store.Notification{ID: 1, EventID: "2f1c8a3e", Recipient: "erhan@example.com", NextAttemptAt: now}
+	taken := &store.Notification{ID: 2, EventID: "5b7d9e1f", Recipient: "erhan@example.com", NextAttemptAt: now}
+
+	ms := storemock.NewStore()
+	ms.On("DueNotifications", ctx, now, 1).Return([]*store.Notification{sent, taken}, nil)
+	ms.On("ClaimNotification", ctx, sent, now.Add(time.Minute)).Return(true, nil)
+	ms.On("ClaimNotification", ctx, taken, now.Add(time.Minute)).Return(false, nil)
+
+	n := NewNotifier(log.NewNopLogger(), ms, nil, Options{QueueSize: 1, Lease: time.Minute})
+	n.now = func() time.Time { return now }
+
+	require.NoError(t, n.Claim(ctx))
+	require.Len(t, n.queue, 1)
+	assert.Equal(t, sent, <-n.queue)
+}
@@ -0,0 +1,106 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPSender sends messages through an smtp server, the connection is upgraded with STARTTLS when the server
+// supports it
+type SMTPSender struct {
+	host     string
+	addr     string
+	userName string
+	password string
+	timeout  time.Duration
+}
+
+// NewSMTPSender creates and returns smtp sender, messages are sent without authentication when user name is empty
+func NewSMTPSender(host string, port int, userName, password string, timeout time.Duration) *SMTPSender {
+	return &SMTPSender{
+		host:     host,
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		userName: userName,
+		password: password,
+		timeout:  timeout,
+	}
+}
+
+// Send sends the message, waiting at most timeout for the server
+func (s *SMTPSender) Send(ctx context.Context, m Message) error {
+	from, err := address(m.From)
+	if err != nil {
+		return err
+	}
+
+	to, err := address(m.To)
+	if err != nil {
+		return err
+	}
+
+	msg, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+
+	if s.userName != "" {
+		if err = c.Auth(smtp.PlainAuth("", s.userName, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Mail(from); err != nil {
+		return err
+	}
+
+	if err = c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
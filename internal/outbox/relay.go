@@ -0,0 +1,228 @@
+// Package outbox publishes the domain events written to the outbox table
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"hotel-california-backend/internal/events"
+	"hotel-california-backend/internal/store"
+	"time"
+)
+
+// defaults used for zero options
+const (
+	defaultInterval    = time.Second
+	defaultBatchSize   = 100
+	defaultMaxAttempts = 10
+)
+
+// pruneInterval is how often published events older than the retention are deleted
+const pruneInterval = time.Hour
+
+// Options represents relay options, published events are kept for Retention and forever when it is zero. An event
+// failing on a publisher MaxAttempts times is dead for that publisher.
+type Options struct {
+	Interval    time.Duration
+	BatchSize   int
+	Retention   time.Duration
+	MaxAttempts int
+}
+
+// Publisher represents a publisher events are relayed through, Name identifies its deliveries in the outbox
+type Publisher struct {
+	Name string
+	events.Publisher
+}
+
+// Relay publishes pending outbox events through every publisher in the order they were written. Events are published
+// at least once, an event is published again when marking it published fails or when several instances relay at the
+// same time. Publishers relay on their own, an event failing on one of them is attempted again only through it.
+type Relay struct {
+	l    log.Logger
+	ms   store.Store
+	ps   []Publisher
+	opts Options
+}
+
+// NewRelay creates and returns relay
+func NewRelay(l log.Logger, ms store.Store, ps []Publisher, opts Options) *Relay {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+
+	return &Relay{
+		l:    l,
+		ms:   ms,
+		ps:   ps,
+		opts: opts,
+	}
+}
+
+// Run relays pending events every interval until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	t := time.NewTicker(r.opts.Interval)
+	defer t.Stop()
+
+	var pruned time.Time
+
+	for {
+		// full batches are followed by the next one without waiting
+		for {
+			n, err := r.Relay(ctx)
+			if err != nil || n < r.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		if r.opts.Retention > 0 && time.Since(pruned) >= pruneInterval {
+			r.prune(ctx)
+			pruned = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Relay publishes a batch of pending events and returns how many were published through every publisher. A
+// publisher stops at the first event failing on it, so that the events of a reservation are not published through
+// it out of order, until the event is dead for it after max attempts. The other publishers go on.
+func (r *Relay) Relay(ctx context.Context) (int, error) {
+	oes, err := r.ms.PendingOutboxEvents(ctx, r.opts.BatchSize)
+	if err != nil {
+		r.log(err, "PendingOutboxEvents", nil, "")
+		return 0, err
+	}
+
+	n := 0
+	stopped := make(map[string]bool, len(r.ps))
+
+	var errs []error
+
+	for _, oe := range oes {
+		if len(stopped) == len(r.ps) {
+			break
+		}
+
+		done := true
+
+		for _, p := range r.ps {
+			if oe.Delivered(p.Name) {
+				continue
+			}
+
+			if stopped[p.Name] {
+				done = false
+				continue
+			}
+
+			if err = r.publish(ctx, oe, p); err != nil {
+				stopped[p.Name] = true
+				done = false
+				errs = append(errs, err)
+			}
+		}
+
+		if !done {
+			continue
+		}
+
+		if err = r.ms.MarkOutboxEventPublished(ctx, oe.ID, time.Now()); err != nil {
+			r.log(err, "MarkOutboxEventPublished", oe, "")
+			return n, err
+		}
+
+		n++
+	}
+
+	return n, errors.Join(errs...)
+}
+
+// publish publishes the event through the publisher and records its delivery, the event is done for the publisher
+// when nil is returned
+func (r *Relay) publish(ctx context.Context, oe *store.OutboxEvent, p Publisher) error {
+	e, err := oe.Event()
+	if err == nil {
+		err = p.Publish(ctx, e)
+	}
+
+	if err != nil {
+		r.log(err, "Publish", oe, p.Name)
+
+		status, markErr := r.ms.MarkOutboxDeliveryFailed(ctx, oe.ID, p.Name, err.Error(), r.opts.MaxAttempts)
+		if markErr != nil {
+			r.log(markErr, "MarkOutboxDeliveryFailed", oe, p.Name)
+			return err
+		}
+
+		if status == store.OutboxDeliveryDead {
+			_ = level.Warn(r.l).Log("action", "DeadOutboxDelivery", "event_id", oe.EventID, "event_type", oe.EventType, "publisher", p.Name)
+			return nil
+		}
+
+		return err
+	}
+
+	if err = r.ms.MarkOutboxDeliveryPublished(ctx, oe.ID, p.Name); err != nil {
+		r.log(err, "MarkOutboxDeliveryPublished", oe, p.Name)
+		return err
+	}
+
+	return nil
+}
+
+func (r *Relay) prune(ctx context.Context) {
+	var deleted int64
+
+	for {
+		n, err := r.ms.DeletePublishedOutboxEvents(ctx, time.Now().Add(-r.opts.Retention), r.opts.BatchSize)
+		if err != nil {
+			r.log(err, "DeletePublishedOutboxEvents", nil, "")
+			break
+		}
+
+		deleted += n
+
+		if n < int64(r.opts.BatchSize) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		_ = level.Info(r.l).Log("action", "DeletePublishedOutboxEvents", "deleted", deleted)
+	}
+}
+
+func (r *Relay) log(err error, action string, oe *store.OutboxEvent, publisher string) {
+	logParams := []interface{}{"action", action}
+
+	if oe != nil {
+		logParams = append(logParams, "event_id", oe.EventID, "event_type", oe.EventType)
+	}
+
+	if publisher != "" {
+		attempts := 1
+		if d := oe.Delivery(publisher); d != nil {
+			attempts += d.Attempts
+		}
+
+		logParams = append(logParams, "publisher", publisher, "attempts", attempts)
+	}
+
+	logParams = append(logParams, "error", err.Error())
+
+	_ = level.Error(r.l).Log(logParams...)
+}
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"hotel-california-backend/internal/events"
+	storemock "hotel-california-backend/internal/mock/store"
+	"hotel-california-backend/internal/store"
+	"testing"
+)
+
+func newOutboxEvent(t *testing.T, id int64, eventID string) *store.OutboxEvent {
+	payload, err := json.Marshal(events.Event{ID: eventID, Type: events.ReservationCreated})
+	require.NoError(t, err)
+
+	return &store.OutboxEvent{ID: id, EventID: eventID, EventType: events.ReservationCreated, Payload: string(payload)}
+}
+
+// recorder is a publisher recording the events it publishes, it fails the event of failID
+type recorder struct {
+	failID    string
+	published []string
+}
+
+func (r *recorder) Publish(_ context.Context, e events.Event) error {
+	if e.ID == r.failID {
+		return errors.New("endpoint is down")
+	}
+
+	r.published = append(r.published, e.ID)
+
+	return nil
+}
+
+func TestRelay_StopsPublisherAtFailedEvent(t *testing.T) {
+	ctx := context.Background()
+
+	ms := storemock.NewStore()
+	ms.On("PendingOutboxEvents", ctx, 10).Return([]*store.OutboxEvent{
+		newOutboxEvent(t, 1, "2f1c8a3e"),
+		newOutboxEvent(t, 2, "5b7d9e1f"),
+		newOutboxEvent(t, 3, "7a9c1e3b"),
+	}, nil)
+	ms.On("MarkOutboxDeliveryPublished", ctx, mock.Anything, mock.Anything).Return(nil)
+	ms.On("MarkOutboxDeliveryFailed", ctx, int64(2), "http", "endpoint is down", 5).Return(store.OutboxDeliveryPending, nil)
+	ms.On("MarkOutboxEventPublished", ctx, int64(1), mock.Anything).Return(nil)
+
+	bus := &recorder{}
+	hp := &recorder{failID: "5b7d9e1f"}
+
+	r := NewRelay(log.NewNopLogger(), ms, []Publisher{{Name: "bus", Publisher: bus}, {Name: "http", Publisher: hp}}, Options{BatchSize: 10, MaxAttempts: 5})
+
+	n, err := r.Relay(ctx)
+
+	// the bus is not held back by the http publisher and does not publish the failed event again
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"2f1c8a3e", "5b7d9e1f", "7a9c1e3b"}, bus.published)
+	assert.Equal(t, []string{"2f1c8a3e"}, hp.published)
+	ms.AssertExpectations(t)
+	ms.AssertNotCalled(t, "MarkOutboxEventPublished", ctx, int64(2), mock.Anything)
+	ms.AssertNotCalled(t, "MarkOutboxEventPublished", ctx, int64(3), mock.Anything)
+
+	// the next batch publishes the pending events only through the http publisher
+	delivered := newOutboxEvent(t, 3, "7a9c1e3b")
+	delivered.Deliveries = []*store.OutboxDelivery{{Publisher: "bus", Status: store.OutboxDeliveryPublished}}
+
+	ms = storemock.NewStore()
+	ms.On("PendingOutboxEvents", ctx, 10).Return([]*store.OutboxEvent{delivered}, nil)
+	ms.On("MarkOutboxDeliveryPublished", ctx, int64(3), "http").Return(nil)
+	ms.On("MarkOutboxEventPublished", ctx, int64(3), mock.Anything).Return(nil)
+
+	bus.published = nil
+	r = NewRelay(log.NewNopLogger(), ms, []Publisher{{Name: "bus", Publisher: bus}, {Name: "http", Publisher: hp}}, Options{BatchSize: 10, MaxAttempts: 5})
+
+	n, err = r.Relay(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, bus.published)
+	ms.AssertExpectations(t)
+}
+
+func TestRelay_DeadEvent(t *testing.T) {
+	ctx := context.Background()
+
+	ms := storemock.NewStore()
+	ms.On("PendingOutboxEvents", ctx, 10).Return([]*store.OutboxEvent{
+		newOutboxEvent(t, 1, "2f1c8a3e"),
+		newOutboxEvent(t, 2, "5b7d9e1f"),
+	}, nil)
+	ms.On("MarkOutboxDeliveryFailed", ctx, int64(1), "http", "endpoint is down", 5).Return(store.OutboxDeliveryDead, nil)
+	ms.On("MarkOutboxDeliveryPublished", ctx, int64(2), "http").Return(nil)
+	ms.On("MarkOutboxEventPublished", ctx, mock.Anything, mock.Anything).Return(nil)
+
+	hp := &recorder{failID: "2f1c8a3e"}
+
+	r := NewRelay(log.NewNopLogger(), ms, []Publisher{{Name: "http", Publisher: hp}}, Options{BatchSize: 10, MaxAttempts: 5})
+
+	n, err := r.Relay(ctx)
+
+	// a dead event no longer holds the publisher back
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"5b7d9e1f"}, hp.published)
+	ms.AssertExpectations(t)
+}
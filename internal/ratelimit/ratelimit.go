@@ -0,0 +1,75 @@
+// Package ratelimit limits the rate of requests per key, e.g. per client ip address, with token buckets kept in
+// memory of the instance
+package ratelimit
+
+import (
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+// buckets idle for idleTTL are forgotten, they are full again by then for any interval up to idleTTL/burst
+const idleTTL = 10 * time.Minute
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter allows burst requests per key at once and one more every interval, a zero interval allows every request
+type Limiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New creates and returns limiter
+func New(interval time.Duration, burst int) *Limiter {
+	return &Limiter{
+		limit:   rate.Every(interval),
+		burst:   max(burst, 1),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow reports whether a request of the key is allowed now, the request is counted when it is
+func (l *Limiter) Allow(key string) bool {
+	if l.limit == rate.Inf {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	if now.Sub(l.lastSweep) >= idleTTL {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = b
+	}
+
+	b.lastSeen = now
+
+	return b.limiter.AllowN(now, 1)
+}
+
+// sweep forgets the buckets idle since idleTTL so that the buckets of one-off clients do not pile up
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastSweep = now
+}
@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	l := New(time.Minute, 2)
+	l.now = func() time.Time { return now }
+
+	assert.True(t, l.Allow("203.0.113.7"))
+	assert.True(t, l.Allow("203.0.113.7"))
+	assert.False(t, l.Allow("203.0.113.7"))
+
+	// keys have their own buckets
+	assert.True(t, l.Allow("198.51.100.4"))
+
+	now = now.Add(time.Minute)
+	assert.True(t, l.Allow("203.0.113.7"))
+	assert.False(t, l.Allow("203.0.113.7"))
+
+	// idle buckets are forgotten
+	now = now.Add(idleTTL)
+	assert.True(t, l.Allow("203.0.113.7"))
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestLimiter_AllowUnlimited(t *testing.T) {
+	l := New(0, 0)
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, l.Allow("203.0.113.7"))
+	}
+
+	assert.Empty(t, l.buckets)
+}
@@ -0,0 +1,52 @@
+package requestid
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// Header is the http header request ids are propagated with
+const Header = "X-Request-ID"
+
+// maxLength limits length of request ids accepted from callers
+const maxLength = 128
+
+var requestIDKey = struct{ Key string }{"request-id"}
+
+// NewContext returns context carrying the request id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// FromContext returns request id of the context or empty string
+func FromContext(ctx context.Context) string {
+	id, ok := ctx.Value(requestIDKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return id
+}
+
+// New generates and returns new request id
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether the request id received from a caller is safe to propagate and log
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
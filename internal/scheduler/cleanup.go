@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"context"
+	"hotel-california-backend/internal/store"
+	"time"
+)
+
+// cleanup job names
+const (
+	JobDeleteIdempotencyKeys = "delete-idempotency-keys"
+	JobDeleteNotifications   = "delete-notifications"
+)
+
+// NewIdempotencyKeyCleanupJob returns the job deleting the expired idempotency keys in batches, expired keys are not
+// replayed anyway and are only kept until this job runs
+func NewIdempotencyKeyCleanupJob(schedule string, ms store.Store) Job {
+	return Job{
+		Name:     JobDeleteIdempotencyKeys,
+		Schedule: schedule,
+		Run: func(ctx context.Context) error {
+			for {
+				n, err := ms.DeleteExpiredIdempotencyKeys(ctx, time.Now(), defaultBatchSize)
+				if err != nil {
+					return err
+				}
+
+				if n < defaultBatchSize || ctx.Err() != nil {
+					return ctx.Err()
+				}
+			}
+		},
+	}
+}
+
+// NewNotificationCleanupJob returns the job deleting the notifications older than the retention in batches, the
+// retention is kept longer than the outbox retention so that no event is published again after its notification is
+// deleted
+func NewNotificationCleanupJob(schedule string, ms store.Store, retention time.Duration) Job {
+	return Job{
+		Name:     JobDeleteNotifications,
+		Schedule: schedule,
+		Run: func(ctx context.Context) error {
+			for {
+				n, err := ms.DeleteNotifications(ctx, time.Now().Add(-retention), defaultBatchSize)
+				if err != nil {
+					return err
+				}
+
+				if n < defaultBatchSize || ctx.Err() != nil {
+					return ctx.Err()
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"hotel-california-backend/internal/store"
+	"time"
+)
+
+// reservation job names
+const (
+	JobRemindReservations = "remind-reservations"
+	JobExpireReservations = "expire-reservations"
+	JobCloseStays         = "close-stays"
+)
+
+// defaultBatchSize is the number of reservations a job reads at once
+const defaultBatchSize = 100
+
+// Penalty represents the penalty of a no-show in minor units of the currency, no penalty is applied for 0
+type Penalty struct {
+	Amount   int64
+	Currency string
+}
+
+// NewReminderJob returns the job writing a reminder event for every active reservation checking in within the lead
+// time, the notifier emails the guests on these events. A reservation is reminded of once, and again after its
+// check-in date is changed.
+func NewReminderJob(schedule string, ms store.Store, lead time.Duration) Job {
+	return Job{
+		Name:     JobRemindReservations,
+		Schedule: schedule,
+		Run: func(ctx context.Context) error {
+			for {
+				now := time.Now()
+
+				rs, err := ms.DueReservationReminders(ctx, now, now.Add(lead), defaultBatchSize)
+				if err != nil {
+					return err
+				}
+
+				reminded := 0
+				for _, res := range rs {
+					ok, err := ms.RemindReservation(ctx, res, now)
+					if err != nil {
+						return err
+					}
+
+					if ok {
+						reminded++
+					}
+				}
+
+				if len(rs) < defaultBatchSize || reminded == 0 {
+					return nil
+				}
+			}
+		},
+	}
+}
+
+// NewExpiryJob returns the job cancelling the active reservations whose hold has ended, the cancellations are
+// recorded in the audit trail as made by the system
+func NewExpiryJob(schedule string, ms store.Store) Job {
+	return Job{
+		Name:     JobExpireReservations,
+		Schedule: schedule,
+		Run: func(ctx context.Context) error {
+			ctx = store.WithActor(ctx, store.Actor{System: true, RequestID: "job:" + JobExpireReservations})
+
+			for {
+				rs, err := ms.ExpiredReservations(ctx, time.Now(), defaultBatchSize)
+				if err != nil {
+					return err
+				}
+
+				expired := 0
+				for _, res := range rs {
+					err = ms.ExpireReservation(ctx, res)
+
+					// reservations changed since they were read are looked at again by the next run
+					if errors.Is(err, store.ErrNotExpired) || errors.Is(err, store.ErrNotActive) ||
+						errors.Is(err, store.ErrVersionMismatch) || errors.Is(err, store.ErrPastCheckIn) {
+						continue
+					}
+
+					if err != nil {
+						return err
+					}
+
+					expired++
+				}
+
+				if len(rs) < defaultBatchSize || expired == 0 {
+					return nil
+				}
+			}
+		},
+	}
+}
+
+// NewStayJob returns the job closing stays. Reservations whose guests have not checked in by the cutoff after the
+// start of the check-in date are marked as no-shows with the penalty, then the active reservations whose check-out
+// date has passed are completed.
+func NewStayJob(schedule string, ms store.Store, cutoff time.Duration, penalty Penalty) Job {
+	return Job{
+		Name:     JobCloseStays,
+		Schedule: schedule,
+		Run: func(ctx context.Context) error {
+			ctx = store.WithActor(ctx, store.Actor{System: true, RequestID: "job:" + JobCloseStays})
+
+			err := closeReservations(ctx,
+				func(now time.Time) ([]*store.Reservation, error) {
+					return ms.NoShowReservations(ctx, now.Add(-cutoff), defaultBatchSize)
+				},
+				func(res *store.Reservation) error {
+					return ms.MarkReservationNoShow(ctx, res, penalty.Amount, penalty.Currency)
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			return closeReservations(ctx,
+				func(now time.Time) ([]*store.Reservation, error) {
+					return ms.CompletableReservations(ctx, now, defaultBatchSize)
+				},
+				func(res *store.Reservation) error {
+					return ms.CompleteReservation(ctx, res)
+				},
+			)
+		},
+	}
+}
+
+// closeReservations closes the reservations found in batches until a batch is not full or closes none, reservations
+// changed since they were read are left to the next run
+func closeReservations(ctx context.Context, find func(now time.Time) ([]*store.Reservation, error), closeOne func(res *store.Reservation) error) error {
+	for {
+		rs, err := find(time.Now())
+		if err != nil {
+			return err
+		}
+
+		closed := 0
+		for _, res := range rs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			err = closeOne(res)
+			if errors.Is(err, store.ErrNotActive) || errors.Is(err, store.ErrCheckedIn) ||
+				errors.Is(err, store.ErrVersionMismatch) {
+				continue
+			}
+
+			if err != nil {
+				return err
+			}
+
+			closed++
+		}
+
+		if len(rs) < defaultBatchSize || closed == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package scheduler runs background jobs on cron schedules, a job is run by one instance of the service at a time
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"hotel-california-backend/internal/store"
+	"os"
+	"time"
+)
+
+// defaults used for zero options
+const (
+	defaultInterval   = 30 * time.Second
+	defaultLockTTL    = 5 * time.Minute
+	defaultRetryDelay = time.Minute
+)
+
+// ErrDuplicateJob is returned when a job is added with the name of another job
+var ErrDuplicateJob = errors.New("a job with the same name is already added")
+
+// Job represents a background job, Schedule is a cron expression of five fields or a descriptor like @hourly.
+// Runs are at least once, a run cut short by a stopped instance is repeated by another one, so Run has to be
+// idempotent.
+type Job struct {
+	Name     string
+	Schedule string
+	Run      func(ctx context.Context) error
+}
+
+// Options represents scheduler options. Due jobs are looked for every Interval, a job is locked for LockTTL which is
+// also the deadline of its run, and a failed run is retried after RetryDelay unless the job is due earlier. Owner
+// identifies the instance in job locks, it defaults to the host name with a random suffix.
+type Options struct {
+	Owner      string
+	Interval   time.Duration
+	LockTTL    time.Duration
+	RetryDelay time.Duration
+}
+
+type scheduledJob struct {
+	Job
+	schedule cron.Schedule
+	ensured  bool
+}
+
+// Scheduler runs the jobs added to it when they are due
+type Scheduler struct {
+	l    log.Logger
+	ms   store.Store
+	opts Options
+	jobs []*scheduledJob
+	now  func() time.Time
+}
+
+// NewScheduler creates and returns scheduler
+func NewScheduler(l log.Logger, ms store.Store, opts Options) *Scheduler {
+	if opts.Owner == "" {
+		host, _ := os.Hostname()
+		opts.Owner = fmt.Sprintf("%s-%s", host, uuid.NewString()[:8])
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = defaultLockTTL
+	}
+
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultRetryDelay
+	}
+
+	return &Scheduler{
+		l:    l,
+		ms:   ms,
+		opts: opts,
+		now:  time.Now,
+	}
+}
+
+// Add adds the job, it returns an error when the schedule can not be parsed
+func (s *Scheduler) Add(job Job) error {
+	for _, j := range s.jobs {
+		if j.Name == job.Name {
+			return fmt.Errorf("%w, %s", ErrDuplicateJob, job.Name)
+		}
+	}
+
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("parsing schedule of job %s failed, %w", job.Name, err)
+	}
+
+	s.jobs = append(s.jobs, &scheduledJob{Job: job, schedule: schedule})
+
+	return nil
+}
+
+// Run runs due jobs every interval until ctx is done, a job running then is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.opts.Interval)
+	defer t.Stop()
+
+	for {
+		_, _ = s.RunDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunDue runs the jobs which are due and not locked by another instance, and returns how many were run
+func (s *Scheduler) RunDue(ctx context.Context) (int, error) {
+	var errs []error
+	n := 0
+
+	for _, j := range s.jobs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		ran, err := s.runJob(ctx, j)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if ran {
+			n++
+		}
+	}
+
+	return n, errors.Join(errs...)
+}
+
+func (s *Scheduler) runJob(ctx context.Context, j *scheduledJob) (bool, error) {
+	now := s.now()
+
+	// every instance persists its jobs, the schedule of the last one started wins
+	if !j.ensured {
+		err := s.ms.EnsureJob(ctx, &store.Job{
+			Name:      j.Name,
+			Schedule:  j.Job.Schedule,
+			NextRunAt: j.schedule.Next(now),
+			CreatedAt: now,
+		})
+		if err != nil {
+			s.log(err, "EnsureJob", j.Name)
+			return false, err
+		}
+
+		j.ensured = true
+	}
+
+	lockedUntil := now.Add(s.opts.LockTTL)
+
+	stored, err := s.ms.AcquireJob(ctx, j.Name, s.opts.Owner, now, lockedUntil)
+	if err != nil {
+		s.log(err, "AcquireJob", j.Name)
+		return false, err
+	}
+
+	if stored == nil {
+		return false, nil
+	}
+
+	// the run ends before the lock expires so that no other instance runs the job at the same time
+	runCtx, cancel := context.WithDeadline(ctx, lockedUntil)
+	runErr := s.run(runCtx, j)
+	cancel()
+
+	end := s.now()
+
+	stored.LastRunAt = &end
+	stored.LastError = ""
+
+	switch {
+	case ctx.Err() != nil:
+		// a run cancelled by shutdown stays due, so that it is repeated by the next instance to look
+		stored.LastError = ctx.Err().Error()
+	case runErr != nil:
+		s.log(runErr, "Run", j.Name)
+
+		stored.LastError = runErr.Error()
+		stored.NextRunAt = j.schedule.Next(end)
+
+		if retryAt := end.Add(s.opts.RetryDelay); retryAt.Before(stored.NextRunAt) {
+			stored.NextRunAt = retryAt
+		}
+	default:
+		stored.NextRunAt = j.schedule.Next(end)
+	}
+
+	if err = s.ms.CompleteJob(context.WithoutCancel(ctx), stored); err != nil {
+		s.log(err, "CompleteJob", j.Name)
+		return true, err
+	}
+
+	return true, nil
+}
+
+// run runs the job, a panic fails the run instead of stopping the scheduler
+func (s *Scheduler) run(ctx context.Context, j *scheduledJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job %s panicked, %v", j.Name, r)
+		}
+	}()
+
+	return j.Run(ctx)
+}
+
+func (s *Scheduler) log(err error, action, job string) {
+	_ = level.Error(s.l).Log(
+		"action", action,
+		"job", job,
+		"error", err.Error(),
+	)
+}
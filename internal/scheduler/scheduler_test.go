@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	storemock "hotel-california-backend/internal/mock/store"
+	"hotel-california-backend/internal/store"
+	"testing"
+	"time"
+)
+
+func TestScheduler_RunDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 24, 10, 30, 0, 0, time.UTC)
+	owner := "instance-a"
+
+	ms := storemock.NewStore()
+	ms.On("EnsureJob", ctx, mock.Anything).Return(nil)
+	ms.On("AcquireJob", ctx, "hourly", owner, now, now.Add(time.Minute)).Return(&store.Job{Name: "hourly", LockedBy: &owner}, nil)
+	ms.On("AcquireJob", ctx, "failing", owner, now, now.Add(time.Minute)).Return(&store.Job{Name: "failing", LockedBy: &owner}, nil)
+	ms.On("AcquireJob", ctx, "locked", owner, now, now.Add(time.Minute)).Return((*store.Job)(nil), nil)
+	ms.On("CompleteJob", mock.Anything, mock.Anything).Return(nil)
+
+	s := NewScheduler(log.NewNopLogger(), ms, Options{Owner: owner, LockTTL: time.Minute, RetryDelay: 5 * time.Minute})
+	s.now = func() time.Time { return now }
+
+	runs := map[string]int{}
+	add := func(name string, err error) {
+		require.NoError(t, s.Add(Job{Name: name, Schedule: "@hourly", Run: func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+
+			runs[name]++
+
+			return err
+		}}))
+	}
+
+	add("hourly", nil)
+	add("failing", errors.New("database is down"))
+	add("locked", nil)
+
+	assert.ErrorIs(t, s.Add(Job{Name: "hourly", Schedule: "@daily"}), ErrDuplicateJob)
+	assert.Error(t, s.Add(Job{Name: "invalid", Schedule: "every hour"}))
+
+	n, err := s.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, map[string]int{"hourly": 1, "failing": 1}, runs)
+
+	completed := map[string]*store.Job{}
+	for _, call := range ms.Calls {
+		if call.Method == "CompleteJob" {
+			job := call.Arguments.Get(1).(*store.Job)
+			completed[job.Name] = job
+		}
+	}
+
+	require.Len(t, completed, 2)
+	assert.Equal(t, time.Date(2024, 3, 24, 11, 0, 0, 0, time.UTC), completed["hourly"].NextRunAt)
+	assert.Empty(t, completed["hourly"].LastError)
+
+	// a failed run is retried before the next hour
+	assert.Equal(t, now.Add(5*time.Minute), completed["failing"].NextRunAt)
+	assert.Equal(t, "database is down", completed["failing"].LastError)
+
+	ms.AssertNumberOfCalls(t, "EnsureJob", 3)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	hotelcalifornia "hotel-california-backend"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/calendar"
+	"hotel-california-backend/internal/localization"
+	"hotel-california-backend/internal/store"
+	"net/url"
+	"time"
+)
+
+// calendar settings
+const (
+	calendarTokenLen  = 32
+	calendarProdID    = "-//Hotel California//Reservations//EN"
+	calendarUIDDomain = "hotel-california"
+	calendarFileName  = "reservations.ics"
+
+	// calendars list at most maxCalendarEvents stays, the latest ones, checked out within the calendar history or
+	// defaultCalendarHistory when it is not set
+	maxCalendarEvents      = 500
+	defaultCalendarHistory = 365 * 24 * time.Hour
+)
+
+// ExportReservations represents service's export reservations method, it renders the user's stays checked out within
+// the calendar history. A request with a calendar token is served the calendar of the token's user in the language
+// the subscription was created in.
+func (s *Service) ExportReservations(ctx context.Context, req hotelcalifornia.ExportReservationsRequest) hotelcalifornia.ExportReservationsResponse {
+	res := hotelcalifornia.ExportReservationsResponse{}
+
+	userID, lang := req.UserId, localization.PreferredLanguage(req.AcceptLanguage)
+
+	if req.CalendarToken != "" {
+		token, err := s.ms.FindCalendarToken(ctx, hashCalendarToken(req.CalendarToken))
+		if err != nil {
+			res.Result = s.storeError(ctx, "ExportReservations", "Mysql FindCalendarToken", err, apierror.DefaultInternalServerError)
+			if errors.Is(err, store.ErrNotFound) {
+				res.Result = apierror.DefaultUnauthorizedError
+			}
+
+			return res
+		}
+
+		userID, lang = token.UserID, token.Language
+	}
+
+	history := s.rs.CalendarHistory
+	if history <= 0 {
+		history = defaultCalendarHistory
+	}
+
+	q := store.ReservationQuery{
+		UserID:     userID,
+		From:       time.Now().Add(-history),
+		SortBy:     store.SortByCheckInDate,
+		Descending: true,
+		Limit:      maxCalendarEvents,
+	}
+
+	reservations, _, err := s.ms.FindReservations(ctx, q)
+	if err != nil {
+		res.Result = s.storeError(ctx, "ExportReservations", "Mysql FindReservations", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	content, err := renderCalendar(lang, reservations, time.Now())
+	if err != nil {
+		res.Result = s.storeError(ctx, "ExportReservations", "RenderCalendar", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Calendar = &hotelcalifornia.File{
+		Name:        calendarFileName,
+		ContentType: calendar.ContentType,
+		Content:     content,
+	}
+
+	return res
+}
+
+// ExportReservation represents service's export reservation method, it renders the reservation as a calendar of one
+// event
+func (s *Service) ExportReservation(ctx context.Context, req hotelcalifornia.ExportReservationRequest) hotelcalifornia.ExportReservationResponse {
+	res := hotelcalifornia.ExportReservationResponse{}
+
+	if apiErr := checkPNR(req.PNR); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	reservation, err := s.ms.FindReservation(ctx, req.PNR, req.UserId)
+	if err != nil {
+		res.Result = s.storeError(ctx, "ExportReservation", "Mysql FindReservation", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	content, err := renderCalendar(localization.PreferredLanguage(req.AcceptLanguage), []*store.Reservation{reservation}, time.Now())
+	if err != nil {
+		res.Result = s.storeError(ctx, "ExportReservation", "RenderCalendar", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Calendar = &hotelcalifornia.File{
+		Name:        reservation.PNR + ".ics",
+		ContentType: calendar.ContentType,
+		Content:     content,
+	}
+
+	return res
+}
+
+// CreateCalendarSubscription represents service's create calendar subscription method, the url with the token is
+// returned only once and the url created before stops working
+func (s *Service) CreateCalendarSubscription(ctx context.Context, req hotelcalifornia.CreateCalendarSubscriptionRequest) hotelcalifornia.CreateCalendarSubscriptionResponse {
+	res := hotelcalifornia.CreateCalendarSubscriptionResponse{}
+
+	u, err := url.Parse(s.rs.CalendarURL)
+	if err != nil {
+		res.Result = s.storeError(ctx, "CreateCalendarSubscription", "ParseCalendarURL", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	secret, err := generateSecret(calendarTokenLen)
+	if err != nil {
+		res.Result = s.storeError(ctx, "CreateCalendarSubscription", "GenerateSecret", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	token := store.CalendarToken{
+		UserID:    req.UserId,
+		TokenHash: hashCalendarToken(secret),
+		Language:  localization.PreferredLanguage(req.AcceptLanguage),
+		CreatedAt: time.Now(),
+	}
+
+	if err = s.ms.CreateCalendarToken(ctx, &token); err != nil {
+		res.Result = s.storeError(ctx, "CreateCalendarSubscription", "Mysql CreateCalendarToken", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	query := u.Query()
+	query.Set("token", secret)
+	u.RawQuery = query.Encode()
+
+	res.Data = &hotelcalifornia.CalendarSubscriptionData{
+		URL:       u.String(),
+		CreatedAt: token.CreatedAt.Format(time.RFC3339),
+	}
+
+	return res
+}
+
+// RevokeCalendarSubscription represents service's revoke calendar subscription method, revoking a revoked
+// subscription succeeds
+func (s *Service) RevokeCalendarSubscription(ctx context.Context, req hotelcalifornia.RevokeCalendarSubscriptionRequest) hotelcalifornia.RevokeCalendarSubscriptionResponse {
+	res := hotelcalifornia.RevokeCalendarSubscriptionResponse{}
+
+	if _, err := s.ms.RevokeCalendarTokens(ctx, req.UserId, time.Now()); err != nil {
+		res.Result = s.storeError(ctx, "RevokeCalendarSubscription", "Mysql RevokeCalendarTokens", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Data = &hotelcalifornia.RevokeCalendarSubscriptionData{
+		IsSuccessfully: true,
+	}
+
+	return res
+}
+
+// renderCalendar renders the reservations as all-day events from check-in until check-out, cancelled stays and
+// no-shows are rendered cancelled so that subscribed calendars remove them
+func renderCalendar(lang string, reservations []*store.Reservation, stamp time.Time) ([]byte, error) {
+	name, err := localization.LocalizeMessage(lang, localization.CalendarName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := calendar.Calendar{
+		ProdID: calendarProdID,
+		Name:   name,
+		Events: make([]calendar.Event, 0, len(reservations)),
+	}
+
+	for _, r := range reservations {
+		templateData := map[string]interface{}{
+			"PNR":           r.PNR,
+			"Destination":   r.Destination,
+			"CheckInDate":   r.CheckInDate.Format(dateLayout),
+			"CheckOutDate":  r.CheckOutDate.Format(dateLayout),
+			"Accommodation": r.Accommodation,
+			"GuestCount":    r.GuestCount,
+		}
+
+		summary, err := localization.LocalizeMessage(lang, localization.CalendarEventSummary, templateData)
+		if err != nil {
+			return nil, err
+		}
+
+		description, err := localization.LocalizeMessage(lang, localization.CalendarEventDescription, templateData)
+		if err != nil {
+			return nil, err
+		}
+
+		status := calendar.StatusConfirmed
+		if r.Status() == store.StatusCancelled || r.Status() == store.StatusNoShow {
+			status = calendar.StatusCancelled
+		}
+
+		c.Events = append(c.Events, calendar.Event{
+			UID:         r.PNR + "@" + calendarUIDDomain,
+			Summary:     summary,
+			Description: description,
+			Location:    r.Destination,
+			Start:       r.CheckInDate,
+			End:         r.CheckOutDate,
+			Status:      status,
+			Sequence:    int(max(r.Version-1, 0)),
+			Stamp:       stamp,
+		})
+	}
+
+	return c.Render(), nil
+}
+
+// hashCalendarToken returns the hash calendar tokens are stored with
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	hotelcalifornia "hotel-california-backend"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/document"
+	"hotel-california-backend/internal/localization"
+	"hotel-california-backend/internal/store"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const documentIssuer = "Hotel California"
+
+// ErrNoNightlyRate is returned when the accommodation of a reservation has no nightly rate configured
+var ErrNoNightlyRate = errors.New("no nightly rate")
+
+// ExportReservationConfirmation represents service's export reservation confirmation method, it renders the
+// confirmation of the reservation with its last invoice. Invoices are issued when reservations are created or their
+// price is changed, reservations without an invoice, e.g. the ones made before, are invoiced on their first export.
+func (s *Service) ExportReservationConfirmation(ctx context.Context, req hotelcalifornia.ExportReservationConfirmationRequest) hotelcalifornia.ExportReservationConfirmationResponse {
+	res := hotelcalifornia.ExportReservationConfirmationResponse{}
+
+	if apiErr := checkPNR(req.PNR); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	reservation, err := s.ms.FindReservation(ctx, req.PNR, req.UserId)
+	if err != nil {
+		res.Result = s.storeError(ctx, "ExportReservationConfirmation", "Mysql FindReservation", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	if reservation.Status() == store.StatusCancelled {
+		res.Result = apierror.ReservationAlreadyCancelled
+		return res
+	}
+
+	inv, err := s.ms.FindInvoice(ctx, reservation.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		inv, err = s.issueMissingInvoice(ctx, reservation)
+	}
+
+	if err != nil {
+		res.Result = s.storeError(ctx, "ExportReservationConfirmation", "Mysql FindInvoice", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	content, err := s.renderConfirmation(localization.PreferredLanguage(req.AcceptLanguage), reservation, inv)
+	if err != nil {
+		res.Result = s.storeError(ctx, "ExportReservationConfirmation", "RenderConfirmation", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Document = &hotelcalifornia.File{
+		Name:        reservation.PNR + "-confirmation.pdf",
+		ContentType: document.ContentType,
+		Content:     content,
+	}
+
+	return res
+}
+
+// issueMissingInvoice issues the invoice of the current version of a reservation which has none, the invoice issued by a
+// concurrent export is returned instead. Accommodations without a nightly rate are not invoiced, so their confirmation
+// is not found.
+func (s *Service) issueMissingInvoice(ctx context.Context, reservation *store.Reservation) (*store.Invoice, error) {
+	inv, err := s.priceReservation(reservation, time.Now())
+	if errors.Is(err, ErrNoNightlyRate) {
+		return nil, &store.Error{Kind: store.ErrNotFound, Op: "IssueInvoice", Err: err}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	inv.ReservationID = reservation.ID
+	inv.ReservationVersion = reservation.Version
+	inv.PNR = reservation.PNR
+
+	err = s.ms.IssueInvoice(ctx, inv)
+	if errors.Is(err, store.ErrConflict) {
+		return s.ms.FindInvoice(ctx, reservation.ID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// invoiceReservation returns the invoice the store issues with the reservation, reservations of accommodations without
+// a nightly rate are not invoiced
+func (s *Service) invoiceReservation(ctx context.Context, method string, reservation *store.Reservation) (*store.Invoice, *apierror.APIError) {
+	inv, err := s.priceReservation(reservation, time.Now())
+	if errors.Is(err, ErrNoNightlyRate) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, s.storeError(ctx, method, "PriceReservation", err, apierror.DefaultInternalServerError)
+	}
+
+	return inv, nil
+}
+
+// priceReservation returns the invoice of the reservation's nights at the nightly rate of its accommodation, taxes are
+// charged on the net amount. The store sets the reservation and its version when it issues the invoice.
+func (s *Service) priceReservation(reservation *store.Reservation, issuedAt time.Time) (*store.Invoice, error) {
+	rate, err := nightlyRate(s.rs.NightlyRates, reservation.Accommodation)
+	if err != nil {
+		return nil, err
+	}
+
+	nights := int(math.Round(reservation.CheckOutDate.Sub(reservation.CheckInDate).Hours() / 24))
+
+	inv := &store.Invoice{
+		PNR:                  reservation.PNR,
+		Nights:               nights,
+		NightlyRate:          rate,
+		NetAmount:            int64(nights) * rate,
+		VATRate:              basisPoints(s.rs.VATRate),
+		AccommodationTaxRate: basisPoints(s.rs.AccommodationTaxRate),
+		Currency:             s.rs.Currency,
+		IssuedAt:             issuedAt,
+	}
+
+	inv.VATAmount = percentage(inv.NetAmount, inv.VATRate)
+	inv.AccommodationTaxAmount = percentage(inv.NetAmount, inv.AccommodationTaxRate)
+	inv.TotalAmount = inv.NetAmount + inv.VATAmount + inv.AccommodationTaxAmount
+
+	return inv, nil
+}
+
+// renderConfirmation renders the confirmation of the reservation with the invoice in the language, amounts are
+// formatted with the separators of the language
+func (s *Service) renderConfirmation(lang string, reservation *store.Reservation, inv *store.Invoice) ([]byte, error) {
+	p := message.NewPrinter(language.Make(lang))
+
+	money := func(amount int64) string {
+		return p.Sprintf("%.2f %s", float64(amount)/100, inv.Currency)
+	}
+
+	var err error
+
+	// localize returns the message of the key, the first error is kept and returned after rendering
+	localize := func(key string, templateData map[string]interface{}) string {
+		if err != nil {
+			return ""
+		}
+
+		var msg string
+		msg, err = localization.LocalizeMessage(lang, key, templateData)
+
+		return msg
+	}
+
+	d := document.Document{
+		Issuer: documentIssuer,
+		Title:  localize(localization.DocumentTitle, nil),
+		Details: []document.Field{
+			{Label: localize(localization.DocumentInvoiceNumber, nil), Value: fmt.Sprintf("%s%06d", s.rs.InvoicePrefix, inv.Number)},
+			{Label: localize(localization.DocumentInvoiceDate, nil), Value: inv.IssuedAt.Format(dateLayout)},
+			{Label: localize(localization.DocumentPNR, nil), Value: reservation.PNR},
+			{Label: localize(localization.DocumentGuest, nil), Value: fmt.Sprintf("%s %s", reservation.User.FirstName, reservation.User.LastName)},
+			{Label: localize(localization.DocumentDestination, nil), Value: reservation.Destination},
+			{Label: localize(localization.DocumentCheckIn, nil), Value: reservation.CheckInDate.Format(dateLayout)},
+			{Label: localize(localization.DocumentCheckOut, nil), Value: reservation.CheckOutDate.Format(dateLayout)},
+			{Label: localize(localization.DocumentAccommodation, nil), Value: reservation.Accommodation},
+			{Label: localize(localization.DocumentGuestCount, nil), Value: strconv.Itoa(reservation.GuestCount)},
+		},
+		Columns: document.Line{
+			Description: localize(localization.DocumentDescription, nil),
+			Amount:      localize(localization.DocumentAmount, nil),
+		},
+		Lines: []document.Line{{
+			Description: localize(localization.DocumentNights, map[string]interface{}{
+				"Accommodation": reservation.Accommodation,
+				"Nights":        inv.Nights,
+				"NightlyRate":   money(inv.NightlyRate),
+			}),
+			Amount: money(inv.NetAmount),
+		}},
+		Totals: []document.Line{
+			{Description: localize(localization.DocumentSubtotal, nil), Amount: money(inv.NetAmount)},
+			{
+				Description: localize(localization.DocumentVAT, map[string]interface{}{"Rate": p.Sprint(float64(inv.VATRate) / 100)}),
+				Amount:      money(inv.VATAmount),
+			},
+			{
+				Description: localize(localization.DocumentAccommodationTax, map[string]interface{}{"Rate": p.Sprint(float64(inv.AccommodationTaxRate) / 100)}),
+				Amount:      money(inv.AccommodationTaxAmount),
+			},
+			{Description: localize(localization.DocumentTotal, nil), Amount: money(inv.TotalAmount)},
+		},
+		Footer: localize(localization.DocumentFooter, nil),
+		Date:   inv.IssuedAt,
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return d.PDF()
+}
+
+// nightlyRate returns the rate of the accommodation from rates of accommodation:amount pairs
+func nightlyRate(rates []string, accommodation string) (int64, error) {
+	for _, r := range rates {
+		name, amount, ok := strings.Cut(r, ":")
+		if !ok || strings.TrimSpace(name) != accommodation {
+			continue
+		}
+
+		rate, err := strconv.ParseInt(strings.TrimSpace(amount), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing nightly rate of %s failed, %w", accommodation, err)
+		}
+
+		return rate, nil
+	}
+
+	return 0, fmt.Errorf("%w, %s", ErrNoNightlyRate, accommodation)
+}
+
+// basisPoints returns the percent in basis points, e.g. 10% is 1000
+func basisPoints(percent float64) int64 {
+	return int64(math.Round(percent * 100))
+}
+
+// percentage returns the rate in basis points of the amount rounded half away from zero
+func percentage(amount, rate int64) int64 {
+	return int64(math.Round(float64(amount) * float64(rate) / 10000))
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	hotelcalifornia "hotel-california-backend"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/store"
+	"strings"
+	"time"
+)
+
+// guests are children under adultAge, document numbers are shown with their last visibleDocumentChars characters
+const (
+	adultAge             = 18
+	visibleDocumentChars = 4
+)
+
+// errors
+var (
+	ErrGuestCountMismatch = errors.New("the number of guests does not match the guest count")
+	ErrNoAdultGuest       = errors.New("a reservation needs an adult guest")
+	ErrInvalidGuestAge    = errors.New("the age does not match the guest type")
+	ErrNoGuestDataKey     = errors.New("identity documents are not accepted since no guest data key is configured")
+)
+
+// validateGuests validates the guests given with the guest count, no guests are valid. Documents are rejected when no
+// guest data key is configured to encrypt them.
+func (s *Service) validateGuests(guests []hotelcalifornia.ReservationGuest, guestCount int) *apierror.APIError {
+	if len(guests) == 0 {
+		return nil
+	}
+
+	if len(guests) != guestCount {
+		return invalidFieldError("guests", ErrGuestCountMismatch)
+	}
+
+	hasAdult := false
+
+	for i, g := range guests {
+		if s.gc == nil && (g.DocumentNumber != "" || g.DocumentType != "") {
+			return invalidFieldError(fmt.Sprintf("guests[%d].documentNumber", i), ErrNoGuestDataKey)
+		}
+
+		isChild := g.Type == store.GuestTypeChild
+
+		if g.Age != nil && isChild != (*g.Age < adultAge) {
+			return invalidFieldError(fmt.Sprintf("guests[%d].age", i), ErrInvalidGuestAge)
+		}
+
+		hasAdult = hasAdult || !isChild
+	}
+
+	if !hasAdult {
+		return invalidFieldError("guests", ErrNoAdultGuest)
+	}
+
+	return nil
+}
+
+// encryptGuests returns the guests to store with their documents encrypted, nil guests are nil so that the stored
+// guests of an updated reservation are kept
+func (s *Service) encryptGuests(guests []hotelcalifornia.ReservationGuest) ([]store.ReservationGuest, error) {
+	if guests == nil {
+		return nil, nil
+	}
+
+	if s.gcErr != nil {
+		return nil, s.gcErr
+	}
+
+	var err error
+
+	rgs := make([]store.ReservationGuest, 0, len(guests))
+
+	for _, g := range guests {
+		rg := store.ReservationGuest{
+			FullName:    strings.TrimSpace(g.FullName),
+			Type:        g.Type,
+			Age:         g.Age,
+			Nationality: g.Nationality,
+			CreatedAt:   time.Now(),
+		}
+
+		if g.DocumentNumber != "" {
+			if s.gc == nil {
+				return nil, ErrNoGuestDataKey
+			}
+
+			if rg.DocumentType, err = s.gc.Encrypt(g.DocumentType); err != nil {
+				return nil, err
+			}
+
+			if rg.DocumentNumber, err = s.gc.Encrypt(g.DocumentNumber); err != nil {
+				return nil, err
+			}
+		}
+
+		rgs = append(rgs, rg)
+	}
+
+	return rgs, nil
+}
+
+// guestData returns the guests to show with their document numbers masked
+func (s *Service) guestData(guests []store.ReservationGuest) ([]hotelcalifornia.ReservationGuestData, error) {
+	if len(guests) == 0 {
+		return nil, nil
+	}
+
+	if s.gcErr != nil {
+		return nil, s.gcErr
+	}
+
+	var err error
+
+	gd := make([]hotelcalifornia.ReservationGuestData, 0, len(guests))
+
+	for _, g := range guests {
+		d := hotelcalifornia.ReservationGuestData{
+			FullName:    g.FullName,
+			Type:        g.Type,
+			Age:         g.Age,
+			Nationality: g.Nationality,
+		}
+
+		if g.DocumentNumber != "" {
+			if s.gc == nil {
+				return nil, ErrNoGuestDataKey
+			}
+
+			if d.DocumentType, err = s.gc.Decrypt(g.DocumentType); err != nil {
+				return nil, err
+			}
+
+			number, err := s.gc.Decrypt(g.DocumentNumber)
+			if err != nil {
+				return nil, err
+			}
+
+			d.DocumentNumber = maskDocumentNumber(number)
+		}
+
+		gd = append(gd, d)
+	}
+
+	return gd, nil
+}
+
+// maskDocumentNumber returns the number with all but its last characters masked, short numbers are masked entirely
+func maskDocumentNumber(number string) string {
+	r := []rune(number)
+
+	visible := 0
+	if len(r) > 2*visibleDocumentChars {
+		visible = visibleDocumentChars
+	}
+
+	return strings.Repeat("*", len(r)-visible) + string(r[len(r)-visible:])
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	hotelcalifornia "hotel-california-backend"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/store"
+	"strings"
+	"unicode"
+)
+
+// LookupReservation represents service's lookup reservation method, it finds the reservation of the pnr for a caller
+// who is not signed in, e.g. a companion of the guest or the front desk. Lookups are limited per ip address and a last
+// name which does not match is not found, so that pnrs can not be guessed.
+func (s *Service) LookupReservation(ctx context.Context, req hotelcalifornia.LookupReservationRequest) hotelcalifornia.LookupReservationResponse {
+	res := hotelcalifornia.LookupReservationResponse{}
+
+	if !s.ll.Allow(req.IPAddress) {
+		res.Result = apierror.DefaultTooManyRequestsError
+		return res
+	}
+
+	if apiErr := checkPNR(req.PNR); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	reservation, err := s.ms.FindReservationByPNR(ctx, req.PNR)
+	if err != nil {
+		res.Result = apierror.DefaultNotFoundError
+		if !errors.Is(err, store.ErrNotFound) {
+			res.Result = s.storeError(ctx, "LookupReservation", "Mysql FindReservationByPNR", err, apierror.DefaultInternalServerError)
+		}
+
+		return res
+	}
+
+	if foldName(reservation.User.LastName) != foldName(req.LastName) {
+		res.Result = apierror.DefaultNotFoundError
+		return res
+	}
+
+	res.Data = &hotelcalifornia.LookupReservationData{
+		PNR:           reservation.PNR,
+		Destination:   reservation.Destination,
+		CheckInDate:   reservation.CheckInDate.Format(dateLayout),
+		CheckOutDate:  reservation.CheckOutDate.Format(dateLayout),
+		Accommodation: reservation.Accommodation,
+		GuestCount:    reservation.GuestCount,
+		Status:        reservation.Status(),
+	}
+
+	return res
+}
+
+// foldName returns the name without case, diacritics and repeated spaces, e.g. "Güneş" and "GUNES" are the same. The
+// dotless ı of Turkish is not a letter with a diacritic, so it is folded to i as well.
+func foldName(name string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	folded, _, err := transform.String(t, name)
+	if err != nil {
+		folded = name
+	}
+
+	folded = strings.ReplaceAll(cases.Fold().String(folded), "ı", "i")
+
+	return strings.Join(strings.Fields(folded), " ")
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/store"
+	"strings"
+)
+
+// legacyPNRAlphabet is the alphabet of the pnrs generated before pnrs had a check character, it holds the pnr alphabet
+const legacyPNRAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// checkPNR rejects a pnr which can not belong to a reservation before the store is queried. Pnrs generated before
+// check characters can not be told apart from mistyped ones, so every pnr of the length made of the legacy alphabet
+// is looked up and the store decides whether it exists.
+func checkPNR(pnr string) *apierror.APIError {
+	if len(pnr) != store.PNRLength {
+		return invalidFieldError("pnr", ErrInvalidPNR)
+	}
+
+	for i := 0; i < len(pnr); i++ {
+		if strings.IndexByte(legacyPNRAlphabet, pnr[i]) < 0 {
+			return invalidFieldError("pnr", ErrInvalidPNR)
+		}
+	}
+
+	return nil
+}
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/go-kit/kit/log"
@@ -12,9 +15,14 @@ import (
 	hotelcalifornia "hotel-california-backend"
 	"hotel-california-backend/configs/envvars"
 	apierror "hotel-california-backend/internal/api-error"
-	mysqlstore "hotel-california-backend/internal/store/mysql"
-	"math/rand"
+	"hotel-california-backend/internal/encryption"
+	"hotel-california-backend/internal/localization"
+	"hotel-california-backend/internal/ratelimit"
+	"hotel-california-backend/internal/requestid"
+	"hotel-california-backend/internal/store"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,14 +33,25 @@ var (
 		"mountain",
 	}
 	dateLayout = "2006-01-02"
-	pnrLen     = 8
-	charset    = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	defaultPageSize = 20
+	sortColumns     = map[string]store.ReservationSort{
+		"checkInDate": store.SortByCheckInDate,
+		"createdAt":   store.SortByCreatedAt,
+	}
+)
+
+// reservation statuses
+const (
+	statusActive = "active"
 )
 
 // errors
 var (
 	ErrNotValidDestination = errors.New("destination not valid")
 	ErrFailedParseDate     = errors.New("failed to parse date")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+	ErrInvalidPNR          = errors.New("invalid pnr")
 )
 
 // compile-time proof of service interface implementation
@@ -42,17 +61,38 @@ var _ hotelcalifornia.Service = (*Service)(nil)
 type Service struct {
 	environment string
 	l           log.Logger
-	ms          mysqlstore.Store
+	ms          store.Store
 	jw          envvars.JWTToken
+	rs          envvars.Reservation
+	ll          *ratelimit.Limiter
+	gc          *encryption.Cipher
+	gcErr       error
+
+	shuttingDown atomic.Bool
 }
 
 // NewService creates and returns service
-func NewService(environment string, l log.Logger, ms mysqlstore.Store, jw envvars.JWTToken) hotelcalifornia.Service {
+func NewService(environment string, l log.Logger, ms store.Store, jw envvars.JWTToken, rs envvars.Reservation) *Service {
+	// the guest data keys are validated when the environment variables are loaded, guests are not encrypted or
+	// decrypted with invalid keys. Without a key guest documents are not accepted.
+	var (
+		gc    *encryption.Cipher
+		gcErr error
+	)
+
+	if rs.GuestDataKey != "" {
+		gc, gcErr = encryption.NewCipher(rs.GuestDataKeyVersion, rs.GuestDataKey, rs.GuestDataOldKeys)
+	}
+
 	return &Service{
 		environment: environment,
 		l:           l,
 		ms:          ms,
 		jw:          jw,
+		rs:          rs,
+		ll:          ratelimit.New(rs.LookupInterval, rs.LookupBurst),
+		gc:          gc,
+		gcErr:       gcErr,
 	}
 }
 
@@ -65,6 +105,68 @@ func (s *Service) Health(_ context.Context, _ hotelcalifornia.HealthRequest) hot
 	return res
 }
 
+// Liveness represents service's liveness method, the process is alive as long as it responds
+func (s *Service) Liveness(_ context.Context, _ hotelcalifornia.LivenessRequest) hotelcalifornia.LivenessResponse {
+	return hotelcalifornia.LivenessResponse{
+		Status: hotelcalifornia.HealthStatusUp,
+	}
+}
+
+// Readiness represents service's readiness method, it checks the dependencies requests are served with
+func (s *Service) Readiness(ctx context.Context, _ hotelcalifornia.ReadinessRequest) hotelcalifornia.ReadinessResponse {
+	if s.shuttingDown.Load() {
+		return hotelcalifornia.ReadinessResponse{
+			Status: hotelcalifornia.HealthStatusDown,
+			Checks: []*hotelcalifornia.HealthCheck{
+				{Name: "shutdown", Status: hotelcalifornia.HealthStatusDown},
+			},
+		}
+	}
+
+	checks := []struct {
+		name  string
+		check func(context.Context) error
+	}{
+		{name: "database", check: s.ms.Ping},
+		{name: "localization", check: func(context.Context) error { return localization.CheckBundle() }},
+	}
+
+	res := hotelcalifornia.ReadinessResponse{
+		Status: hotelcalifornia.HealthStatusUp,
+		Checks: make([]*hotelcalifornia.HealthCheck, 0, len(checks)),
+	}
+
+	for _, c := range checks {
+		begin := time.Now()
+		err := c.check(ctx)
+
+		hc := &hotelcalifornia.HealthCheck{
+			Name:      c.name,
+			Status:    hotelcalifornia.HealthStatusUp,
+			LatencyMs: float64(time.Since(begin).Microseconds()) / 1000,
+		}
+
+		if err != nil {
+			s.log(ctx, err, map[string]interface{}{
+				"method": "Readiness",
+				"action": c.name,
+			})
+
+			hc.Status = hotelcalifornia.HealthStatusDown
+			res.Status = hotelcalifornia.HealthStatusDown
+		}
+
+		res.Checks = append(res.Checks, hc)
+	}
+
+	return res
+}
+
+// BeginShutdown marks the service not ready, so that traffic is drained before the servers shut down
+func (s *Service) BeginShutdown() {
+	s.shuttingDown.Store(true)
+}
+
 // SignIn represents service's sign in method
 func (s *Service) SignIn(ctx context.Context, req hotelcalifornia.SignInRequest) hotelcalifornia.SignInResponse {
 	res := hotelcalifornia.SignInResponse{}
@@ -75,22 +177,19 @@ func (s *Service) SignIn(ctx context.Context, req hotelcalifornia.SignInRequest)
 	password := hex.EncodeToString(hash[:])
 
 	usr, err := s.ms.SignIn(ctx, userName, password)
-	if err != nil {
-		s.log(err, map[string]interface{}{
-			"method": "SignIn",
-			"action": "Mysql SignIn",
-			"error":  err.Error(),
-		})
-
-		res.Result = apierror.NewBadRequestError(err)
-		res.Result.BaseError = err
+	if errors.Is(err, store.ErrNotFound) {
+		res.Result = apierror.InvalidCredentials.WithBaseError(err)
+		return res
+	}
 
+	if err != nil {
+		res.Result = s.storeError(ctx, "SignIn", "Mysql SignIn", err, apierror.DefaultInternalServerError)
 		return res
 	}
 
 	token, err := s.createToken(usr.ID)
 	if err != nil {
-		s.log(err, map[string]interface{}{
+		s.log(ctx, err, map[string]interface{}{
 			"method": "SignIn",
 			"action": "CreateToken",
 			"error":  err.Error(),
@@ -112,6 +211,67 @@ func (s *Service) SignIn(ctx context.Context, req hotelcalifornia.SignInRequest)
 
 // CreateReservation represents service's create reservation method
 func (s *Service) CreateReservation(ctx context.Context, req hotelcalifornia.CreateReservationRequest) hotelcalifornia.CreateReservationResponse {
+	if req.IdempotencyKey == "" {
+		return s.createReservation(ctx, req)
+	}
+
+	res := hotelcalifornia.CreateReservationResponse{}
+
+	fingerprint, err := s.fingerprint(req)
+	if err != nil {
+		res.Result = apierror.NewBadRequestError(err)
+		res.Result.BaseError = err
+		return res
+	}
+
+	now := time.Now()
+
+	ik := &store.IdempotencyKey{
+		UserID:      req.UserId,
+		Key:         req.IdempotencyKey,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.rs.IdempotencyKeyTTL),
+	}
+
+	existing, err := s.ms.AcquireIdempotencyKey(ctx, ik)
+	if err != nil {
+		res.Result = s.storeError(ctx, "CreateReservation", "Mysql AcquireIdempotencyKey", err, apierror.CouldNotCreateReservation)
+		return res
+	}
+
+	if existing != nil {
+		return s.replayReservation(ctx, existing, fingerprint)
+	}
+
+	res = s.createReservation(ctx, req)
+	if res.Result != nil {
+		if err = s.ms.ReleaseIdempotencyKey(ctx, ik.ID); err != nil {
+			s.log(ctx, err, map[string]interface{}{
+				"method": "CreateReservation",
+				"action": "Mysql ReleaseIdempotencyKey",
+			})
+		}
+
+		return res
+	}
+
+	data, err := json.Marshal(res.Data)
+	if err == nil {
+		err = s.ms.CompleteIdempotencyKey(ctx, ik.ID, string(data))
+	}
+
+	if err != nil {
+		s.log(ctx, err, map[string]interface{}{
+			"method": "CreateReservation",
+			"action": "Mysql CompleteIdempotencyKey",
+		})
+	}
+
+	return res
+}
+
+func (s *Service) createReservation(ctx context.Context, req hotelcalifornia.CreateReservationRequest) hotelcalifornia.CreateReservationResponse {
 	res := hotelcalifornia.CreateReservationResponse{}
 	userId := req.UserId
 
@@ -141,31 +301,54 @@ func (s *Service) CreateReservation(ctx context.Context, req hotelcalifornia.Cre
 		return res
 	}
 
-	pnr := s.generatePNR(pnrLen)
+	apiErr := s.validateGuests(req.Guests, req.GuestCount)
+	if apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	guests, err := s.encryptGuests(req.Guests)
+	if err != nil {
+		res.Result = s.storeError(ctx, "CreateReservation", "EncryptGuests", err, apierror.CouldNotCreateReservation)
+		return res
+	}
 
-	rev := mysqlstore.Reservation{
+	rev := store.Reservation{
 		UserID:        userId,
-		PNR:           pnr,
 		Destination:   req.Destination,
 		CheckInDate:   checkInDate,
 		CheckOutDate:  checkOutDate,
 		CreatedAt:     time.Now(),
 		Accommodation: req.Accommodation,
 		GuestCount:    req.GuestCount,
+		Version:       1,
+		Language:      localization.PreferredLanguage(req.AcceptLanguage),
 		IsActive:      true,
 		IsDeleted:     false,
+		Guests:        guests,
 	}
 
-	err = s.ms.CreateReservation(ctx, &rev)
-	if err != nil {
-		res.Result = apierror.CouldNotCreateReservation
-		res.Result.BaseError = err
+	if rev.Invoice, apiErr = s.invoiceReservation(ctx, "CreateReservation", &rev); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	if s.rs.HoldTTL > 0 {
+		expiresAt := rev.CreatedAt.Add(s.rs.HoldTTL)
+		rev.ExpiresAt = &expiresAt
+	}
+
+	ctx = withActor(ctx, userId, req.IPAddress, req.AcceptLanguage)
+
+	// the store generates the pnr
+	if err = s.ms.CreateReservation(ctx, &rev); err != nil {
+		res.Result = s.storeError(ctx, "CreateReservation", "Mysql CreateReservation", err, apierror.CouldNotCreateReservation)
 		return res
 	}
 
 	res.Data = &hotelcalifornia.CreateReservationData{
 		IsSuccessfully: true,
-		PNR:            pnr,
+		PNR:            rev.PNR,
 	}
 
 	return res
@@ -178,6 +361,11 @@ func (s *Service) UpdateReservation(ctx context.Context, req hotelcalifornia.Upd
 	userId := req.UserId
 	pnr := req.PNR
 
+	if apiErr := checkPNR(pnr); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
 	va := s.validAccommodation(req.Accommodation)
 	if va == false {
 		res.Result = apierror.NewBadRequestError(ErrNotValidDestination)
@@ -204,12 +392,29 @@ func (s *Service) UpdateReservation(ctx context.Context, req hotelcalifornia.Upd
 		return res
 	}
 
+	version, apiErr := s.parseIfMatch(req.IfMatch)
+	if apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
 	if checkInDate.After(checkOutDate) {
 		res.Result = apierror.CouldNotCheckInGreaterThanCheckout
 		return res
 	}
 
-	rev := mysqlstore.Reservation{
+	if apiErr = s.validateGuests(req.Guests, req.GuestCount); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	guests, err := s.encryptGuests(req.Guests)
+	if err != nil {
+		res.Result = s.storeError(ctx, "UpdateReservation", "EncryptGuests", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	rev := store.Reservation{
 		PNR:           pnr,
 		UserID:        userId,
 		Destination:   req.Destination,
@@ -217,34 +422,232 @@ func (s *Service) UpdateReservation(ctx context.Context, req hotelcalifornia.Upd
 		CheckOutDate:  checkOutDate,
 		Accommodation: req.Accommodation,
 		GuestCount:    req.GuestCount,
+		Version:       version,
+		Guests:        guests,
+	}
+
+	if rev.Invoice, apiErr = s.invoiceReservation(ctx, "UpdateReservation", &rev); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	err = s.ms.UpdateReservation(withActor(ctx, userId, req.IPAddress, req.AcceptLanguage), &rev)
+	if errors.Is(err, store.ErrGuestCount) {
+		res.Result = invalidFieldError("guestCount", err)
+		return res
 	}
 
-	err = s.ms.UpdateReservation(ctx, &rev)
 	if err != nil {
-		res.Result = apierror.NewBadRequestError(err)
-		res.Result.BaseError = err
+		res.Result = s.storeError(ctx, "UpdateReservation", "Mysql UpdateReservation", err, apierror.DefaultInternalServerError)
 		return res
 	}
 
 	res.Data = &hotelcalifornia.UpdateReservationData{
 		IsSuccessfully: true,
 	}
+	res.ETag = etag(rev.Version)
 
 	return res
 }
 
+// CancelReservation represents service's cancel reservation method
+func (s *Service) CancelReservation(ctx context.Context, req hotelcalifornia.CancelReservationRequest) hotelcalifornia.CancelReservationResponse {
+	res := hotelcalifornia.CancelReservationResponse{}
+
+	if apiErr := checkPNR(req.PNR); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	version, apiErr := s.parseIfMatch(req.IfMatch)
+	if apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	rev := store.Reservation{
+		PNR:     req.PNR,
+		UserID:  req.UserId,
+		Version: version,
+	}
+
+	err := s.ms.CancelReservation(withActor(ctx, req.UserId, req.IPAddress, req.AcceptLanguage), &rev)
+	if errors.Is(err, store.ErrPastCheckIn) {
+		res.Result = apierror.CouldNotCancelPastReservation.WithBaseError(err)
+		return res
+	}
+
+	if err != nil {
+		res.Result = s.storeError(ctx, "CancelReservation", "Mysql CancelReservation", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Data = &hotelcalifornia.CancelReservationData{
+		IsSuccessfully: true,
+	}
+	res.ETag = etag(rev.Version)
+
+	return res
+}
+
+// ConfirmReservation represents service's confirm reservation method, it releases the hold of the reservation so that
+// it is not cancelled when the hold ends
+func (s *Service) ConfirmReservation(ctx context.Context, req hotelcalifornia.ConfirmReservationRequest) hotelcalifornia.ConfirmReservationResponse {
+	res := hotelcalifornia.ConfirmReservationResponse{}
+
+	if apiErr := checkPNR(req.PNR); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	version, apiErr := s.parseIfMatch(req.IfMatch)
+	if apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	rev := store.Reservation{
+		PNR:     req.PNR,
+		UserID:  req.UserId,
+		Version: version,
+	}
+
+	err := s.ms.ConfirmReservation(withActor(ctx, req.UserId, req.IPAddress, req.AcceptLanguage), &rev)
+	if err != nil {
+		res.Result = s.storeError(ctx, "ConfirmReservation", "Mysql ConfirmReservation", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Data = &hotelcalifornia.ConfirmReservationData{
+		IsSuccessfully: true,
+	}
+	res.ETag = etag(rev.Version)
+
+	return res
+}
+
+// FindReservationHistory represents service's find reservation history method, the history is shown to the owner
+// of the reservation and to staff
+func (s *Service) FindReservationHistory(ctx context.Context, req hotelcalifornia.FindReservationHistoryRequest) hotelcalifornia.FindReservationHistoryResponse {
+	res := hotelcalifornia.FindReservationHistoryResponse{}
+
+	if apiErr := checkPNR(req.PNR); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	reservation, err := s.ms.FindReservationByPNR(ctx, req.PNR)
+	if err != nil {
+		res.Result = s.storeError(ctx, "FindReservationHistory", "Mysql FindReservationByPNR", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	isStaff, err := s.isStaff(ctx, req.UserId)
+	if err != nil {
+		res.Result = s.storeError(ctx, "FindReservationHistory", "Mysql FindUser", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	// reservations of other users are not found, as in FindReservation
+	if reservation.UserID != req.UserId && !isStaff {
+		res.Result = apierror.DefaultNotFoundError
+		return res
+	}
+
+	audits, err := s.ms.FindReservationAudits(ctx, req.PNR)
+	if err != nil {
+		res.Result = s.storeError(ctx, "FindReservationHistory", "Mysql FindReservationAudits", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	entries := make([]hotelcalifornia.ReservationHistoryEntry, 0, len(audits))
+
+	for _, audit := range audits {
+		diff, err := audit.Diff()
+		if err != nil {
+			res.Result = s.storeError(ctx, "FindReservationHistory", "Unmarshal audit changes", err, apierror.DefaultInternalServerError)
+			return res
+		}
+
+		changes := make(map[string]hotelcalifornia.ReservationChange, len(diff))
+		for field, c := range diff {
+			changes[field] = hotelcalifornia.ReservationChange{From: c.From, To: c.To}
+		}
+
+		entry := hotelcalifornia.ReservationHistoryEntry{
+			Action:      audit.Action,
+			ActorUserID: audit.ActorUserID,
+			Changes:     changes,
+			CreatedAt:   audit.CreatedAt.Format(time.RFC3339),
+		}
+
+		if isStaff {
+			entry.IPAddress = audit.IPAddress
+			entry.RequestID = audit.RequestID
+		}
+
+		entries = append(entries, entry)
+	}
+
+	res.Data = &hotelcalifornia.FindReservationHistoryData{
+		PNR:     reservation.PNR,
+		Entries: entries,
+	}
+
+	return res
+}
+
+// isStaff reports whether the user has the staff role
+func (s *Service) isStaff(ctx context.Context, userID int64) (bool, error) {
+	usr, err := s.ms.FindUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return usr.Role == store.RoleStaff, nil
+}
+
+// withActor returns context recording the user and the client ip address in the audit entries of the changes, the
+// language is passed on to the notifications of the changes
+func withActor(ctx context.Context, userID int64, ipAddress, language string) context.Context {
+	return store.WithActor(ctx, store.Actor{
+		UserID:    userID,
+		IPAddress: ipAddress,
+		RequestID: requestid.FromContext(ctx),
+		Language:  language,
+	})
+}
+
 // FindReservation represents service's find reservation method
 func (s *Service) FindReservation(ctx context.Context, req hotelcalifornia.FindReservationRequest) hotelcalifornia.FindReservationResponse {
 	res := hotelcalifornia.FindReservationResponse{}
 
+	if apiErr := checkPNR(req.PNR); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
 	reservation, err := s.ms.FindReservation(ctx, req.PNR, req.UserId)
 	if err != nil {
-		res.Result = apierror.NewBadRequestError(err)
-		res.Result.BaseError = err
+		res.Result = s.storeError(ctx, "FindReservation", "Mysql FindReservation", err, apierror.DefaultInternalServerError)
 		return res
 	}
 
-	res.Data = &hotelcalifornia.FindReservationData{
+	data, err := s.reservationData(reservation)
+	if err != nil {
+		res.Result = s.storeError(ctx, "FindReservation", "GuestData", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Data = &data
+	res.ETag = etag(reservation.Version)
+
+	return res
+}
+
+// reservationData returns the details of the reservation, its user and guests are preloaded
+func (s *Service) reservationData(reservation *store.Reservation) (hotelcalifornia.FindReservationData, error) {
+	d := hotelcalifornia.FindReservationData{
 		PNR:           reservation.PNR,
 		Destination:   reservation.Destination,
 		CheckInDate:   reservation.CheckInDate.Format(dateLayout),
@@ -252,47 +655,265 @@ func (s *Service) FindReservation(ctx context.Context, req hotelcalifornia.FindR
 		Accommodation: reservation.Accommodation,
 		GuestCount:    reservation.GuestCount,
 		UserName:      fmt.Sprintf("%s %s", reservation.User.FirstName, reservation.User.LastName),
+		Status:        reservation.Status(),
 	}
 
-	return res
+	if reservation.CheckedInAt != nil {
+		d.CheckedInAt = reservation.CheckedInAt.Format(time.RFC3339)
+	}
+
+	if reservation.PenaltyAmount != 0 {
+		d.NoShowPenalty = &hotelcalifornia.Money{
+			Amount:   reservation.PenaltyAmount,
+			Currency: reservation.PenaltyCurrency,
+		}
+	}
+
+	guests, err := s.guestData(reservation.Guests)
+	if err != nil {
+		return d, err
+	}
+
+	d.Guests = guests
+
+	return d, nil
 }
 
 // FindReservations represents service's find reservations method
 func (s *Service) FindReservations(ctx context.Context, req hotelcalifornia.FindReservationsRequest) hotelcalifornia.FindReservationsResponse {
 	res := hotelcalifornia.FindReservationsResponse{}
 
-	reservations, err := s.ms.FindReservations(ctx, req.UserId)
+	q, apiErr := s.reservationQuery(req)
+	if apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	limit := q.Limit
+
+	// one more than the page is fetched to know whether there is a next page
+	q.Limit++
+
+	reservations, total, err := s.ms.FindReservations(ctx, q)
 	if err != nil {
-		res.Result = apierror.NewBadRequestError(err)
-		res.Result.BaseError = err
+		res.Result = s.storeError(ctx, "FindReservations", "Mysql FindReservations", err, apierror.DefaultInternalServerError)
 		return res
 	}
 
+	var nextCursor string
+	if len(reservations) > limit {
+		reservations = reservations[:limit]
+		nextCursor = encodeCursor(q, reservations[limit-1])
+	}
+
 	var rvs []hotelcalifornia.FindReservationData
 
 	for _, reservation := range reservations {
-
-		d := hotelcalifornia.FindReservationData{
-			PNR:           reservation.PNR,
-			Destination:   reservation.Destination,
-			CheckInDate:   reservation.CheckInDate.Format(dateLayout),
-			CheckOutDate:  reservation.CheckOutDate.Format(dateLayout),
-			Accommodation: reservation.Accommodation,
-			GuestCount:    reservation.GuestCount,
-			UserName:      fmt.Sprintf("%s %s", reservation.User.FirstName, reservation.User.LastName),
+		data, err := s.reservationData(reservation)
+		if err != nil {
+			res.Result = s.storeError(ctx, "FindReservations", "GuestData", err, apierror.DefaultInternalServerError)
+			return res
 		}
 
-		rvs = append(rvs, d)
+		rvs = append(rvs, data)
 	}
 
 	res.Data = &hotelcalifornia.FindReservationsData{
 		Reservations:   rvs,
+		TotalCount:     total,
+		NextCursor:     nextCursor,
 		IsSuccessfully: true,
 	}
 
 	return res
 }
 
+// reservationQuery converts list parameters of the request to store query
+func (s *Service) reservationQuery(req hotelcalifornia.FindReservationsRequest) (store.ReservationQuery, *apierror.APIError) {
+	q := store.ReservationQuery{
+		UserID:        req.UserId,
+		Destination:   req.Destination,
+		Accommodation: req.Accommodation,
+		SortBy:        store.SortByCheckInDate,
+		Limit:         req.Limit,
+	}
+
+	if q.Limit == 0 {
+		q.Limit = s.rs.DefaultPageSize
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = defaultPageSize
+	}
+
+	if req.Sort != "" {
+		q.SortBy = sortColumns[strings.TrimPrefix(req.Sort, "-")]
+		q.Descending = strings.HasPrefix(req.Sort, "-")
+	}
+
+	if req.Status != "" {
+		isActive := req.Status == statusActive
+		q.IsActive = &isActive
+	}
+
+	var err error
+
+	if req.From != "" {
+		if q.From, err = s.parseDate(req.From, dateLayout); err != nil {
+			return q, invalidFieldError("from", err)
+		}
+	}
+
+	if req.To != "" {
+		if q.To, err = s.parseDate(req.To, dateLayout); err != nil {
+			return q, invalidFieldError("to", err)
+		}
+	}
+
+	if req.Cursor != "" {
+		if q.After, err = decodeCursor(q, req.Cursor); err != nil {
+			return q, invalidFieldError("cursor", err)
+		}
+	}
+
+	return q, nil
+}
+
+// reservationCursor represents the opaque cursor handed to clients for the next page
+type reservationCursor struct {
+	SortBy     store.ReservationSort `json:"s"`
+	Descending bool                  `json:"d,omitempty"`
+	Value      time.Time             `json:"v"`
+	ID         int64                 `json:"i"`
+}
+
+func encodeCursor(q store.ReservationQuery, last *store.Reservation) string {
+	c := reservationCursor{
+		SortBy:     q.SortBy,
+		Descending: q.Descending,
+		Value:      last.CheckInDate,
+		ID:         last.ID,
+	}
+
+	if q.SortBy == store.SortByCreatedAt {
+		c.Value = last.CreatedAt
+	}
+
+	b, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(q store.ReservationQuery, cursor string) (*store.ReservationCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c reservationCursor
+	if err = json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	// a cursor is only meaningful for the sort order and direction it was created with
+	if c.SortBy != q.SortBy || c.Descending != q.Descending {
+		return nil, ErrInvalidCursor
+	}
+
+	return &store.ReservationCursor{
+		Value: c.Value,
+		ID:    c.ID,
+	}, nil
+}
+
+func invalidFieldError(field string, err error) *apierror.APIError {
+	apiErr := apierror.NewValidationError(err.Error(), localization.ValidationError, &apierror.FieldError{
+		Field:               field,
+		Rule:                "invalid",
+		Message:             err.Error(),
+		MessageLocalizerKey: localization.ValidationFieldInvalid,
+		TemplateData: map[string]interface{}{
+			"Field": field,
+		},
+	})
+	apiErr.BaseError = err
+
+	return apiErr
+}
+
+// replayReservation returns the response stored for an idempotency key if the request matches the original one
+func (s *Service) replayReservation(ctx context.Context, ik *store.IdempotencyKey, fingerprint string) hotelcalifornia.CreateReservationResponse {
+	res := hotelcalifornia.CreateReservationResponse{}
+
+	if ik.Fingerprint != fingerprint {
+		res.Result = apierror.IdempotencyKeyMismatch
+		return res
+	}
+
+	if ik.Response == "" {
+		res.Result = apierror.IdempotencyKeyInProgress
+		return res
+	}
+
+	var data hotelcalifornia.CreateReservationData
+	if err := json.Unmarshal([]byte(ik.Response), &data); err != nil {
+		s.log(ctx, err, map[string]interface{}{
+			"method": "CreateReservation",
+			"action": "Unmarshal idempotent response",
+		})
+
+		res.Result = apierror.CouldNotCreateReservation
+		return res
+	}
+
+	res.Data = &data
+
+	return res
+}
+
+// fingerprint hashes the request body fields, so that a key reused with another body can be detected
+func (s *Service) fingerprint(req hotelcalifornia.CreateReservationRequest) (string, error) {
+	req.Header = hotelcalifornia.Header{}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(body)
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// parseIfMatch returns the reservation version expected by the If-Match header, 0 matches any version
+func (s *Service) parseIfMatch(ifMatch string) (int64, *apierror.APIError) {
+	ifMatch = strings.TrimSpace(ifMatch)
+
+	if ifMatch == "" {
+		if s.rs.IfMatchRequired {
+			return 0, apierror.ReservationPreconditionRequired
+		}
+
+		return 0, nil
+	}
+
+	if ifMatch == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, apierror.ReservationModified
+	}
+
+	return version, nil
+}
+
+// etag returns the entity tag of a reservation version
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
 func (s *Service) createToken(userid int64) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 
@@ -321,16 +942,6 @@ func (s *Service) validAccommodation(ac string) bool {
 	return false
 }
 
-// It has nothing to do with the real scenario.
-func (s *Service) generatePNR(length int) string {
-
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
-	}
-	return string(b)
-}
-
 func (s *Service) parseDate(dateString, format string) (time.Time, error) {
 	parsedTime, err := time.Parse(format, dateString)
 	if err != nil {
@@ -340,8 +951,45 @@ func (s *Service) parseDate(dateString, format string) (time.Time, error) {
 	return parsedTime, nil
 }
 
-func (s *Service) log(err error, additionalParams map[string]interface{}) {
-	logParams := make([]interface{}, 0, 2+len(additionalParams)*2)
+// storeError logs the store error and maps it to the api error returned to clients, fallback is used for unknown errors
+func (s *Service) storeError(ctx context.Context, method, action string, err error, fallback *apierror.APIError) *apierror.APIError {
+	s.log(ctx, err, map[string]interface{}{
+		"method": method,
+		"action": action,
+	})
+
+	apiErr := fallback
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		apiErr = apierror.DefaultNotFoundError
+	case errors.Is(err, store.ErrForbiddenOwner):
+		apiErr = apierror.DefaultForbiddenError
+	case errors.Is(err, store.ErrVersionMismatch):
+		apiErr = apierror.ReservationModified
+	case errors.Is(err, store.ErrPastCheckIn):
+		apiErr = apierror.CouldNotChangeReservationCheckInDate
+	case errors.Is(err, store.ErrNotActive):
+		apiErr = apierror.ReservationAlreadyCancelled
+	case errors.Is(err, store.ErrCheckedIn):
+		apiErr = apierror.ReservationAlreadyCheckedIn
+	case errors.Is(err, store.ErrNotCheckInDay):
+		apiErr = apierror.CouldNotCheckInReservation
+	case errors.Is(err, store.ErrConflict), errors.Is(err, store.ErrNotHeld):
+		apiErr = apierror.DefaultConflictError
+	case errors.Is(err, store.ErrConstraintViolation):
+		apiErr = apierror.DefaultConstraintViolationError
+	case errors.Is(err, store.ErrUnavailable):
+		apiErr = apierror.DefaultServiceUnavailableError
+	}
+
+	return apiErr.WithBaseError(err)
+}
+
+func (s *Service) log(ctx context.Context, err error, additionalParams map[string]interface{}) {
+	logParams := make([]interface{}, 0, 4+len(additionalParams)*2)
+
+	logParams = append(logParams, "request_id", requestid.FromContext(ctx))
 
 	for k, v := range additionalParams {
 		logParams = append(logParams, k, v)
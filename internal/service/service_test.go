@@ -6,12 +6,18 @@ import (
 	"fmt"
 	"github.com/go-kit/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	hotelcalifornia "hotel-california-backend"
 	"hotel-california-backend/configs/envvars"
 	apierror "hotel-california-backend/internal/api-error"
-	mysqlstoretmock "hotel-california-backend/internal/mock/store/mysql"
-	mysqlstore "hotel-california-backend/internal/store/mysql"
+	"hotel-california-backend/internal/localization"
+	storemock "hotel-california-backend/internal/mock/store"
+	"hotel-california-backend/internal/store"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -24,12 +30,12 @@ func TestFindReservation_Success(t *testing.T) {
 	logger := log.NewLogfmtLogger(os.Stdout)
 
 	// MySQL Mock
-	ms := mysqlstoretmock.NewStore()
+	ms := storemock.NewStore()
 
-	pnr := "xyz123"
+	pnr := "XYZ2345X"
 	var userId int64 = 1
 
-	reservation := &mysqlstore.Reservation{
+	reservation := &store.Reservation{
 		CheckInDate:   time.Now(),
 		CheckOutDate:  time.Now().AddDate(0, 0, 1),
 		Accommodation: "mountain",
@@ -37,7 +43,9 @@ func TestFindReservation_Success(t *testing.T) {
 		GuestCount:    1,
 		PNR:           pnr,
 		UserID:        userId,
-		User: mysqlstore.User{
+		Version:       3,
+		IsActive:      true,
+		User: store.User{
 			FirstName: "John",
 			LastName:  "Doe",
 		},
@@ -45,7 +53,7 @@ func TestFindReservation_Success(t *testing.T) {
 
 	ms.On("FindReservation", ctx, pnr, userId).Return(reservation, nil)
 
-	service := NewService("dev", logger, ms, envvars.JWTToken{})
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{})
 
 	req := hotelcalifornia.FindReservationRequest{
 		IPAddress: "127.192.1.1",
@@ -62,8 +70,10 @@ func TestFindReservation_Success(t *testing.T) {
 			Accommodation: reservation.Accommodation,
 			GuestCount:    reservation.GuestCount,
 			UserName:      fmt.Sprintf("%s %s", reservation.User.FirstName, reservation.User.LastName),
+			Status:        store.StatusConfirmed,
 		},
 		Result: nil,
+		ETag:   `"3"`,
 	}
 
 	response := service.FindReservation(ctx, req)
@@ -78,17 +88,17 @@ func TestFindReservation_Error(t *testing.T) {
 	logger := log.NewLogfmtLogger(os.Stdout)
 
 	// MySQL Mock
-	ms := mysqlstoretmock.NewStore()
+	ms := storemock.NewStore()
 
-	pnr := "xyz123"
+	pnr := "XYZ2345X"
 	var userId int64 = 1
 
-	var reservation *mysqlstore.Reservation
-	err := errors.New("record not found")
+	var reservation *store.Reservation
+	err := &store.Error{Kind: store.ErrNotFound, Op: "FindReservation", Err: errors.New("record not found")}
 
 	ms.On("FindReservation", ctx, pnr, userId).Return(reservation, err)
 
-	service := NewService("dev", logger, ms, envvars.JWTToken{})
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{})
 
 	req := hotelcalifornia.FindReservationRequest{
 		IPAddress: "127.192.1.1",
@@ -98,11 +108,800 @@ func TestFindReservation_Error(t *testing.T) {
 
 	expectedResponse := hotelcalifornia.FindReservationResponse{
 		Data:   nil,
-		Result: apierror.NewBadRequestError(err),
+		Result: apierror.DefaultNotFoundError.WithBaseError(err),
 	}
 
-	expectedResponse.Result.BaseError = err
-
 	response := service.FindReservation(ctx, req)
 	assert.Equal(t, expectedResponse, response)
 }
+
+func TestCreateReservation_IdempotentReplay(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{IdempotencyKeyTTL: time.Hour})
+
+	req := hotelcalifornia.CreateReservationRequest{
+		UserId:         1,
+		IdempotencyKey: "6f1c2a4e",
+		Destination:    "Istanbul",
+		CheckInDate:    "2024-03-29",
+		CheckOutDate:   "2024-03-30",
+		Accommodation:  "mountain",
+		GuestCount:     1,
+	}
+
+	fingerprint, err := service.fingerprint(req)
+	assert.NoError(t, err)
+
+	stored := &store.IdempotencyKey{
+		UserID:      1,
+		Key:         req.IdempotencyKey,
+		Fingerprint: fingerprint,
+		Response:    `{"isSuccessfully":true,"pnr":"pE5TYsDj"}`,
+	}
+
+	ms.On("AcquireIdempotencyKey", ctx, mock.Anything).Return(stored, nil)
+
+	expectedResponse := hotelcalifornia.CreateReservationResponse{
+		Data: &hotelcalifornia.CreateReservationData{
+			IsSuccessfully: true,
+			PNR:            "pE5TYsDj",
+		},
+	}
+
+	response := service.CreateReservation(ctx, req)
+	assert.Equal(t, expectedResponse, response)
+	ms.AssertNotCalled(t, "CreateReservation", mock.Anything, mock.Anything)
+}
+
+func TestCreateReservation_IdempotencyKeyMismatch(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{IdempotencyKeyTTL: time.Hour})
+
+	req := hotelcalifornia.CreateReservationRequest{
+		UserId:         1,
+		IdempotencyKey: "6f1c2a4e",
+		Destination:    "Istanbul",
+		CheckInDate:    "2024-03-29",
+		CheckOutDate:   "2024-03-30",
+		Accommodation:  "mountain",
+		GuestCount:     2,
+	}
+
+	stored := &store.IdempotencyKey{
+		UserID:      1,
+		Key:         req.IdempotencyKey,
+		Fingerprint: "fingerprint-of-another-body",
+		Response:    `{"isSuccessfully":true,"pnr":"pE5TYsDj"}`,
+	}
+
+	ms.On("AcquireIdempotencyKey", ctx, mock.Anything).Return(stored, nil)
+
+	response := service.CreateReservation(ctx, req)
+	assert.Nil(t, response.Data)
+	assert.Equal(t, apierror.IdempotencyKeyMismatch, response.Result)
+}
+
+func TestUpdateReservation_StaleIfMatch(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	ms.On("UpdateReservation", mock.Anything, mock.MatchedBy(func(res *store.Reservation) bool {
+		return res.Version == 2
+	})).Return(store.ErrVersionMismatch)
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{IfMatchRequired: true, NightlyRates: []string{"city:250000"}})
+
+	req := hotelcalifornia.UpdateReservationRequest{
+		UserId:        1,
+		IfMatch:       `"2"`,
+		PNR:           "XYZ2345X",
+		Destination:   "Istanbul",
+		CheckInDate:   time.Now().AddDate(0, 0, 7).Format(dateLayout),
+		CheckOutDate:  time.Now().AddDate(0, 0, 9).Format(dateLayout),
+		Accommodation: "city",
+		GuestCount:    2,
+	}
+
+	response := service.UpdateReservation(ctx, req)
+	assert.Nil(t, response.Data)
+	assert.Equal(t, apierror.ReservationModified.WithBaseError(store.ErrVersionMismatch), response.Result)
+
+	req.IfMatch = ""
+
+	response = service.UpdateReservation(ctx, req)
+	assert.Nil(t, response.Data)
+	assert.Equal(t, apierror.ReservationPreconditionRequired, response.Result)
+}
+
+func TestConfirmReservation(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	ms.On("ConfirmReservation", mock.Anything, mock.MatchedBy(func(res *store.Reservation) bool {
+		return res.PNR == "HC7K2M9W"
+	})).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*store.Reservation).Version = 2
+	})
+	ms.On("ConfirmReservation", mock.Anything, mock.Anything).Return(store.ErrNotHeld)
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{HoldTTL: time.Hour})
+
+	response := service.ConfirmReservation(ctx, hotelcalifornia.ConfirmReservationRequest{UserId: 1, IfMatch: `"1"`, PNR: "HC7K2M9W"})
+	assert.Nil(t, response.Result)
+	assert.True(t, response.Data.IsSuccessfully)
+	assert.Equal(t, `"2"`, response.ETag)
+
+	// reservations without a hold are not confirmed again
+	response = service.ConfirmReservation(ctx, hotelcalifornia.ConfirmReservationRequest{UserId: 1, PNR: "XYZ2345X"})
+	assert.Nil(t, response.Data)
+	assert.Equal(t, apierror.DefaultConflictError.WithBaseError(store.ErrNotHeld), response.Result)
+}
+
+func TestFindReservations_NextCursor(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	checkIn := time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)
+
+	reservations := []*store.Reservation{
+		{ID: 1, PNR: "pE5TYsDj", CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 1)},
+		{ID: 2, PNR: "ObAlIEJP", CheckInDate: checkIn.AddDate(0, 0, 1), CheckOutDate: checkIn.AddDate(0, 0, 2)},
+		{ID: 3, PNR: "2G90DqRo", CheckInDate: checkIn.AddDate(0, 0, 2), CheckOutDate: checkIn.AddDate(0, 0, 3)},
+	}
+
+	ms.On("FindReservations", ctx, store.ReservationQuery{
+		UserID: 1,
+		SortBy: store.SortByCheckInDate,
+		Limit:  3,
+	}).Return(reservations, int64(5), nil)
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{})
+
+	response := service.FindReservations(ctx, hotelcalifornia.FindReservationsRequest{
+		UserId: 1,
+		Limit:  2,
+	})
+
+	assert.Nil(t, response.Result)
+	assert.Len(t, response.Data.Reservations, 2)
+	assert.Equal(t, int64(5), response.Data.TotalCount)
+	assert.NotEmpty(t, response.Data.NextCursor)
+
+	ms.On("FindReservations", ctx, store.ReservationQuery{
+		UserID: 1,
+		SortBy: store.SortByCheckInDate,
+		After: &store.ReservationCursor{
+			Value: reservations[1].CheckInDate,
+			ID:    reservations[1].ID,
+		},
+		Limit: 3,
+	}).Return(reservations[2:], int64(5), nil)
+
+	cursor := response.Data.NextCursor
+
+	response = service.FindReservations(ctx, hotelcalifornia.FindReservationsRequest{
+		UserId: 1,
+		Limit:  2,
+		Cursor: cursor,
+	})
+
+	assert.Nil(t, response.Result)
+	assert.Len(t, response.Data.Reservations, 1)
+	assert.Empty(t, response.Data.NextCursor)
+
+	// the cursor does not continue the list in the other direction
+	response = service.FindReservations(ctx, hotelcalifornia.FindReservationsRequest{
+		UserId: 1,
+		Limit:  2,
+		Sort:   "-checkInDate",
+		Cursor: cursor,
+	})
+
+	assert.Equal(t, apierror.NameValidationError, response.Result.Name)
+	assert.Equal(t, "cursor", response.Result.Fields[0].Field)
+}
+
+func TestFindReservation_UnknownStoreError(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	pnr := "XYZ2345X"
+	var userId int64 = 1
+
+	var reservation *store.Reservation
+	err := errors.New("Error 1146 (42S02): Table 'hotel.reservations' doesn't exist")
+
+	ms.On("FindReservation", ctx, pnr, userId).Return(reservation, err)
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{})
+
+	req := hotelcalifornia.FindReservationRequest{
+		PNR:    pnr,
+		UserId: userId,
+	}
+
+	response := service.FindReservation(ctx, req)
+	assert.Nil(t, response.Data)
+	assert.Equal(t, apierror.NameInternalServerError, response.Result.Name)
+	assert.Empty(t, response.Result.Message)
+	assert.Equal(t, err, response.Result.BaseError)
+}
+
+func TestReadiness(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	ms.On("Ping", ctx).Return(&store.Error{Kind: store.ErrUnavailable, Op: "Ping", Err: errors.New("connection refused")})
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{})
+
+	response := service.Readiness(ctx, hotelcalifornia.ReadinessRequest{})
+	assert.Equal(t, hotelcalifornia.HealthStatusDown, response.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode())
+	assert.Len(t, response.Checks, 2)
+	assert.Equal(t, "database", response.Checks[0].Name)
+	assert.Equal(t, hotelcalifornia.HealthStatusDown, response.Checks[0].Status)
+
+	service.BeginShutdown()
+
+	response = service.Readiness(ctx, hotelcalifornia.ReadinessRequest{})
+	assert.Equal(t, hotelcalifornia.HealthStatusDown, response.Status)
+	assert.Equal(t, "shutdown", response.Checks[0].Name)
+}
+
+func TestCreateReservation_StoreGeneratesPNR(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	ms.On("CreateReservation", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		res := args.Get(1).(*store.Reservation)
+		assert.Empty(t, res.PNR)
+
+		// the invoice is issued with the reservation
+		require.NotNil(t, res.Invoice)
+		assert.Equal(t, 1, res.Invoice.Nights)
+		assert.Equal(t, int64(300000), res.Invoice.NetAmount)
+
+		res.PNR = "ABCDEFGV"
+	}).Return(nil).Once()
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{NightlyRates: []string{"mountain:300000"}})
+
+	req := hotelcalifornia.CreateReservationRequest{
+		UserId:        1,
+		Destination:   "Istanbul",
+		CheckInDate:   "2024-03-29",
+		CheckOutDate:  "2024-03-30",
+		Accommodation: "mountain",
+		GuestCount:    1,
+	}
+
+	response := service.CreateReservation(ctx, req)
+
+	assert.Nil(t, response.Result)
+	assert.Equal(t, "ABCDEFGV", response.Data.PNR)
+
+	// an accommodation without a nightly rate is reserved without an invoice
+	ms.On("CreateReservation", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		res := args.Get(1).(*store.Reservation)
+		assert.Nil(t, res.Invoice)
+
+		res.PNR = "ABCDEFGW"
+	}).Return(nil).Once()
+
+	req.Accommodation = "beach"
+
+	response = service.CreateReservation(ctx, req)
+	assert.Nil(t, response.Result)
+	assert.Equal(t, "ABCDEFGW", response.Data.PNR)
+}
+
+func TestFindReservation_InvalidPNR(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{})
+
+	// a pnr which can not be generated is not looked up
+	response := service.FindReservation(ctx, hotelcalifornia.FindReservationRequest{UserId: 1, PNR: "ABCD-FGW"})
+
+	assert.Equal(t, apierror.NameValidationError, response.Result.Name)
+	assert.Equal(t, "pnr", response.Result.Fields[0].Field)
+	ms.AssertNotCalled(t, "FindReservation", mock.Anything, mock.Anything, mock.Anything)
+
+	// pnrs generated before check characters are looked up even when they look like a mistyped pnr
+	assert.Nil(t, checkPNR("pE5TYsDj"))
+	assert.Nil(t, checkPNR("ABCDEFGV"))
+	assert.Nil(t, checkPNR("ABCDEFGW"))
+	assert.NotNil(t, checkPNR("ABCDEFGHJ"))
+}
+
+func TestFindReservationHistory(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	pnr := "XYZ2345X"
+
+	ms.On("FindReservationByPNR", ctx, pnr).Return(&store.Reservation{PNR: pnr, UserID: 1}, nil)
+	ms.On("FindUser", ctx, int64(1)).Return(&store.User{ID: 1, Role: store.RoleGuest}, nil)
+	ms.On("FindUser", ctx, int64(2)).Return(&store.User{ID: 2, Role: store.RoleGuest}, nil)
+	ms.On("FindUser", ctx, int64(3)).Return(&store.User{ID: 3, Role: store.RoleStaff}, nil)
+	ms.On("FindReservationAudits", ctx, pnr).Return([]*store.ReservationAudit{
+		{
+			PNR:         pnr,
+			Action:      store.AuditActionUpdate,
+			ActorUserID: 1,
+			IPAddress:   "10.0.0.1",
+			RequestID:   "4bf92f35",
+			Changes:     `{"checkInDate":{"from":"2024-03-26","to":"2024-03-27"}}`,
+			CreatedAt:   time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC),
+		},
+	}, nil)
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{})
+
+	// the owner sees the changes
+	response := service.FindReservationHistory(ctx, hotelcalifornia.FindReservationHistoryRequest{UserId: 1, PNR: pnr})
+	assert.Nil(t, response.Result)
+	assert.Equal(t, &hotelcalifornia.FindReservationHistoryData{
+		PNR: pnr,
+		Entries: []hotelcalifornia.ReservationHistoryEntry{
+			{
+				Action:      store.AuditActionUpdate,
+				ActorUserID: 1,
+				Changes: map[string]hotelcalifornia.ReservationChange{
+					"checkInDate": {From: "2024-03-26", To: "2024-03-27"},
+				},
+				CreatedAt: "2024-03-20T10:00:00Z",
+			},
+		},
+	}, response.Data)
+
+	// other users do not find it
+	response = service.FindReservationHistory(ctx, hotelcalifornia.FindReservationHistoryRequest{UserId: 2, PNR: pnr})
+	assert.Nil(t, response.Data)
+	assert.Equal(t, apierror.DefaultNotFoundError, response.Result)
+
+	// staff also see where the changes were made from
+	response = service.FindReservationHistory(ctx, hotelcalifornia.FindReservationHistoryRequest{UserId: 3, PNR: pnr})
+	assert.Nil(t, response.Result)
+	assert.Equal(t, "10.0.0.1", response.Data.Entries[0].IPAddress)
+	assert.Equal(t, "4bf92f35", response.Data.Entries[0].RequestID)
+}
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	ms.On("FindUser", ctx, int64(1)).Return(&store.User{ID: 1, Role: store.RoleGuest}, nil)
+	ms.On("FindUser", ctx, int64(3)).Return(&store.User{ID: 3, Role: store.RoleStaff}, nil)
+	ms.On("CreateWebhookSubscription", ctx, mock.Anything).Return(nil)
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{})
+
+	req := hotelcalifornia.CreateWebhookSubscriptionRequest{
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{"ReservationCreated", "ReservationCancelled"},
+	}
+
+	// guests can not manage subscriptions
+	req.UserId = 1
+	response := service.CreateWebhookSubscription(ctx, req)
+	assert.Nil(t, response.Data)
+	assert.Equal(t, apierror.DefaultForbiddenError, response.Result)
+
+	// staff get the secret once
+	req.UserId = 3
+	response = service.CreateWebhookSubscription(ctx, req)
+	assert.Nil(t, response.Result)
+	assert.Len(t, response.Data.Secret, 64)
+	assert.Equal(t, req.EventTypes, response.Data.EventTypes)
+
+	sub := ms.Calls[len(ms.Calls)-1].Arguments.Get(1).(*store.WebhookSubscription)
+	assert.Equal(t, "ReservationCreated,ReservationCancelled", sub.EventTypes)
+	assert.Equal(t, response.Data.Secret, sub.Secret)
+	assert.True(t, sub.IsActive)
+}
+
+func TestCalendarSubscription(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	err := localization.InitializeBundle(envvars.Localization{
+		LanguageFilesDirectory: "../localization/language-files",
+	})
+	require.NoError(t, err)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	ms.On("CreateCalendarToken", ctx, mock.Anything).Return(nil)
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{
+		CalendarURL: "https://hotel-california.example.com/v1/reservations.ics",
+	})
+
+	created := service.CreateCalendarSubscription(ctx, hotelcalifornia.CreateCalendarSubscriptionRequest{
+		Header: hotelcalifornia.Header{AcceptLanguage: "tr-TR,tr;q=0.9"},
+		UserId: 1,
+	})
+	require.Nil(t, created.Result)
+
+	u, err := url.Parse(created.Data.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/reservations.ics", u.Path)
+
+	token := ms.Calls[len(ms.Calls)-1].Arguments.Get(1).(*store.CalendarToken)
+	assert.Equal(t, hashCalendarToken(u.Query().Get("token")), token.TokenHash)
+	assert.Equal(t, "tr-TR", token.Language)
+
+	ms.On("FindCalendarToken", ctx, token.TokenHash).Return(token, nil)
+	ms.On("FindCalendarToken", ctx, mock.Anything).Return((*store.CalendarToken)(nil), &store.Error{Kind: store.ErrNotFound, Op: "FindCalendarToken", Err: errors.New("no such token")})
+	ms.On("FindReservations", ctx, mock.Anything).Return([]*store.Reservation{{
+		PNR:           "HC7K2M9W",
+		UserID:        1,
+		Destination:   "İzmir",
+		CheckInDate:   time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC),
+		CheckOutDate:  time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC),
+		Accommodation: "beach",
+		GuestCount:    2,
+		Version:       2,
+	}}, int64(1), nil)
+
+	// the calendar is served to the token without signing in
+	exported := service.ExportReservations(ctx, hotelcalifornia.ExportReservationsRequest{CalendarToken: u.Query().Get("token")})
+	require.Nil(t, exported.Result)
+	assert.Equal(t, "text/calendar; charset=utf-8", exported.File().ContentType)
+
+	ics := string(exported.File().Content)
+	assert.Contains(t, ics, "X-WR-CALNAME:Hotel California rezervasyonları\r\n")
+	assert.Contains(t, ics, "UID:HC7K2M9W@hotel-california\r\n")
+	assert.Contains(t, ics, "DTSTART;VALUE=DATE:20240324\r\nDTEND;VALUE=DATE:20240330\r\n")
+	assert.Contains(t, ics, "LOCATION:İzmir\r\n")
+	assert.Contains(t, ics, "STATUS:CANCELLED\r\n")
+	assert.Contains(t, ics, "SEQUENCE:1\r\n")
+
+	q := ms.Calls[len(ms.Calls)-1].Arguments.Get(1).(store.ReservationQuery)
+	assert.Equal(t, int64(1), q.UserID)
+
+	// calendars without a history list the latest stays of the default history
+	assert.WithinDuration(t, time.Now().Add(-defaultCalendarHistory), q.From, time.Minute)
+	assert.Equal(t, store.SortByCheckInDate, q.SortBy)
+	assert.True(t, q.Descending)
+
+	exported = service.ExportReservations(ctx, hotelcalifornia.ExportReservationsRequest{CalendarToken: "revoked"})
+	assert.Nil(t, exported.File())
+	assert.Equal(t, apierror.DefaultUnauthorizedError, exported.Result)
+}
+
+func TestExportReservationConfirmation(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	err := localization.InitializeBundle(envvars.Localization{
+		LanguageFilesDirectory: "../localization/language-files",
+	})
+	require.NoError(t, err)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	reservation := &store.Reservation{
+		ID:            7,
+		PNR:           "HC7K2M9W",
+		UserID:        1,
+		Destination:   "İzmir",
+		CheckInDate:   time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC),
+		CheckOutDate:  time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC),
+		Accommodation: "beach",
+		GuestCount:    2,
+		Version:       2,
+		IsActive:      true,
+		User:          store.User{FirstName: "Işıl", LastName: "Güneş"},
+	}
+
+	ms.On("FindReservation", ctx, "HC7K2M9W", int64(1)).Return(reservation, nil)
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{
+		NightlyRates:         []string{"beach:350050", "city:250000"},
+		VATRate:              10,
+		AccommodationTaxRate: 2,
+		Currency:             "TRY",
+		InvoicePrefix:        "HC-",
+	})
+
+	inv, err := service.priceReservation(reservation, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 6, inv.Nights)
+	assert.Equal(t, int64(2100300), inv.NetAmount)
+	assert.Equal(t, int64(210030), inv.VATAmount)
+	assert.Equal(t, int64(42006), inv.AccommodationTaxAmount)
+	assert.Equal(t, int64(2352336), inv.TotalAmount)
+
+	inv.Number = 42
+	ms.On("FindInvoice", ctx, int64(7)).Return(inv, nil)
+
+	response := service.ExportReservationConfirmation(ctx, hotelcalifornia.ExportReservationConfirmationRequest{
+		Header: hotelcalifornia.Header{AcceptLanguage: "tr"},
+		PNR:    "HC7K2M9W",
+		UserId: 1,
+	})
+	require.Nil(t, response.Result)
+	assert.Equal(t, "HC7K2M9W-confirmation.pdf", response.File().Name)
+	assert.Equal(t, "application/pdf", response.File().ContentType)
+	assert.True(t, strings.HasPrefix(string(response.File().Content), "%PDF-"))
+
+	// the confirmation is read, the reservation is not invoiced again
+	ms.AssertNotCalled(t, "CreateReservation", mock.Anything, mock.Anything)
+	ms.AssertNotCalled(t, "UpdateReservation", mock.Anything, mock.Anything)
+
+	// confirmations of cancelled reservations are not exported
+	reservation.IsActive = false
+
+	response = service.ExportReservationConfirmation(ctx, hotelcalifornia.ExportReservationConfirmationRequest{PNR: "HC7K2M9W", UserId: 1})
+	assert.Nil(t, response.File())
+	assert.Equal(t, apierror.ReservationAlreadyCancelled, response.Result)
+	ms.AssertNumberOfCalls(t, "FindInvoice", 1)
+
+	// reservations made before invoices were issued with them are invoiced on their first export
+	uninvoiced := *reservation
+	uninvoiced.ID = 8
+	uninvoiced.PNR = "HC7K2M8P"
+	uninvoiced.IsActive = true
+
+	ms.On("FindReservation", ctx, "HC7K2M8P", int64(1)).Return(&uninvoiced, nil)
+	ms.On("FindInvoice", ctx, int64(8)).Return((*store.Invoice)(nil), store.ErrNotFound)
+	ms.On("IssueInvoice", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*store.Invoice).Number = 43
+	}).Return(nil)
+
+	response = service.ExportReservationConfirmation(ctx, hotelcalifornia.ExportReservationConfirmationRequest{PNR: "HC7K2M8P", UserId: 1})
+	require.Nil(t, response.Result)
+	assert.Equal(t, "HC7K2M8P-confirmation.pdf", response.File().Name)
+
+	issued := ms.Calls[len(ms.Calls)-1].Arguments.Get(1).(*store.Invoice)
+	assert.Equal(t, int64(8), issued.ReservationID)
+	assert.Equal(t, int64(2), issued.ReservationVersion)
+	assert.Equal(t, int64(2352336), issued.TotalAmount)
+}
+
+func TestLookupReservation(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	reservation := &store.Reservation{
+		PNR:           "HC7K2M9W",
+		UserID:        1,
+		Destination:   "İzmir",
+		CheckInDate:   time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC),
+		CheckOutDate:  time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC),
+		Accommodation: "beach",
+		GuestCount:    2,
+		IsActive:      true,
+		User:          store.User{FirstName: "Işıl", LastName: "Güneş Yıldız"},
+	}
+
+	ms.On("FindReservationByPNR", ctx, "HC7K2M9W").Return(reservation, nil)
+	ms.On("FindReservationByPNR", ctx, "HC000000").Return((*store.Reservation)(nil), store.ErrNotFound)
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{
+		LookupInterval: time.Hour,
+		LookupBurst:    4,
+	})
+
+	// last names match without case and diacritics
+	response := service.LookupReservation(ctx, hotelcalifornia.LookupReservationRequest{
+		IPAddress: "203.0.113.7",
+		PNR:       "HC7K2M9W",
+		LastName:  " GUNES  yildiz",
+	})
+	require.Nil(t, response.Result)
+	assert.Equal(t, hotelcalifornia.LookupReservationData{
+		PNR:           "HC7K2M9W",
+		Destination:   "İzmir",
+		CheckInDate:   "2024-03-24",
+		CheckOutDate:  "2024-03-30",
+		Accommodation: "beach",
+		GuestCount:    2,
+		Status:        store.StatusConfirmed,
+	}, *response.Data)
+
+	// a last name which does not match is not found like a pnr which does not exist
+	response = service.LookupReservation(ctx, hotelcalifornia.LookupReservationRequest{
+		IPAddress: "203.0.113.7",
+		PNR:       "HC7K2M9W",
+		LastName:  "Yılmaz",
+	})
+	assert.Equal(t, apierror.DefaultNotFoundError, response.Result)
+
+	response = service.LookupReservation(ctx, hotelcalifornia.LookupReservationRequest{
+		IPAddress: "203.0.113.7",
+		PNR:       "HC000000",
+		LastName:  "Güneş Yıldız",
+	})
+	assert.Equal(t, apierror.DefaultNotFoundError, response.Result)
+
+	// lookups are limited per ip address
+	response = service.LookupReservation(ctx, hotelcalifornia.LookupReservationRequest{
+		IPAddress: "203.0.113.7",
+		PNR:       "HC7K2M9W",
+		LastName:  "Güneş Yıldız",
+	})
+	require.Nil(t, response.Result)
+
+	response = service.LookupReservation(ctx, hotelcalifornia.LookupReservationRequest{
+		IPAddress: "203.0.113.7",
+		PNR:       "HC7K2M9W",
+		LastName:  "Güneş Yıldız",
+	})
+	assert.Equal(t, apierror.DefaultTooManyRequestsError, response.Result)
+
+	response = service.LookupReservation(ctx, hotelcalifornia.LookupReservationRequest{
+		IPAddress: "198.51.100.4",
+		PNR:       "HC7K2M9W",
+		LastName:  "Güneş Yıldız",
+	})
+	assert.Nil(t, response.Result)
+}
+
+func TestReservationGuests(t *testing.T) {
+	// Context
+	ctx := context.Background()
+
+	// Log
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// MySQL Mock
+	ms := storemock.NewStore()
+
+	rs := envvars.Reservation{GuestDataKey: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=", GuestDataKeyVersion: 1, NightlyRates: []string{"city:250000"}}
+
+	service := NewService("dev", logger, ms, envvars.JWTToken{}, rs)
+
+	age := 7
+	checkIn := time.Now().AddDate(0, 0, 10)
+
+	req := hotelcalifornia.CreateReservationRequest{
+		UserId:        1,
+		Destination:   "Istanbul",
+		CheckInDate:   checkIn.Format(dateLayout),
+		CheckOutDate:  checkIn.AddDate(0, 0, 2).Format(dateLayout),
+		Accommodation: "city",
+		GuestCount:    2,
+		Guests: []hotelcalifornia.ReservationGuest{
+			{FullName: "Işıl Güneş", Type: "adult", Nationality: "TR", DocumentType: "national_id", DocumentNumber: "12345678901"},
+			{FullName: "Ada Güneş", Type: "child", Age: &age, Nationality: "TR"},
+		},
+	}
+
+	// guests have to match the guest count
+	mismatch := req
+	mismatch.GuestCount = 3
+	response := service.CreateReservation(ctx, mismatch)
+	require.NotNil(t, response.Result)
+	assert.Equal(t, "guests", response.Result.Fields[0].Field)
+
+	// a child is under 18
+	adultAge := 18
+	grownUp := req
+	grownUp.Guests = []hotelcalifornia.ReservationGuest{req.Guests[0], {FullName: "Ada Güneş", Type: "child", Age: &adultAge, Nationality: "TR"}}
+	response = service.CreateReservation(ctx, grownUp)
+	require.NotNil(t, response.Result)
+	assert.Equal(t, "guests[1].age", response.Result.Fields[0].Field)
+
+	// documents are not accepted without a guest data key
+	keyless := NewService("dev", logger, ms, envvars.JWTToken{}, envvars.Reservation{NightlyRates: rs.NightlyRates})
+	response = keyless.CreateReservation(ctx, req)
+	require.NotNil(t, response.Result)
+	assert.Equal(t, http.StatusBadRequest, response.Result.StatusCode)
+	assert.Equal(t, "guests[0].documentNumber", response.Result.Fields[0].Field)
+
+	var created *store.Reservation
+	ms.On("CreateReservation", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*store.Reservation)
+		created.PNR = "HC7K2M9W"
+	}).Return(nil)
+
+	response = service.CreateReservation(ctx, req)
+	require.Nil(t, response.Result)
+	require.Len(t, created.Guests, 2)
+
+	// documents are stored encrypted
+	assert.NotEmpty(t, created.Guests[0].DocumentNumber)
+	assert.NotContains(t, created.Guests[0].DocumentNumber, "12345678901")
+	assert.NotEqual(t, "national_id", created.Guests[0].DocumentType)
+	assert.Empty(t, created.Guests[1].DocumentNumber)
+
+	created.User = store.User{FirstName: "Işıl", LastName: "Güneş"}
+	created.IsActive = true
+	ms.On("FindReservation", ctx, created.PNR, int64(1)).Return(created, nil)
+
+	// document numbers are masked but their last characters
+	found := service.FindReservation(ctx, hotelcalifornia.FindReservationRequest{PNR: created.PNR, UserId: 1})
+	require.Nil(t, found.Result)
+	assert.Equal(t, []hotelcalifornia.ReservationGuestData{
+		{FullName: "Işıl Güneş", Type: "adult", Nationality: "TR", DocumentType: "national_id", DocumentNumber: "*******8901"},
+		{FullName: "Ada Güneş", Type: "child", Age: &age, Nationality: "TR"},
+	}, found.Data.Guests)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	hotelcalifornia "hotel-california-backend"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/store"
+	"time"
+)
+
+// CheckInReservation represents service's check in reservation method, staff check guests in from the check-in date
+// until the check-out date. Guests not checked in by the no-show cutoff are marked as no-shows by the scheduler.
+func (s *Service) CheckInReservation(ctx context.Context, req hotelcalifornia.CheckInReservationRequest) hotelcalifornia.CheckInReservationResponse {
+	res := hotelcalifornia.CheckInReservationResponse{}
+
+	if apiErr := checkPNR(req.PNR); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	if apiErr := s.requireStaff(ctx, "CheckInReservation", req.UserId); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	reservation, err := s.ms.FindReservationByPNR(ctx, req.PNR)
+	if err != nil {
+		res.Result = s.storeError(ctx, "CheckInReservation", "Mysql FindReservationByPNR", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	now := time.Now()
+
+	rev := store.Reservation{
+		PNR:    reservation.PNR,
+		UserID: reservation.UserID,
+	}
+
+	err = s.ms.CheckInReservation(withActor(ctx, req.UserId, req.IPAddress, req.AcceptLanguage), &rev, now)
+	if err != nil {
+		res.Result = s.storeError(ctx, "CheckInReservation", "Mysql CheckInReservation", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Data = &hotelcalifornia.CheckInReservationData{
+		IsSuccessfully: true,
+		CheckedInAt:    now.Format(time.RFC3339),
+	}
+
+	return res
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	hotelcalifornia "hotel-california-backend"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/store"
+	"strings"
+	"time"
+)
+
+// webhook settings
+const (
+	webhookSecretLen         = 32
+	defaultWebhookDeliveries = 50
+)
+
+// CreateWebhookSubscription represents service's create webhook subscription method, the secret deliveries are
+// signed with is generated and returned only once
+func (s *Service) CreateWebhookSubscription(ctx context.Context, req hotelcalifornia.CreateWebhookSubscriptionRequest) hotelcalifornia.CreateWebhookSubscriptionResponse {
+	res := hotelcalifornia.CreateWebhookSubscriptionResponse{}
+
+	if apiErr := s.requireStaff(ctx, "CreateWebhookSubscription", req.UserId); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		res.Result = s.storeError(ctx, "CreateWebhookSubscription", "GenerateSecret", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	sub := store.WebhookSubscription{
+		URL:        req.URL,
+		EventTypes: strings.Join(req.EventTypes, ","),
+		Secret:     secret,
+		UserID:     req.ReservationUserId,
+		IsActive:   true,
+		CreatedAt:  time.Now(),
+	}
+
+	if err = s.ms.CreateWebhookSubscription(ctx, &sub); err != nil {
+		res.Result = s.storeError(ctx, "CreateWebhookSubscription", "Mysql CreateWebhookSubscription", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	data := webhookSubscriptionData(&sub)
+	data.Secret = sub.Secret
+
+	res.Data = &data
+
+	return res
+}
+
+// FindWebhookSubscriptions represents service's find webhook subscriptions method
+func (s *Service) FindWebhookSubscriptions(ctx context.Context, req hotelcalifornia.FindWebhookSubscriptionsRequest) hotelcalifornia.FindWebhookSubscriptionsResponse {
+	res := hotelcalifornia.FindWebhookSubscriptionsResponse{}
+
+	if apiErr := s.requireStaff(ctx, "FindWebhookSubscriptions", req.UserId); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	subs, err := s.ms.FindWebhookSubscriptions(ctx)
+	if err != nil {
+		res.Result = s.storeError(ctx, "FindWebhookSubscriptions", "Mysql FindWebhookSubscriptions", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	data := make([]hotelcalifornia.WebhookSubscriptionData, 0, len(subs))
+	for _, sub := range subs {
+		data = append(data, webhookSubscriptionData(sub))
+	}
+
+	res.Data = &hotelcalifornia.FindWebhookSubscriptionsData{
+		Subscriptions: data,
+	}
+
+	return res
+}
+
+// DeleteWebhookSubscription represents service's delete webhook subscription method, deliveries already queued for
+// the subscription are still attempted
+func (s *Service) DeleteWebhookSubscription(ctx context.Context, req hotelcalifornia.DeleteWebhookSubscriptionRequest) hotelcalifornia.DeleteWebhookSubscriptionResponse {
+	res := hotelcalifornia.DeleteWebhookSubscriptionResponse{}
+
+	if apiErr := s.requireStaff(ctx, "DeleteWebhookSubscription", req.UserId); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	if err := s.ms.DeleteWebhookSubscription(ctx, req.ID); err != nil {
+		res.Result = s.storeError(ctx, "DeleteWebhookSubscription", "Mysql DeleteWebhookSubscription", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Data = &hotelcalifornia.DeleteWebhookSubscriptionData{
+		IsSuccessfully: true,
+	}
+
+	return res
+}
+
+// FindWebhookDeliveries represents service's find webhook deliveries method, the latest deliveries are listed first
+func (s *Service) FindWebhookDeliveries(ctx context.Context, req hotelcalifornia.FindWebhookDeliveriesRequest) hotelcalifornia.FindWebhookDeliveriesResponse {
+	res := hotelcalifornia.FindWebhookDeliveriesResponse{}
+
+	if apiErr := s.requireStaff(ctx, "FindWebhookDeliveries", req.UserId); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	q := store.WebhookDeliveryQuery{
+		SubscriptionID: req.SubscriptionId,
+		Status:         req.Status,
+		Limit:          req.Limit,
+	}
+
+	if q.Limit == 0 {
+		q.Limit = defaultWebhookDeliveries
+	}
+
+	ds, err := s.ms.FindWebhookDeliveries(ctx, q)
+	if err != nil {
+		res.Result = s.storeError(ctx, "FindWebhookDeliveries", "Mysql FindWebhookDeliveries", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	deliveries := make([]hotelcalifornia.WebhookDeliveryData, 0, len(ds))
+
+	for _, d := range ds {
+		attempts := make([]hotelcalifornia.WebhookDeliveryAttemptData, 0, len(d.AttemptLogs))
+		for _, a := range d.AttemptLogs {
+			attempts = append(attempts, hotelcalifornia.WebhookDeliveryAttemptData{
+				StatusCode: a.StatusCode,
+				Error:      a.Error,
+				DurationMs: a.DurationMs,
+				CreatedAt:  a.CreatedAt.Format(time.RFC3339),
+			})
+		}
+
+		delivery := hotelcalifornia.WebhookDeliveryData{
+			ID:             d.ID,
+			SubscriptionId: d.SubscriptionID,
+			EventID:        d.EventID,
+			EventType:      d.EventType,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			LastStatusCode: d.LastStatusCode,
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+			AttemptLogs:    attempts,
+		}
+
+		// only pending deliveries are attempted again
+		if d.Status == store.WebhookDeliveryPending {
+			delivery.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	res.Data = &hotelcalifornia.FindWebhookDeliveriesData{
+		Deliveries: deliveries,
+	}
+
+	return res
+}
+
+// RedeliverWebhookDelivery represents service's redeliver webhook delivery method, the delivery is attempted again
+// with a fresh count of attempts whatever its status
+func (s *Service) RedeliverWebhookDelivery(ctx context.Context, req hotelcalifornia.RedeliverWebhookDeliveryRequest) hotelcalifornia.RedeliverWebhookDeliveryResponse {
+	res := hotelcalifornia.RedeliverWebhookDeliveryResponse{}
+
+	if apiErr := s.requireStaff(ctx, "RedeliverWebhookDelivery", req.UserId); apiErr != nil {
+		res.Result = apiErr
+		return res
+	}
+
+	if err := s.ms.RedeliverWebhookDelivery(ctx, req.ID, time.Now()); err != nil {
+		res.Result = s.storeError(ctx, "RedeliverWebhookDelivery", "Mysql RedeliverWebhookDelivery", err, apierror.DefaultInternalServerError)
+		return res
+	}
+
+	res.Data = &hotelcalifornia.RedeliverWebhookDeliveryData{
+		IsSuccessfully: true,
+	}
+
+	return res
+}
+
+// requireStaff returns forbidden error unless the user has the staff role
+func (s *Service) requireStaff(ctx context.Context, method string, userID int64) *apierror.APIError {
+	isStaff, err := s.isStaff(ctx, userID)
+	if err != nil {
+		return s.storeError(ctx, method, "Mysql FindUser", err, apierror.DefaultInternalServerError)
+	}
+
+	if !isStaff {
+		return apierror.DefaultForbiddenError
+	}
+
+	return nil
+}
+
+func webhookSubscriptionData(sub *store.WebhookSubscription) hotelcalifornia.WebhookSubscriptionData {
+	return hotelcalifornia.WebhookSubscriptionData{
+		ID:                sub.ID,
+		URL:               sub.URL,
+		EventTypes:        strings.Split(sub.EventTypes, ","),
+		ReservationUserId: sub.UserID,
+		CreatedAt:         sub.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func generateWebhookSecret() (string, error) {
+	return generateSecret(webhookSecretLen)
+}
+
+// generateSecret returns n random bytes hex encoded
+func generateSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
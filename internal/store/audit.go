@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// audit actions
+const (
+	AuditActionCreate   = "create"
+	AuditActionUpdate   = "update"
+	AuditActionCancel   = "cancel"
+	AuditActionExpire   = "expire"
+	AuditActionConfirm  = "confirm"
+	AuditActionCheckIn  = "check_in"
+	AuditActionComplete = "complete"
+	AuditActionNoShow   = "no_show"
+)
+
+// auditDateLayout is the layout dates are recorded with in audit changes
+const auditDateLayout = "2006-01-02"
+
+// ReservationAudit represents an append-only record of a change to a reservation, it is written in the same
+// transaction as the change
+type ReservationAudit struct {
+	ID            int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	ReservationID int64     `gorm:"column:reservation_id;not null"`
+	PNR           string    `gorm:"column:pnr;size:16;not null;index:idx_reservation_audits_pnr,priority:1"`
+	Action        string    `gorm:"column:action;size:16;not null"`
+	ActorUserID   int64     `gorm:"column:actor_user_id;not null"`
+	IPAddress     string    `gorm:"column:ip_address;size:45"`
+	RequestID     string    `gorm:"column:request_id;size:128"`
+	Changes       string    `gorm:"column:changes;type:text"`
+	CreatedAt     time.Time `gorm:"column:createdAt;not null"`
+}
+
+// AuditChange represents the value of a field before and after a change, From is nil for created reservations
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff returns the changed fields of the audit entry
+func (a *ReservationAudit) Diff() (map[string]AuditChange, error) {
+	changes := map[string]AuditChange{}
+
+	if a.Changes == "" {
+		return changes, nil
+	}
+
+	if err := json.Unmarshal([]byte(a.Changes), &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// Actor represents who makes a change and where from, it is recorded in the audit entries of the change. Language is
+// the language the actor made the change in, it is carried by the domain event of the change. System actors are
+// background jobs, their changes are recorded with actor user id 0.
+type Actor struct {
+	UserID    int64
+	IPAddress string
+	RequestID string
+	Language  string
+	System    bool
+}
+
+var actorKey = struct{ Key string }{"actor"}
+
+// WithActor returns context carrying the actor of the changes made with it
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// actorFromContext returns the actor of the context, changes without one are recorded as made by the owner
+func actorFromContext(ctx context.Context, ownerID int64) Actor {
+	actor, ok := ctx.Value(actorKey).(Actor)
+	if !ok || (actor.UserID == 0 && !actor.System) {
+		actor.UserID = ownerID
+	}
+
+	return actor
+}
+
+func newAudit(ctx context.Context, action string, before, after *Reservation) (*ReservationAudit, error) {
+	changes, err := json.Marshal(diffReservations(before, after))
+	if err != nil {
+		return nil, err
+	}
+
+	actor := actorFromContext(ctx, after.UserID)
+
+	return &ReservationAudit{
+		ReservationID: after.ID,
+		PNR:           after.PNR,
+		Action:        action,
+		ActorUserID:   actor.UserID,
+		IPAddress:     actor.IPAddress,
+		RequestID:     actor.RequestID,
+		Changes:       string(changes),
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+// diffReservations returns the fields whose values differ, every field when before is nil
+func diffReservations(before, after *Reservation) map[string]AuditChange {
+	to := auditFields(after)
+	changes := make(map[string]AuditChange, len(to))
+
+	var from map[string]interface{}
+	if before != nil {
+		from = auditFields(before)
+	}
+
+	for field, v := range to {
+		if before == nil {
+			changes[field] = AuditChange{To: v}
+			continue
+		}
+
+		if from[field] != v {
+			changes[field] = AuditChange{From: from[field], To: v}
+		}
+	}
+
+	// replaced guests are recorded even when their number is the same
+	if before != nil && !sameGuests(before.Guests, after.Guests) {
+		changes["guests"] = AuditChange{From: len(before.Guests), To: len(after.Guests)}
+	}
+
+	return changes
+}
+
+// auditFields returns the audited fields of the reservation, the end of the hold is left out of confirmed
+// reservations and the fields of stays are left out until the stay progresses. Guests are recorded by their number,
+// the audit entries are sent with the events of the changes so no personal data of the guests is recorded.
+func auditFields(res *Reservation) map[string]interface{} {
+	fields := map[string]interface{}{
+		"destination":   res.Destination,
+		"checkInDate":   res.CheckInDate.Format(auditDateLayout),
+		"checkOutDate":  res.CheckOutDate.Format(auditDateLayout),
+		"accommodation": res.Accommodation,
+		"guestCount":    res.GuestCount,
+		"isActive":      res.IsActive,
+	}
+
+	if res.ExpiresAt != nil {
+		fields["expiresAt"] = res.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+
+	if res.StayStatus != "" {
+		fields["status"] = res.StayStatus
+	}
+
+	if res.PenaltyAmount != 0 {
+		fields["penaltyAmount"] = res.PenaltyAmount
+		fields["penaltyCurrency"] = res.PenaltyCurrency
+	}
+
+	if len(res.Guests) > 0 {
+		fields["guests"] = len(res.Guests)
+	}
+
+	return fields
+}
+
+// FindReservationAudits returns the audit entries of the reservation, oldest first
+func (s *store) FindReservationAudits(ctx context.Context, pnr string) ([]*ReservationAudit, error) {
+	var audits []*ReservationAudit
+
+	err := s.db.WithContext(ctx).Where("pnr = ?", pnr).Order("id").Find(&audits).Error
+	if err != nil {
+		return nil, s.translateError("FindReservationAudits", err)
+	}
+
+	return audits, nil
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CalendarToken represents the token a user's calendar is subscribed to with instead of signing in, only the sha256
+// hash of the token is stored. The calendar is rendered in Language.
+type CalendarToken struct {
+	ID        int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID    int64      `gorm:"column:user_id;not null;index:idx_calendar_tokens_user_id"`
+	TokenHash string     `gorm:"column:token_hash;size:64;not null;uniqueIndex:idx_calendar_tokens_token_hash"`
+	Language  string     `gorm:"column:language;size:35;not null;default:''"`
+	CreatedAt time.Time  `gorm:"column:createdAt;not null"`
+	RevokedAt *time.Time `gorm:"column:revoked_at"`
+}
+
+// CreateCalendarToken creates the token, a user has one token at a time so the token the user had before is revoked
+func (s *store) CreateCalendarToken(ctx context.Context, token *CalendarToken) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := revokeCalendarTokens(tx, token.UserID, token.CreatedAt).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(token).Error
+	})
+
+	return s.translateError("CreateCalendarToken", err)
+}
+
+// FindCalendarToken returns the token of the hash, ErrNotFound is returned when it is revoked
+func (s *store) FindCalendarToken(ctx context.Context, tokenHash string) (*CalendarToken, error) {
+	var token CalendarToken
+
+	err := s.db.WithContext(ctx).Where("token_hash = ? AND revoked_at IS NULL", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, s.translateError("FindCalendarToken", err)
+	}
+
+	return &token, nil
+}
+
+// RevokeCalendarTokens revokes the user's tokens and returns how many were revoked
+func (s *store) RevokeCalendarTokens(ctx context.Context, userID int64, revokedAt time.Time) (int64, error) {
+	result := revokeCalendarTokens(s.db.WithContext(ctx), userID, revokedAt)
+	if result.Error != nil {
+		return 0, s.translateError("RevokeCalendarTokens", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func revokeCalendarTokens(db *gorm.DB, userID int64, revokedAt time.Time) *gorm.DB {
+	return db.Model(&CalendarToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt)
+}
@@ -0,0 +1,16 @@
+package store
+
+import (
+	"hotel-california-backend/internal/migrate"
+	"io/fs"
+)
+
+// Dialect represents what differs between the databases the store runs on
+type Dialect struct {
+	// Migrations are the dialect's migration scripts, versions are kept in step across dialects
+	Migrations fs.FS
+	// Migrate is how migrations are locked and recorded on the database
+	Migrate migrate.Dialect
+	// ErrorKind returns the kind of the driver's own errors, nil when it is unknown
+	ErrorKind func(err error) error
+}
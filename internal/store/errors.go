@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"gorm.io/gorm"
+	"net"
+)
+
+// error kinds
+var (
+	ErrNotFound            = errors.New("record not found")
+	ErrConflict            = errors.New("record conflicts with an existing record")
+	ErrForbiddenOwner      = errors.New("record does not belong to this user")
+	ErrConstraintViolation = errors.New("record violates a constraint")
+	ErrUnavailable         = errors.New("database is unavailable")
+)
+
+// errors
+var (
+	ErrVersionMismatch = errors.New("the reservation has been changed since it was read")
+	ErrPastCheckIn     = errors.New("the check-in date of a past reservation cannot be changed")
+	ErrNotActive       = errors.New("the reservation is already cancelled")
+	ErrNotExpired      = errors.New("the reservation hold has not expired")
+	ErrNotHeld         = errors.New("the reservation is not held")
+	ErrCheckedIn       = errors.New("the reservation is already checked in")
+	ErrNotCheckInDay   = errors.New("the reservation can only be checked in from its check-in date until its check-out date")
+	ErrGuestCount      = errors.New("the guest count does not match the guests of the reservation")
+)
+
+// compile-time proof of error interface implementation
+var _ error = (*Error)(nil)
+
+// Error represents a store error of a kind, keeping the driver error for logs
+type Error struct {
+	Kind error
+	Op   string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Op + ": " + e.Kind.Error() + ", " + e.Err.Error()
+}
+
+// Is reports whether the error is of the target kind
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// translateError wraps database errors of a known kind into Error, driverErrorKind maps the dialect's own errors
+func translateError(op string, err error, driverErrorKind func(error) error) error {
+	if err == nil {
+		return nil
+	}
+
+	var storeErr *Error
+	if errors.As(err, &storeErr) {
+		return err
+	}
+
+	kind := errorKind(err)
+	if kind == nil && driverErrorKind != nil {
+		kind = driverErrorKind(err)
+	}
+
+	if kind != nil {
+		return &Error{
+			Kind: kind,
+			Op:   op,
+			Err:  err,
+		}
+	}
+
+	return err
+}
+
+func errorKind(err error) error {
+	for _, kind := range []error{ErrNotFound, ErrConflict, ErrForbiddenOwner, ErrConstraintViolation, ErrUnavailable} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrConflict
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return ErrConstraintViolation
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, context.DeadlineExceeded):
+		return ErrUnavailable
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrUnavailable
+	}
+
+	return nil
+}
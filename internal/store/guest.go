@@ -0,0 +1,91 @@
+package store
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// guest types, children are guests under 18
+const (
+	GuestTypeAdult = "adult"
+	GuestTypeChild = "child"
+)
+
+// ReservationGuest represents a named guest of a reservation. The identity document is encrypted by the service, so
+// DocumentType and DocumentNumber are ciphertexts, and empty for guests without a document. Age is required for
+// children only.
+type ReservationGuest struct {
+	ID             int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	ReservationID  int64     `gorm:"column:reservation_id;not null;index:idx_reservation_guests_reservation_id"`
+	Position       int       `gorm:"column:position;not null;default:0"`
+	FullName       string    `gorm:"column:full_name;size:100;not null"`
+	Type           string    `gorm:"column:guest_type;size:8;not null"`
+	Age            *int      `gorm:"column:age"`
+	Nationality    string    `gorm:"column:nationality;size:2;not null"`
+	DocumentType   string    `gorm:"column:document_type;type:text"`
+	DocumentNumber string    `gorm:"column:document_number;type:text"`
+	CreatedAt      time.Time `gorm:"column:createdAt;not null"`
+}
+
+// orderGuests preloads guests in the order they were given
+func orderGuests(db *gorm.DB) *gorm.DB {
+	return db.Order("position").Order("id")
+}
+
+// replaceGuests replaces the stored guests of the reservation with res.Guests
+func replaceGuests(tx *gorm.DB, res *Reservation) error {
+	if err := tx.Where("reservation_id = ?", res.ID).Delete(&ReservationGuest{}).Error; err != nil {
+		return err
+	}
+
+	if len(res.Guests) == 0 {
+		return nil
+	}
+
+	guests := make([]ReservationGuest, len(res.Guests))
+	for i, g := range res.Guests {
+		g.ID = 0
+		g.ReservationID = res.ID
+		g.Position = i
+
+		if g.CreatedAt.IsZero() {
+			g.CreatedAt = time.Now()
+		}
+
+		guests[i] = g
+	}
+
+	if err := tx.Create(&guests).Error; err != nil {
+		return err
+	}
+
+	res.Guests = guests
+
+	return nil
+}
+
+// sameGuests reports whether the guests are the same, documents are compared by their ciphertexts so guests given
+// again are not the same
+func sameGuests(a, b []ReservationGuest) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].FullName != b[i].FullName || a[i].Type != b[i].Type || !sameAge(a[i].Age, b[i].Age) ||
+			a[i].Nationality != b[i].Nationality || a[i].DocumentType != b[i].DocumentType ||
+			a[i].DocumentNumber != b[i].DocumentNumber {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameAge(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// invoiceRetries is how many times an invoice is numbered again after another one took its number
+const invoiceRetries = 3
+
+// Invoice represents the invoice of a version of a reservation, reservations are invoiced when they are created or
+// updated. Invoices are numbered sequentially from 1, amounts are in minor units of the currency and rates are in
+// basis points, e.g. 1000 is 10%.
+type Invoice struct {
+	ID                     int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	Number                 int64     `gorm:"column:number;not null;uniqueIndex:idx_invoices_number"`
+	ReservationID          int64     `gorm:"column:reservation_id;not null;uniqueIndex:idx_invoices_reservation_version"`
+	ReservationVersion     int64     `gorm:"column:reservation_version;not null;uniqueIndex:idx_invoices_reservation_version"`
+	PNR                    string    `gorm:"column:pnr;size:16;not null"`
+	Nights                 int       `gorm:"column:nights;not null"`
+	NightlyRate            int64     `gorm:"column:nightly_rate;not null"`
+	NetAmount              int64     `gorm:"column:net_amount;not null"`
+	VATRate                int64     `gorm:"column:vat_rate;not null"`
+	VATAmount              int64     `gorm:"column:vat_amount;not null"`
+	AccommodationTaxRate   int64     `gorm:"column:accommodation_tax_rate;not null"`
+	AccommodationTaxAmount int64     `gorm:"column:accommodation_tax_amount;not null"`
+	TotalAmount            int64     `gorm:"column:total_amount;not null"`
+	Currency               string    `gorm:"column:currency;size:3;not null"`
+	IssuedAt               time.Time `gorm:"column:issued_at;not null"`
+}
+
+// IssueInvoice numbers and creates the invoice of a reservation version which has none, e.g. of reservations made
+// before invoices were issued with them. ErrConflict is returned when the version is already invoiced.
+func (s *store) IssueInvoice(ctx context.Context, inv *Invoice) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.issueInvoice(tx, inv)
+	})
+	if err != nil {
+		return s.translateError("IssueInvoice", err)
+	}
+
+	return nil
+}
+
+// issueInvoice numbers and creates the invoice with tx, the invoice is issued in the transaction of the change it
+// prices. The last number is read with a lock, numbers taken by concurrent transactions the lock does not hold back
+// are numbered again, a conflict on the reservation version is not.
+func (s *store) issueInvoice(tx *gorm.DB, inv *Invoice) error {
+	var err error
+
+	for attempt := 0; attempt < invoiceRetries; attempt++ {
+		inv.ID = 0
+
+		err = tx.Transaction(func(tx *gorm.DB) error {
+			var last []int64
+
+			err := tx.Model(&Invoice{}).
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Order("number DESC").
+				Limit(1).
+				Pluck("number", &last).Error
+			if err != nil {
+				return err
+			}
+
+			inv.Number = 1
+			if len(last) > 0 {
+				inv.Number = last[0] + 1
+			}
+
+			return tx.Create(inv).Error
+		})
+		if !errors.Is(s.translateError("IssueInvoice", err), ErrConflict) {
+			return err
+		}
+
+		var invoiced int64
+
+		cerr := tx.Model(&Invoice{}).
+			Where("reservation_id = ? AND reservation_version = ?", inv.ReservationID, inv.ReservationVersion).
+			Count(&invoiced).Error
+		if cerr != nil {
+			return cerr
+		}
+
+		if invoiced > 0 {
+			return err
+		}
+	}
+
+	return err
+}
+
+// invoiced reports whether the last invoice of the reservation has the amounts of inv, so that a change which does
+// not change the price is not invoiced again
+func invoiced(tx *gorm.DB, inv *Invoice) (bool, error) {
+	var last []Invoice
+
+	err := tx.Where("reservation_id = ?", inv.ReservationID).Order("reservation_version DESC").Limit(1).Find(&last).Error
+	if err != nil || len(last) == 0 {
+		return false, err
+	}
+
+	l := last[0]
+
+	return l.Nights == inv.Nights && l.NightlyRate == inv.NightlyRate && l.NetAmount == inv.NetAmount &&
+		l.VATRate == inv.VATRate && l.AccommodationTaxRate == inv.AccommodationTaxRate &&
+		l.TotalAmount == inv.TotalAmount && l.Currency == inv.Currency, nil
+}
+
+// FindInvoice returns the last invoice of the reservation, which is issued when the reservation is created or its
+// price is changed
+func (s *store) FindInvoice(ctx context.Context, reservationID int64) (*Invoice, error) {
+	var inv Invoice
+
+	err := s.db.WithContext(ctx).Where("reservation_id = ?", reservationID).Order("reservation_version DESC").First(&inv).Error
+	if err != nil {
+		return nil, s.translateError("FindInvoice", err)
+	}
+
+	return &inv, nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Job represents a scheduled job shared by every instance of the service. An instance runs the job after acquiring
+// its lock, which is held by LockedBy until LockedUntil, so a job left locked by a stopped instance is run again by
+// another one when its lock expires.
+type Job struct {
+	ID          int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	Name        string     `gorm:"column:name;size:64;not null;uniqueIndex:idx_jobs_name"`
+	Schedule    string     `gorm:"column:schedule;size:64;not null"`
+	NextRunAt   time.Time  `gorm:"column:next_run_at;not null"`
+	LockedBy    *string    `gorm:"column:locked_by;size:64"`
+	LockedUntil *time.Time `gorm:"column:locked_until"`
+	LastRunAt   *time.Time `gorm:"column:last_run_at"`
+	LastError   string     `gorm:"column:last_error;type:text"`
+	CreatedAt   time.Time  `gorm:"column:createdAt;not null"`
+}
+
+// EnsureJob creates the job, or when it exists with another schedule changes its schedule and next run
+func (s *store) EnsureJob(ctx context.Context, job *Job) error {
+	err := s.translateError("EnsureJob", s.db.WithContext(ctx).Create(job).Error)
+	if !errors.Is(err, ErrConflict) {
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Model(&Job{}).
+		Where("name = ? AND schedule <> ?", job.Name, job.Schedule).
+		Updates(map[string]interface{}{
+			"schedule":    job.Schedule,
+			"next_run_at": job.NextRunAt,
+		}).Error
+
+	return s.translateError("EnsureJob", err)
+}
+
+// AcquireJob locks the job for owner until lockedUntil when its run is due and it is not locked by another instance,
+// it returns nil when the job is not acquired
+func (s *store) AcquireJob(ctx context.Context, name, owner string, now, lockedUntil time.Time) (*Job, error) {
+	db := s.db.WithContext(ctx)
+
+	result := db.Model(&Job{}).
+		Where("name = ? AND next_run_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", name, now, now).
+		Updates(map[string]interface{}{
+			"locked_by":    owner,
+			"locked_until": lockedUntil,
+		})
+	if result.Error != nil {
+		return nil, s.translateError("AcquireJob", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var job Job
+	if err := db.Where("name = ?", name).First(&job).Error; err != nil {
+		return nil, s.translateError("AcquireJob", err)
+	}
+
+	return &job, nil
+}
+
+// CompleteJob stores the last run and the next run of the job and releases its lock, ErrNotFound is returned when the
+// lock was taken over by another instance in the meantime
+func (s *store) CompleteJob(ctx context.Context, job *Job) error {
+	result := s.db.WithContext(ctx).Model(&Job{}).
+		Where("name = ? AND locked_by = ?", job.Name, job.LockedBy).
+		Updates(map[string]interface{}{
+			"next_run_at":  job.NextRunAt,
+			"last_run_at":  job.LastRunAt,
+			"last_error":   job.LastError,
+			"locked_by":    nil,
+			"locked_until": nil,
+		})
+	if result.Error != nil {
+		return s.translateError("CompleteJob", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return &Error{Kind: ErrNotFound, Op: "CompleteJob", Err: errors.New("job lock not held")}
+	}
+
+	return nil
+}
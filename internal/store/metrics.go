@@ -0,0 +1,103 @@
+package store
+
+import (
+	"errors"
+	"github.com/go-kit/kit/metrics"
+	"gorm.io/gorm"
+	"time"
+)
+
+const queryStartKey = "metrics:query_start"
+
+// compile-time proof of gorm plugin interface implementation
+var _ gorm.Plugin = (*QueryMetrics)(nil)
+
+// QueryMetrics represents gorm plugin observing query durations by operation and table
+type QueryMetrics struct {
+	duration metrics.Histogram
+}
+
+// NewQueryMetrics creates and returns query metrics plugin
+func NewQueryMetrics(duration metrics.Histogram) *QueryMetrics {
+	return &QueryMetrics{
+		duration: duration,
+	}
+}
+
+// Name returns plugin name
+func (qm *QueryMetrics) Name() string {
+	return "hotel-california:query-metrics"
+}
+
+// Initialize registers callbacks around every gorm operation
+func (qm *QueryMetrics) Initialize(db *gorm.DB) error {
+	return registerCallbacks(db, "metrics", qm.before, qm.after)
+}
+
+func (qm *QueryMetrics) before(_ string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		db.InstanceSet(queryStartKey, time.Now())
+	}
+}
+
+func (qm *QueryMetrics) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+
+		begin, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+
+		qm.duration.With(
+			"operation", operation,
+			"table", db.Statement.Table,
+			"success", successLabel(db.Error),
+		).Observe(time.Since(begin).Seconds())
+	}
+}
+
+// registerCallbacks registers before and after callbacks named by prefix around every gorm operation
+func registerCallbacks(db *gorm.DB, prefix string, before, after func(operation string) func(*gorm.DB)) error {
+	cb := db.Callback()
+
+	type register func(name string, fn func(*gorm.DB)) error
+
+	processors := []struct {
+		operation string
+		before    register
+		after     register
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, p := range processors {
+		name := prefix + ":" + p.operation
+
+		if err := p.before(name+":before", before(p.operation)); err != nil {
+			return err
+		}
+
+		if err := p.after(name+":after", after(p.operation)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func successLabel(err error) string {
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "false"
+	}
+
+	return "true"
+}
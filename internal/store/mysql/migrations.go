@@ -0,0 +1,26 @@
+package mysqlstore
+
+import (
+	"database/sql"
+	"embed"
+	"hotel-california-backend/internal/store"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations returns the mysql migration scripts embedded in the binary
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic(err)
+	}
+
+	return sub
+}
+
+// OpenDB opens and returns a database handle for running migrations outside the store
+func OpenDB(opts store.Options) (*sql.DB, error) {
+	return sql.Open("mysql", dsn(opts))
+}
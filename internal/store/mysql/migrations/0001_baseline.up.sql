@@ -0,0 +1,29 @@
+-- baseline of the schema previously created by gorm AutoMigrate, existing tables are kept as they are
+
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    first_name LONGTEXT,
+    last_name LONGTEXT,
+    username LONGTEXT,
+    password LONGTEXT,
+    createdAt DATETIME(3) NULL,
+    is_active BOOLEAN,
+    is_deleted BOOLEAN,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS reservations (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    user_id BIGINT,
+    pnr LONGTEXT,
+    destination LONGTEXT,
+    check_in_date DATETIME(3) NULL,
+    check_out_date DATETIME(3) NULL,
+    accommodation LONGTEXT,
+    guest_count BIGINT,
+    createdAt DATETIME(3) NULL,
+    is_active BOOLEAN,
+    is_deleted BOOLEAN,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_reservations_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
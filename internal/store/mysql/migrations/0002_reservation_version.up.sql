@@ -0,0 +1,3 @@
+-- the version of a reservation is checked against If-Match on changes, existing reservations start at 1
+
+ALTER TABLE reservations ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
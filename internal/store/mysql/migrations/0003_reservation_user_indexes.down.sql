@@ -0,0 +1,7 @@
+-- mysql drops the index it created for the user foreign key once these indexes can serve it, so the foreign key is
+-- given an index of its own before they are dropped
+CREATE INDEX idx_reservations_user ON reservations (user_id);
+
+DROP INDEX idx_reservations_user_created ON reservations;
+
+DROP INDEX idx_reservations_user_check_in ON reservations;
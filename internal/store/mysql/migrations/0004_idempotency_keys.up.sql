@@ -0,0 +1,12 @@
+CREATE TABLE idempotency_keys (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    user_id BIGINT,
+    idempotency_key VARCHAR(255),
+    fingerprint VARCHAR(64),
+    response TEXT,
+    createdAt DATETIME(3) NULL,
+    expires_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_idempotency_keys_user_key (user_id, idempotency_key),
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
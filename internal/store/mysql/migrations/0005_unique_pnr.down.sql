@@ -0,0 +1,3 @@
+DROP INDEX idx_reservations_pnr ON reservations;
+
+ALTER TABLE reservations MODIFY pnr LONGTEXT;
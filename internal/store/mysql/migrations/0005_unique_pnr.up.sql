@@ -0,0 +1,10 @@
+-- pnr is shortened from LONGTEXT so that it can be indexed. Reservations sharing a pnr with an older reservation are
+-- issued R followed by their id in 7 hex digits, the oldest one keeps the pnr.
+
+UPDATE reservations
+SET pnr = CONCAT('R', LPAD(HEX(id), 7, '0'))
+WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM reservations GROUP BY pnr) AS kept);
+
+ALTER TABLE reservations MODIFY pnr VARCHAR(16) NOT NULL;
+
+CREATE UNIQUE INDEX idx_reservations_pnr ON reservations (pnr);
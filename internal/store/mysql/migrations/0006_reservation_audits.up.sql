@@ -0,0 +1,18 @@
+-- staff users can read the history of every reservation, the others only of their own
+
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'guest';
+
+CREATE TABLE reservation_audits (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    reservation_id BIGINT NOT NULL,
+    pnr VARCHAR(16) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor_user_id BIGINT NOT NULL,
+    ip_address VARCHAR(45),
+    request_id VARCHAR(128),
+    changes TEXT,
+    createdAt DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    INDEX idx_reservation_audits_pnr (pnr, id),
+    CONSTRAINT fk_reservation_audits_reservation FOREIGN KEY (reservation_id) REFERENCES reservations (id)
+);
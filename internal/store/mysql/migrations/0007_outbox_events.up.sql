@@ -0,0 +1,27 @@
+-- domain events are written in the transaction of the change and published by the relay, every publisher keeps its
+-- own delivery of an event
+
+CREATE TABLE outbox_events (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    createdAt DATETIME(3) NOT NULL,
+    published_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_outbox_events_event_id (event_id),
+    INDEX idx_outbox_events_published_at (published_at, id)
+);
+
+CREATE TABLE outbox_deliveries (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    outbox_event_id BIGINT NOT NULL,
+    publisher VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    updatedAt DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_outbox_deliveries_event_publisher (outbox_event_id, publisher)
+);
@@ -0,0 +1,42 @@
+-- webhook subscriptions of partners, the deliveries of events to them and every attempt of a delivery
+
+CREATE TABLE webhook_subscriptions (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    url VARCHAR(2048) NOT NULL,
+    event_types VARCHAR(255) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL,
+    createdAt DATETIME(3) NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    subscription_id BIGINT NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(3) NOT NULL,
+    last_status_code BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    createdAt DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_webhook_deliveries_subscription_event (subscription_id, event_id),
+    INDEX idx_webhook_deliveries_status_next_attempt (status, next_attempt_at),
+    CONSTRAINT fk_webhook_deliveries_subscription FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (id)
+);
+
+CREATE TABLE webhook_delivery_attempts (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    delivery_id BIGINT NOT NULL,
+    status_code BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    createdAt DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    INDEX idx_webhook_delivery_attempts_delivery (delivery_id),
+    CONSTRAINT fk_webhook_delivery_attempts_delivery FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries (id)
+);
@@ -0,0 +1,8 @@
+ALTER TABLE reservations
+    DROP INDEX idx_reservations_expires_at,
+    DROP INDEX idx_reservations_check_in,
+    DROP COLUMN expires_at,
+    DROP COLUMN reminded_at,
+    DROP COLUMN language;
+
+DROP TABLE jobs;
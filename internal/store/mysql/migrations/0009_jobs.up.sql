@@ -0,0 +1,22 @@
+-- scheduled jobs shared by every instance, and the reservation columns of check-in reminders and expiring holds
+
+CREATE TABLE jobs (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL,
+    schedule VARCHAR(64) NOT NULL,
+    next_run_at DATETIME(3) NOT NULL,
+    locked_by VARCHAR(64),
+    locked_until DATETIME(3),
+    last_run_at DATETIME(3),
+    last_error TEXT,
+    createdAt DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_jobs_name (name)
+);
+
+ALTER TABLE reservations
+    ADD COLUMN language VARCHAR(35) NOT NULL DEFAULT '',
+    ADD COLUMN reminded_at DATETIME(3) NULL,
+    ADD COLUMN expires_at DATETIME(3) NULL,
+    ADD INDEX idx_reservations_check_in (check_in_date),
+    ADD INDEX idx_reservations_expires_at (expires_at);
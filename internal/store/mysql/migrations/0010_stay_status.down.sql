@@ -0,0 +1,6 @@
+ALTER TABLE reservations
+    DROP INDEX idx_reservations_check_out,
+    DROP COLUMN penalty_currency,
+    DROP COLUMN penalty_amount,
+    DROP COLUMN checked_in_at,
+    DROP COLUMN stay_status;
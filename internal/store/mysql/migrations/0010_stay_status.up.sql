@@ -0,0 +1,17 @@
+-- the progress of stays, reservations are checked in, completed after check-out or marked as no-shows
+
+ALTER TABLE reservations
+    ADD COLUMN stay_status VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN checked_in_at DATETIME(3) NULL,
+    ADD COLUMN penalty_amount BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN penalty_currency VARCHAR(3) NOT NULL DEFAULT '',
+    ADD INDEX idx_reservations_check_out (check_out_date);
+
+-- reservations made before stays were tracked are taken as stayed, so that the stay job does not mark them as no-shows
+UPDATE reservations
+SET stay_status = 'completed', is_active = FALSE
+WHERE is_active = TRUE AND check_out_date <= UTC_TIMESTAMP(3);
+
+UPDATE reservations
+SET stay_status = 'checked_in', checked_in_at = check_in_date
+WHERE is_active = TRUE AND check_in_date <= UTC_TIMESTAMP(3);
@@ -0,0 +1,13 @@
+-- calendar subscription tokens, only a hash of a token is stored
+
+CREATE TABLE calendar_tokens (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    language VARCHAR(35) NOT NULL DEFAULT '',
+    createdAt DATETIME(3) NOT NULL,
+    revoked_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_calendar_tokens_token_hash (token_hash),
+    INDEX idx_calendar_tokens_user_id (user_id)
+);
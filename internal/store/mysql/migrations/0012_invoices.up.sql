@@ -0,0 +1,22 @@
+-- invoices of reservations, numbered sequentially. Amounts are in minor units of the currency and rates in basis points.
+
+CREATE TABLE invoices (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    number BIGINT NOT NULL,
+    reservation_id BIGINT NOT NULL,
+    reservation_version BIGINT NOT NULL,
+    pnr VARCHAR(16) NOT NULL,
+    nights INT NOT NULL,
+    nightly_rate BIGINT NOT NULL,
+    net_amount BIGINT NOT NULL,
+    vat_rate BIGINT NOT NULL,
+    vat_amount BIGINT NOT NULL,
+    accommodation_tax_rate BIGINT NOT NULL,
+    accommodation_tax_amount BIGINT NOT NULL,
+    total_amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    issued_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_invoices_number (number),
+    UNIQUE INDEX idx_invoices_reservation_version (reservation_id, reservation_version)
+);
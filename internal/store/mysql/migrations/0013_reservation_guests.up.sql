@@ -0,0 +1,16 @@
+-- named guests of reservations, identity documents are encrypted by the service before they are stored
+
+CREATE TABLE reservation_guests (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    reservation_id BIGINT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    full_name VARCHAR(100) NOT NULL,
+    guest_type VARCHAR(8) NOT NULL,
+    age INT NULL,
+    nationality VARCHAR(2) NOT NULL,
+    document_type TEXT NULL,
+    document_number TEXT NULL,
+    createdAt DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    INDEX idx_reservation_guests_reservation_id (reservation_id)
+);
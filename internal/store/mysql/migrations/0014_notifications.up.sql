@@ -0,0 +1,18 @@
+-- emails sent for events, recorded with the rendered message before an email is queued so that an event published
+-- again is not emailed twice and an email not sent yet is sent after a restart
+
+CREATE TABLE notifications (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    sent_at DATETIME(3),
+    next_attempt_at DATETIME(3) NOT NULL,
+    createdAt DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_notifications_event_id (event_id),
+    INDEX idx_notifications_created_at (createdAt),
+    INDEX idx_notifications_sent_next_attempt (sent_at, next_attempt_at)
+);
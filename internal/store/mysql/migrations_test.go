@@ -0,0 +1,18 @@
+package mysqlstore
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hotel-california-backend/internal/migrate"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := migrate.Load(Migrations())
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for _, mg := range migrations {
+		assert.NotEmpty(t, mg.Down, "%d_%s has no down script", mg.Version, mg.Name)
+	}
+}
@@ -0,0 +1,23 @@
+package mysqlstore
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hotel-california-backend/internal/store"
+	"testing"
+	"time"
+)
+
+func TestDSN(t *testing.T) {
+	got := dsn(store.Options{
+		URI:            "db.internal",
+		Port:           "3306",
+		Database:       "hotel",
+		UserName:       "hotel",
+		Password:       "p@ss",
+		ConnectTimeout: 10,
+		ReadTimeout:    30 * time.Second,
+		WriteTimeout:   15 * time.Second,
+	})
+
+	assert.Equal(t, "hotel:p@ss@tcp(db.internal:3306)/hotel?parseTime=true&readTimeout=30s&timeout=10s&writeTimeout=15s", got)
+}
@@ -0,0 +1,57 @@
+package mysqlstore_test
+
+import (
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	gormmysql "gorm.io/driver/mysql"
+	"hotel-california-backend/internal/migrate"
+	"hotel-california-backend/internal/store"
+	mysqlstore "hotel-california-backend/internal/store/mysql"
+	"hotel-california-backend/internal/store/storetest"
+	"net"
+	"os"
+	"testing"
+)
+
+// testDSNEnv names the dsn of a mysql database the tests may wipe, e.g. user:pass@tcp(localhost:3306)/test
+const testDSNEnv = "STORE_TEST_MYSQL_DSN"
+
+func TestStore(t *testing.T) {
+	raw := os.Getenv(testDSNEnv)
+	if raw == "" {
+		t.Skipf("%s is not set, the mysql store suite is skipped", testDSNEnv)
+	}
+
+	cfg, err := mysql.ParseDSN(raw)
+	require.NoError(t, err)
+
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	require.NoError(t, err)
+
+	opts := store.Options{
+		URI:      host,
+		Port:     port,
+		Database: cfg.DBName,
+		UserName: cfg.User,
+		Password: cfg.Passwd,
+	}
+
+	storetest.Run(t, func(t *testing.T) storetest.Database {
+		db, err := mysqlstore.OpenDB(opts)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+
+		return storetest.Database{
+			DB:         db,
+			Migrations: mysqlstore.Migrations(),
+			Dialect:    migrate.MySQL,
+			Gorm:       gormmysql.New(gormmysql.Config{Conn: db}),
+			NewStore: func() (store.Store, error) {
+				return mysqlstore.NewStore(opts)
+			},
+		}
+	})
+}
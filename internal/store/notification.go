@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// Notification represents the email sent for an event, it is recorded with the rendered message before the email is
+// queued so that an event published again is not emailed twice and an email not sent yet survives a restart. SentAt
+// is nil until the email is sent, NextAttemptAt is when another instance may claim it.
+type Notification struct {
+	ID            int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	EventID       string     `gorm:"column:event_id;size:36;not null;uniqueIndex:idx_notifications_event_id"`
+	EventType     string     `gorm:"column:event_type;size:64;not null"`
+	Recipient     string     `gorm:"column:recipient;size:255;not null"`
+	Subject       string     `gorm:"column:subject;type:text;not null"`
+	Body          string     `gorm:"column:body;type:text;not null"`
+	SentAt        *time.Time `gorm:"column:sent_at"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null"`
+	CreatedAt     time.Time  `gorm:"column:createdAt;not null;index:idx_notifications_created_at"`
+}
+
+// CreateNotification records the notification, ErrConflict is returned when the event was already notified of
+func (s *store) CreateNotification(ctx context.Context, n *Notification) error {
+	return s.translateError("CreateNotification", s.db.WithContext(ctx).Create(n).Error)
+}
+
+// DueNotifications returns at most limit notifications not sent yet whose next attempt is due
+func (s *store) DueNotifications(ctx context.Context, now time.Time, limit int) ([]*Notification, error) {
+	var ns []*Notification
+
+	err := s.db.WithContext(ctx).
+		Where("sent_at IS NULL AND next_attempt_at <= ?", now).
+		Order("next_attempt_at").
+		Order("id").
+		Limit(limit).
+		Find(&ns).Error
+	if err != nil {
+		return nil, s.translateError("DueNotifications", err)
+	}
+
+	return ns, nil
+}
+
+// ClaimNotification postpones the next attempt of the notification to until, so that other instances do not send it
+// at the same time. It reports false when another instance claimed the notification first.
+func (s *store) ClaimNotification(ctx context.Context, n *Notification, until time.Time) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&Notification{}).
+		Where("id = ? AND sent_at IS NULL AND next_attempt_at = ?", n.ID, n.NextAttemptAt).
+		Update("next_attempt_at", until)
+	if result.Error != nil {
+		return false, s.translateError("ClaimNotification", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	n.NextAttemptAt = until
+
+	return true, nil
+}
+
+// MarkNotificationSent records that the email of the notification was sent
+func (s *store) MarkNotificationSent(ctx context.Context, id int64, at time.Time) error {
+	err := s.db.WithContext(ctx).Model(&Notification{}).Where("id = ?", id).Update("sent_at", at).Error
+
+	return s.translateError("MarkNotificationSent", err)
+}
+
+// DeleteNotifications deletes at most limit notifications recorded before the time and returns how many were deleted
+func (s *store) DeleteNotifications(ctx context.Context, before time.Time, limit int) (int64, error) {
+	db := s.db.WithContext(ctx)
+
+	// the ids are read first since not every database supports a limit on delete, the column is quoted for postgres
+	createdBefore := clause.Lt{Column: clause.Column{Name: "createdAt"}, Value: before}
+
+	var ids []int64
+	if err := db.Model(&Notification{}).Where(createdBefore).Order("id").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, s.translateError("DeleteNotifications", err)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := db.Where("id IN ?", ids).Delete(&Notification{})
+	if result.Error != nil {
+		return 0, s.translateError("DeleteNotifications", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
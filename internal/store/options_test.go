@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestConnect_RetriesWithBackoff(t *testing.T) {
+	opts := Options{
+		ConnectRetries:      2,
+		ConnectRetryBackoff: 10 * time.Millisecond,
+	}
+
+	db, err := sql.Open("mysql", "tcp(127.0.0.1:1)/?timeout=1s")
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	begin := time.Now()
+	err = connect(context.Background(), db, opts)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+	assert.GreaterOrEqual(t, time.Since(begin), 30*time.Millisecond)
+}
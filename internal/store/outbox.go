@@ -0,0 +1,254 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"hotel-california-backend/internal/events"
+	"time"
+)
+
+// event types of audit actions
+var auditEventTypes = map[string]string{
+	AuditActionCreate:   events.ReservationCreated,
+	AuditActionUpdate:   events.ReservationUpdated,
+	AuditActionCancel:   events.ReservationCancelled,
+	AuditActionExpire:   events.ReservationCancelled,
+	AuditActionConfirm:  events.ReservationConfirmed,
+	AuditActionCheckIn:  events.ReservationCheckedIn,
+	AuditActionComplete: events.ReservationCompleted,
+	AuditActionNoShow:   events.ReservationNoShow,
+}
+
+// outbox delivery statuses, a failing delivery stays pending until it is dead after the last attempt
+const (
+	OutboxDeliveryPending   = "pending"
+	OutboxDeliveryPublished = "published"
+	OutboxDeliveryDead      = "dead"
+)
+
+// OutboxEvent represents a domain event waiting to be published, it is written in the same transaction as the change
+// it describes. Payload is the json encoded events.Event. The event is published once every publisher is done with
+// it, Deliveries are the deliveries of the publishers which have attempted it.
+type OutboxEvent struct {
+	ID          int64             `gorm:"column:id;primaryKey;autoIncrement"`
+	EventID     string            `gorm:"column:event_id;size:36;not null;uniqueIndex:idx_outbox_events_event_id"`
+	EventType   string            `gorm:"column:event_type;size:64;not null"`
+	AggregateID string            `gorm:"column:aggregate_id;size:64;not null"`
+	Payload     string            `gorm:"column:payload;type:text;not null"`
+	CreatedAt   time.Time         `gorm:"column:createdAt;not null"`
+	PublishedAt *time.Time        `gorm:"column:published_at;index:idx_outbox_events_published_at,priority:1"`
+	Deliveries  []*OutboxDelivery `gorm:"foreignKey:OutboxEventID"`
+}
+
+// OutboxDelivery represents the delivery of an outbox event through one of the publishers, the publishers relay
+// events on their own so that an event failing on one of them is not published again through the others
+type OutboxDelivery struct {
+	ID            int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	OutboxEventID int64     `gorm:"column:outbox_event_id;not null;uniqueIndex:idx_outbox_deliveries_event_publisher,priority:1"`
+	Publisher     string    `gorm:"column:publisher;size:32;not null;uniqueIndex:idx_outbox_deliveries_event_publisher,priority:2"`
+	Status        string    `gorm:"column:status;size:16;not null"`
+	Attempts      int       `gorm:"column:attempts;not null;default:0"`
+	LastError     string    `gorm:"column:last_error;type:text"`
+	UpdatedAt     time.Time `gorm:"column:updatedAt;not null"`
+}
+
+// Delivery returns the delivery of the event through the publisher, nil when the publisher has not attempted it
+func (o *OutboxEvent) Delivery(publisher string) *OutboxDelivery {
+	for _, d := range o.Deliveries {
+		if d.Publisher == publisher {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// Delivered reports whether the publisher is done with the event, it is done once the event is published or dead
+func (o *OutboxEvent) Delivered(publisher string) bool {
+	d := o.Delivery(publisher)
+
+	return d != nil && d.Status != OutboxDeliveryPending
+}
+
+// Event returns the domain event of the outbox entry
+func (o *OutboxEvent) Event() (events.Event, error) {
+	var e events.Event
+	err := json.Unmarshal([]byte(o.Payload), &e)
+
+	return e, err
+}
+
+func newOutboxEvent(eventType string, res *Reservation, changes json.RawMessage, language string, occurredAt time.Time) (*OutboxEvent, error) {
+	data, err := json.Marshal(events.ReservationData{
+		PNR:           res.PNR,
+		UserID:        res.UserID,
+		Destination:   res.Destination,
+		CheckInDate:   res.CheckInDate.Format(auditDateLayout),
+		CheckOutDate:  res.CheckOutDate.Format(auditDateLayout),
+		Accommodation: res.Accommodation,
+		GuestCount:    res.GuestCount,
+		IsActive:      res.IsActive,
+		Status:        res.Status(),
+		Version:       res.Version,
+		Language:      language,
+		Changes:       changes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e := events.Event{
+		ID:          uuid.NewString(),
+		Type:        eventType,
+		AggregateID: res.PNR,
+		OccurredAt:  occurredAt.UTC(),
+		Data:        data,
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		EventID:     e.ID,
+		EventType:   e.Type,
+		AggregateID: e.AggregateID,
+		Payload:     string(payload),
+		CreatedAt:   occurredAt,
+	}, nil
+}
+
+// recordChange writes the audit entry and the domain event of the change with tx
+func recordChange(ctx context.Context, tx *gorm.DB, action string, before, after *Reservation) error {
+	audit, err := newAudit(ctx, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Create(audit).Error; err != nil {
+		return err
+	}
+
+	// changes of system actors are told in the language the reservation was made in
+	language := actorFromContext(ctx, after.UserID).Language
+	if language == "" {
+		language = after.Language
+	}
+
+	oe, err := newOutboxEvent(auditEventTypes[action], after, json.RawMessage(audit.Changes), language, audit.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(oe).Error
+}
+
+// PendingOutboxEvents returns at most limit unpublished events in the order they were written, with their deliveries
+func (s *store) PendingOutboxEvents(ctx context.Context, limit int) ([]*OutboxEvent, error) {
+	var oes []*OutboxEvent
+
+	err := s.db.WithContext(ctx).Where("published_at IS NULL").Order("id").Limit(limit).Preload("Deliveries").Find(&oes).Error
+	if err != nil {
+		return nil, s.translateError("PendingOutboxEvents", err)
+	}
+
+	return oes, nil
+}
+
+// MarkOutboxEventPublished marks the event published, once every publisher is done with it
+func (s *store) MarkOutboxEventPublished(ctx context.Context, id int64, publishedAt time.Time) error {
+	err := s.db.WithContext(ctx).Model(&OutboxEvent{}).Where("id = ?", id).Update("published_at", publishedAt).Error
+
+	return s.translateError("MarkOutboxEventPublished", err)
+}
+
+// MarkOutboxDeliveryPublished marks the delivery of the event through the publisher published
+func (s *store) MarkOutboxDeliveryPublished(ctx context.Context, id int64, publisher string) error {
+	err := s.saveOutboxDelivery(ctx, id, publisher, func(d *OutboxDelivery) {
+		d.Status = OutboxDeliveryPublished
+	})
+
+	return s.translateError("MarkOutboxDeliveryPublished", err)
+}
+
+// MarkOutboxDeliveryFailed counts the failed attempt to publish the event through the publisher and keeps its error,
+// the delivery is dead after max attempts. The status of the delivery is returned.
+func (s *store) MarkOutboxDeliveryFailed(ctx context.Context, id int64, publisher, lastError string, maxAttempts int) (string, error) {
+	var status string
+
+	err := s.saveOutboxDelivery(ctx, id, publisher, func(d *OutboxDelivery) {
+		d.Attempts++
+		d.LastError = lastError
+
+		d.Status = OutboxDeliveryPending
+		if d.Attempts >= maxAttempts {
+			d.Status = OutboxDeliveryDead
+		}
+
+		status = d.Status
+	})
+	if err != nil {
+		return "", s.translateError("MarkOutboxDeliveryFailed", err)
+	}
+
+	return status, nil
+}
+
+// saveOutboxDelivery applies change to the delivery of the event through the publisher, creating it on the first
+// attempt
+func (s *store) saveOutboxDelivery(ctx context.Context, id int64, publisher string, change func(d *OutboxDelivery)) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ds []*OutboxDelivery
+		if err := tx.Where("outbox_event_id = ? AND publisher = ?", id, publisher).Limit(1).Find(&ds).Error; err != nil {
+			return err
+		}
+
+		d := &OutboxDelivery{OutboxEventID: id, Publisher: publisher}
+		if len(ds) > 0 {
+			d = ds[0]
+		}
+
+		change(d)
+		d.UpdatedAt = time.Now()
+
+		return tx.Save(d).Error
+	})
+}
+
+// DeletePublishedOutboxEvents deletes at most limit events published before the time with their deliveries and
+// returns how many were deleted. Events dead for a publisher are kept for inspection.
+func (s *store) DeletePublishedOutboxEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var n int64
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// the ids are read first since not every database supports a limit on delete
+		var ids []int64
+
+		err := tx.Model(&OutboxEvent{}).
+			Where("published_at < ?", before).
+			Where("NOT EXISTS (SELECT 1 FROM outbox_deliveries WHERE outbox_deliveries.outbox_event_id = outbox_events.id AND outbox_deliveries.status = ?)", OutboxDeliveryDead).
+			Order("id").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if err = tx.Where("outbox_event_id IN ?", ids).Delete(&OutboxDelivery{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("id IN ?", ids).Delete(&OutboxEvent{})
+		n = result.RowsAffected
+
+		return result.Error
+	})
+	if err != nil {
+		return 0, s.translateError("DeletePublishedOutboxEvents", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// pnrAlphabet leaves out characters read alike, such as 0 and O or 1, I and L
+const pnrAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// PNRLength is the length of the generated pnrs including the check character
+const PNRLength = 8
+
+// pnrRetries is the number of pnrs generated for a reservation before a conflict is given up on
+const pnrRetries = 5
+
+// generatePNR returns a random pnr of length characters, the last one being its check character
+func generatePNR(length int) (string, error) {
+	max := big.NewInt(int64(len(pnrAlphabet)))
+
+	b := make([]byte, length-1, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		b[i] = pnrAlphabet[n.Int64()]
+	}
+
+	return string(append(b, pnrCheckChar(string(b)))), nil
+}
+
+// ValidPNR reports whether pnr is a generated pnr, made of the pnr alphabet and ending with its check character
+func ValidPNR(pnr string) bool {
+	if len(pnr) != PNRLength {
+		return false
+	}
+
+	body := pnr[:len(pnr)-1]
+	for i := 0; i < len(body); i++ {
+		if strings.IndexByte(pnrAlphabet, body[i]) < 0 {
+			return false
+		}
+	}
+
+	return pnr[len(pnr)-1] == pnrCheckChar(body)
+}
+
+// pnrCheckChar computes the position weighted sum of body modulo the alphabet size, which is prime, so any single
+// mistyped character and any swap of adjacent characters changes the check character
+func pnrCheckChar(body string) byte {
+	sum := 0
+	for i := 0; i < len(body); i++ {
+		sum += (i + 1) * strings.IndexByte(pnrAlphabet, body[i])
+	}
+
+	return pnrAlphabet[sum%len(pnrAlphabet)]
+}
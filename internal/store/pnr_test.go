@@ -0,0 +1,46 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePNR(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		pnr, err := generatePNR(PNRLength)
+		require.NoError(t, err)
+
+		assert.Len(t, pnr, PNRLength)
+		assert.False(t, strings.ContainsAny(pnr, "0O1lI"))
+		assert.True(t, ValidPNR(pnr))
+	}
+}
+
+func TestValidPNR_DetectsTypos(t *testing.T) {
+	pnr, err := generatePNR(PNRLength)
+	require.NoError(t, err)
+
+	for i := 0; i < len(pnr); i++ {
+		for j := 0; j < len(pnrAlphabet); j++ {
+			if pnrAlphabet[j] == pnr[i] {
+				continue
+			}
+
+			typo := pnr[:i] + string(pnrAlphabet[j]) + pnr[i+1:]
+			assert.False(t, ValidPNR(typo), typo)
+		}
+	}
+
+	for i := 0; i+2 < len(pnr); i++ {
+		if pnr[i] == pnr[i+1] {
+			continue
+		}
+
+		swap := pnr[:i] + string(pnr[i+1]) + string(pnr[i]) + pnr[i+2:]
+		assert.False(t, ValidPNR(swap), swap)
+	}
+
+	assert.False(t, ValidPNR("pE5TYsDj"))
+}
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL NOT NULL,
+    first_name TEXT,
+    last_name TEXT,
+    username TEXT,
+    password TEXT,
+    "createdAt" TIMESTAMPTZ,
+    is_active BOOLEAN,
+    is_deleted BOOLEAN,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS reservations (
+    id BIGSERIAL NOT NULL,
+    user_id BIGINT,
+    pnr TEXT NOT NULL,
+    destination TEXT,
+    check_in_date TIMESTAMPTZ,
+    check_out_date TIMESTAMPTZ,
+    accommodation TEXT,
+    guest_count BIGINT,
+    "createdAt" TIMESTAMPTZ,
+    is_active BOOLEAN,
+    is_deleted BOOLEAN,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_reservations_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
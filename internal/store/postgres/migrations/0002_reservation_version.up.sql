@@ -0,0 +1 @@
+ALTER TABLE reservations ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
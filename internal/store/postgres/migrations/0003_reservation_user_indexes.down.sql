@@ -0,0 +1,3 @@
+DROP INDEX idx_reservations_user_created;
+
+DROP INDEX idx_reservations_user_check_in;
@@ -0,0 +1,3 @@
+CREATE INDEX idx_reservations_user_check_in ON reservations (user_id, check_in_date);
+
+CREATE INDEX idx_reservations_user_created ON reservations (user_id, "createdAt");
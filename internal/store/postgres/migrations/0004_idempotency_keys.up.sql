@@ -0,0 +1,14 @@
+CREATE TABLE idempotency_keys (
+    id BIGSERIAL NOT NULL,
+    user_id BIGINT,
+    idempotency_key VARCHAR(255),
+    fingerprint VARCHAR(64),
+    response TEXT,
+    "createdAt" TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX idx_idempotency_keys_user_key ON idempotency_keys (user_id, idempotency_key);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
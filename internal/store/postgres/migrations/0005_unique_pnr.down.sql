@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_reservations_pnr;
+
+ALTER TABLE reservations ALTER COLUMN pnr TYPE TEXT;
@@ -0,0 +1,7 @@
+UPDATE reservations
+SET pnr = 'R' || LPAD(UPPER(TO_HEX(id)), 7, '0')
+WHERE id NOT IN (SELECT MIN(id) FROM reservations GROUP BY pnr);
+
+ALTER TABLE reservations ALTER COLUMN pnr TYPE VARCHAR(16);
+
+CREATE UNIQUE INDEX idx_reservations_pnr ON reservations (pnr);
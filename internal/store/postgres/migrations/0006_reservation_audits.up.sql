@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'guest';
+
+CREATE TABLE reservation_audits (
+    id BIGSERIAL NOT NULL,
+    reservation_id BIGINT NOT NULL,
+    pnr VARCHAR(16) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor_user_id BIGINT NOT NULL,
+    ip_address VARCHAR(45),
+    request_id VARCHAR(128),
+    changes TEXT,
+    "createdAt" TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_reservation_audits_reservation FOREIGN KEY (reservation_id) REFERENCES reservations (id)
+);
+
+CREATE INDEX idx_reservation_audits_pnr ON reservation_audits (pnr, id);
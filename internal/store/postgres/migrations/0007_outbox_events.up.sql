@@ -0,0 +1,27 @@
+CREATE TABLE outbox_events (
+    id BIGSERIAL NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    "createdAt" TIMESTAMPTZ NOT NULL,
+    published_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX idx_outbox_events_event_id ON outbox_events (event_id);
+
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at, id);
+
+CREATE TABLE outbox_deliveries (
+    id BIGSERIAL NOT NULL,
+    outbox_event_id BIGINT NOT NULL,
+    publisher VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    "updatedAt" TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX idx_outbox_deliveries_event_publisher ON outbox_deliveries (outbox_event_id, publisher);
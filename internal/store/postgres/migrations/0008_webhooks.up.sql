@@ -0,0 +1,43 @@
+CREATE TABLE webhook_subscriptions (
+    id BIGSERIAL NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    event_types VARCHAR(255) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL,
+    "createdAt" TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL NOT NULL,
+    subscription_id BIGINT NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_status_code BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    "createdAt" TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_webhook_deliveries_subscription FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (id)
+);
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_subscription_event ON webhook_deliveries (subscription_id, event_id);
+
+CREATE INDEX idx_webhook_deliveries_status_next_attempt ON webhook_deliveries (status, next_attempt_at);
+
+CREATE TABLE webhook_delivery_attempts (
+    id BIGSERIAL NOT NULL,
+    delivery_id BIGINT NOT NULL,
+    status_code BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    "createdAt" TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_webhook_delivery_attempts_delivery FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries (id)
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts (delivery_id);
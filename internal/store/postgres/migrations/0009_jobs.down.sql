@@ -0,0 +1,10 @@
+DROP INDEX idx_reservations_expires_at;
+
+DROP INDEX idx_reservations_check_in;
+
+ALTER TABLE reservations
+    DROP COLUMN expires_at,
+    DROP COLUMN reminded_at,
+    DROP COLUMN language;
+
+DROP TABLE jobs;
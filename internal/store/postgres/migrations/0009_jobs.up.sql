@@ -0,0 +1,23 @@
+CREATE TABLE jobs (
+    id BIGSERIAL NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    schedule VARCHAR(64) NOT NULL,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    locked_by VARCHAR(64),
+    locked_until TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ,
+    last_error TEXT,
+    "createdAt" TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX idx_jobs_name ON jobs (name);
+
+ALTER TABLE reservations
+    ADD COLUMN language VARCHAR(35) NOT NULL DEFAULT '',
+    ADD COLUMN reminded_at TIMESTAMPTZ NULL,
+    ADD COLUMN expires_at TIMESTAMPTZ NULL;
+
+CREATE INDEX idx_reservations_check_in ON reservations (check_in_date);
+
+CREATE INDEX idx_reservations_expires_at ON reservations (expires_at);
@@ -0,0 +1,7 @@
+DROP INDEX idx_reservations_check_out;
+
+ALTER TABLE reservations
+    DROP COLUMN penalty_currency,
+    DROP COLUMN penalty_amount,
+    DROP COLUMN checked_in_at,
+    DROP COLUMN stay_status;
@@ -0,0 +1,16 @@
+ALTER TABLE reservations
+    ADD COLUMN stay_status VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN checked_in_at TIMESTAMPTZ NULL,
+    ADD COLUMN penalty_amount BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN penalty_currency VARCHAR(3) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_reservations_check_out ON reservations (check_out_date);
+
+-- reservations made before stays were tracked are taken as stayed, so that the stay job does not mark them as no-shows
+UPDATE reservations
+SET stay_status = 'completed', is_active = FALSE
+WHERE is_active = TRUE AND check_out_date <= CURRENT_TIMESTAMP;
+
+UPDATE reservations
+SET stay_status = 'checked_in', checked_in_at = check_in_date
+WHERE is_active = TRUE AND check_in_date <= CURRENT_TIMESTAMP;
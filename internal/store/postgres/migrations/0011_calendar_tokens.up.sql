@@ -0,0 +1,13 @@
+CREATE TABLE calendar_tokens (
+    id BIGSERIAL NOT NULL,
+    user_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    language VARCHAR(35) NOT NULL DEFAULT '',
+    "createdAt" TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX idx_calendar_tokens_token_hash ON calendar_tokens (token_hash);
+
+CREATE INDEX idx_calendar_tokens_user_id ON calendar_tokens (user_id);
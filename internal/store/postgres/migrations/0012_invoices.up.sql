@@ -0,0 +1,22 @@
+CREATE TABLE invoices (
+    id BIGSERIAL NOT NULL,
+    number BIGINT NOT NULL,
+    reservation_id BIGINT NOT NULL,
+    reservation_version BIGINT NOT NULL,
+    pnr VARCHAR(16) NOT NULL,
+    nights INT NOT NULL,
+    nightly_rate BIGINT NOT NULL,
+    net_amount BIGINT NOT NULL,
+    vat_rate BIGINT NOT NULL,
+    vat_amount BIGINT NOT NULL,
+    accommodation_tax_rate BIGINT NOT NULL,
+    accommodation_tax_amount BIGINT NOT NULL,
+    total_amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    issued_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX idx_invoices_number ON invoices (number);
+
+CREATE UNIQUE INDEX idx_invoices_reservation_version ON invoices (reservation_id, reservation_version);
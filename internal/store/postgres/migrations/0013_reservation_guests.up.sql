@@ -0,0 +1,15 @@
+CREATE TABLE reservation_guests (
+    id BIGSERIAL NOT NULL,
+    reservation_id BIGINT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    full_name VARCHAR(100) NOT NULL,
+    guest_type VARCHAR(8) NOT NULL,
+    age INTEGER NULL,
+    nationality VARCHAR(2) NOT NULL,
+    document_type TEXT NULL,
+    document_number TEXT NULL,
+    "createdAt" TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_reservation_guests_reservation_id ON reservation_guests (reservation_id);
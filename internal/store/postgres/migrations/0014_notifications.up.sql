@@ -0,0 +1,18 @@
+CREATE TABLE notifications (
+    id BIGSERIAL NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    sent_at TIMESTAMPTZ,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    "createdAt" TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX idx_notifications_event_id ON notifications (event_id);
+
+CREATE INDEX idx_notifications_created_at ON notifications ("createdAt");
+
+CREATE INDEX idx_notifications_sent_next_attempt ON notifications (sent_at, next_attempt_at);
@@ -0,0 +1,158 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"hotel-california-backend/internal/migrate"
+	"hotel-california-backend/internal/store"
+	"io/fs"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// ssl modes
+var sslModes = map[string]struct{}{
+	"disable":     {},
+	"allow":       {},
+	"prefer":      {},
+	"require":     {},
+	"verify-ca":   {},
+	"verify-full": {},
+}
+
+// ErrInvalidSSLMode is returned for ssl modes postgres does not know
+var ErrInvalidSSLMode = errors.New("invalid postgres ssl mode")
+
+// postgres error classes and codes mapped to error kinds, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+var (
+	postgresErrorClassKinds = map[string]error{
+		"08": store.ErrUnavailable,         // connection exception
+		"23": store.ErrConstraintViolation, // integrity constraint violation
+		"53": store.ErrUnavailable,         // insufficient resources
+		"57": store.ErrUnavailable,         // operator intervention
+		"58": store.ErrUnavailable,         // system error
+	}
+	postgresErrorKinds = map[string]error{
+		"23505": store.ErrConflict,            // unique violation
+		"22001": store.ErrConstraintViolation, // string data right truncation
+		"22003": store.ErrConstraintViolation, // numeric value out of range
+		"22007": store.ErrConstraintViolation, // invalid datetime format
+		"22008": store.ErrConstraintViolation, // datetime field overflow
+		"25006": store.ErrUnavailable,         // read only sql transaction
+		"28000": store.ErrUnavailable,         // invalid authorization specification
+		"28P01": store.ErrUnavailable,         // invalid password
+		"3D000": store.ErrUnavailable,         // invalid catalog name
+		"40001": store.ErrUnavailable,         // serialization failure
+		"40P01": store.ErrUnavailable,         // deadlock detected
+		"55P03": store.ErrUnavailable,         // lock not available
+	}
+)
+
+// Dialect is the dialect of postgres databases
+var Dialect = store.Dialect{
+	Migrations: Migrations(),
+	Migrate:    migrate.Postgres,
+	ErrorKind:  errorKind,
+}
+
+// NewStore creates and returns store on the postgres database, URI is the host and SSLMode one of postgres' ssl modes
+func NewStore(opts store.Options) (store.Store, error) {
+	dsn, err := DSN(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var replicas []gorm.Dialector
+	for _, o := range opts.ReplicaOptions() {
+		replicaDSN, err := DSN(o)
+		if err != nil {
+			return nil, err
+		}
+
+		replicas = append(replicas, postgres.Open(replicaDSN))
+	}
+
+	return store.NewStore(postgres.Open(dsn), Dialect, opts, replicas...)
+}
+
+// Migrations returns the postgres migration scripts embedded in the binary
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic(err)
+	}
+
+	return sub
+}
+
+// OpenDB opens and returns a database handle for running migrations outside the store
+func OpenDB(opts store.Options) (*sql.DB, error) {
+	dsn, err := DSN(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.Open("pgx", dsn)
+}
+
+// DSN builds and returns the connection url of the options, ssl mode defaults to prefer
+func DSN(opts store.Options) (string, error) {
+	sslMode := opts.SSLMode
+	if sslMode == "" {
+		sslMode = "prefer"
+	}
+
+	if _, ok := sslModes[sslMode]; !ok {
+		return "", fmt.Errorf("%w, %q", ErrInvalidSSLMode, sslMode)
+	}
+
+	q := url.Values{}
+	q.Set("sslmode", sslMode)
+
+	if opts.ConnectTimeout > 0 {
+		q.Set("connect_timeout", strconv.Itoa(opts.ConnectTimeout))
+	}
+
+	host := opts.URI
+	if opts.Port != "" {
+		host = net.JoinHostPort(opts.URI, opts.Port)
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(opts.UserName, opts.Password),
+		Host:     host,
+		Path:     "/" + opts.Database,
+		RawQuery: q.Encode(),
+	}
+
+	return u.String(), nil
+}
+
+// errorKind returns the error kind of postgres errors gorm leaves untranslated
+func errorKind(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if kind, ok := postgresErrorKinds[pgErr.Code]; ok {
+			return kind
+		}
+
+		return postgresErrorClassKinds[pgErr.Code[:2]]
+	}
+
+	if pgconn.Timeout(err) {
+		return store.ErrUnavailable
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultReplicaHealthInterval is used when Options.ReplicaHealthInterval is not set
+const defaultReplicaHealthInterval = 5 * time.Second
+
+// replica represents a read replica, reads skip it while it is unhealthy
+type replica struct {
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// replicaSet routes reads to healthy replicas in turn. Users who have just written are read from the primary for
+// the stickiness window, so that they see their own writes despite replication lag; the window is kept per instance,
+// so records missing on a replica are read from the primary as well.
+type replicaSet struct {
+	replicas   []*replica
+	next       atomic.Uint64
+	stickiness time.Duration
+	writes     sync.Map
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// ReplicaOptions returns the options of every replica in opts.Replicas, a replica is a host or host:port and
+// shares the primary's credentials, database and timeouts
+func (opts Options) ReplicaOptions() []Options {
+	replicas := make([]Options, 0, len(opts.Replicas))
+
+	for _, addr := range opts.Replicas {
+		o := opts
+		o.Replicas = nil
+
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			o.URI, o.Port = host, port
+		} else {
+			o.URI = addr
+		}
+
+		replicas = append(replicas, o)
+	}
+
+	return replicas
+}
+
+func openReplicas(dialectors []gorm.Dialector, newConfig func() *gorm.Config, opts Options) (*replicaSet, error) {
+	rs := &replicaSet{
+		stickiness: opts.ReplicaStickiness,
+		stop:       make(chan struct{}),
+	}
+
+	for _, dialector := range dialectors {
+		db, err := gorm.Open(dialector, newConfig())
+		if err != nil {
+			rs.close()
+			return nil, err
+		}
+
+		r := &replica{db: db}
+		rs.replicas = append(rs.replicas, r)
+
+		for _, p := range opts.Plugins {
+			if err = db.Use(p); err != nil {
+				rs.close()
+				return nil, err
+			}
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			rs.close()
+			return nil, err
+		}
+
+		configurePool(sqlDB, opts)
+
+		// an unreachable replica does not stop the service, it is checked again by the health checker
+		r.healthy.Store(ping(context.Background(), sqlDB, opts.PingTimeout) == nil)
+	}
+
+	if len(rs.replicas) > 0 {
+		interval := opts.ReplicaHealthInterval
+		if interval <= 0 {
+			interval = defaultReplicaHealthInterval
+		}
+
+		rs.wg.Add(1)
+		go rs.checkHealth(interval, opts.PingTimeout)
+	}
+
+	return rs, nil
+}
+
+// reader returns a healthy replica for reading the user's data, nil when the primary has to be read
+func (rs *replicaSet) reader(userID int64) *replica {
+	if len(rs.replicas) == 0 || rs.recentlyWrote(userID) {
+		return nil
+	}
+
+	n := uint64(len(rs.replicas))
+	start := rs.next.Add(1)
+
+	for i := uint64(0); i < n; i++ {
+		r := rs.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// wrote records the user's write for read-your-writes routing
+func (rs *replicaSet) wrote(userID int64) {
+	if len(rs.replicas) == 0 || rs.stickiness <= 0 {
+		return
+	}
+
+	rs.writes.Store(userID, time.Now())
+}
+
+func (rs *replicaSet) recentlyWrote(userID int64) bool {
+	v, ok := rs.writes.Load(userID)
+	if !ok {
+		return false
+	}
+
+	return time.Since(v.(time.Time)) < rs.stickiness
+}
+
+func (rs *replicaSet) checkHealth(interval, timeout time.Duration) {
+	defer rs.wg.Done()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-t.C:
+		}
+
+		for _, r := range rs.replicas {
+			sqlDB, err := r.db.DB()
+			if err == nil {
+				err = ping(context.Background(), sqlDB, timeout)
+			}
+
+			r.healthy.Store(err == nil)
+		}
+
+		// forget writes older than the stickiness window
+		rs.writes.Range(func(k, v interface{}) bool {
+			if time.Since(v.(time.Time)) >= rs.stickiness {
+				rs.writes.Delete(k)
+			}
+
+			return true
+		})
+	}
+}
+
+func (rs *replicaSet) close() error {
+	close(rs.stop)
+	rs.wg.Wait()
+
+	var errs []error
+	for _, r := range rs.replicas {
+		if sqlDB, err := r.db.DB(); err == nil {
+			errs = append(errs, sqlDB.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
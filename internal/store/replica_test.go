@@ -0,0 +1,72 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReplicaOptions(t *testing.T) {
+	opts := Options{
+		URI:      "primary.internal",
+		Port:     "3306",
+		Database: "hotel",
+		Replicas: []string{"replica-1.internal", "replica-2.internal:3307"},
+	}
+
+	replicas := opts.ReplicaOptions()
+
+	assert.Len(t, replicas, 2)
+	assert.Equal(t, "replica-1.internal", replicas[0].URI)
+	assert.Equal(t, "3306", replicas[0].Port)
+	assert.Equal(t, "replica-2.internal", replicas[1].URI)
+	assert.Equal(t, "3307", replicas[1].Port)
+	assert.Equal(t, "hotel", replicas[1].Database)
+	assert.Empty(t, replicas[1].Replicas)
+}
+
+func TestReplicaSet_Reader(t *testing.T) {
+	r1, r2 := &replica{}, &replica{}
+	r1.healthy.Store(true)
+	r2.healthy.Store(true)
+
+	rs := &replicaSet{
+		replicas:   []*replica{r1, r2},
+		stickiness: time.Minute,
+	}
+
+	// healthy replicas are read in turn
+	first := rs.reader(1)
+	second := rs.reader(1)
+	assert.NotNil(t, first)
+	assert.NotNil(t, second)
+	assert.NotSame(t, first, second)
+
+	// unhealthy replicas are skipped
+	r1.healthy.Store(false)
+	assert.Same(t, r2, rs.reader(1))
+	assert.Same(t, r2, rs.reader(1))
+
+	// the primary is read when no replica is healthy
+	r2.healthy.Store(false)
+	assert.Nil(t, rs.reader(1))
+
+	r2.healthy.Store(true)
+
+	// users who have just written read the primary, others keep reading replicas
+	rs.wrote(1)
+	assert.Nil(t, rs.reader(1))
+	assert.Same(t, r2, rs.reader(2))
+
+	rs.writes.Store(int64(1), time.Now().Add(-time.Hour))
+	assert.Same(t, r2, rs.reader(1))
+}
+
+func TestReplicaSet_ReaderWithoutReplicas(t *testing.T) {
+	rs := &replicaSet{stickiness: time.Minute}
+
+	rs.wrote(1)
+
+	assert.Nil(t, rs.reader(1))
+	assert.Nil(t, rs.reader(2))
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reservations;
+
+DROP TABLE IF EXISTS users;
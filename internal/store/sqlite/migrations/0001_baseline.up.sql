@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT,
+    last_name TEXT,
+    username TEXT,
+    password TEXT,
+    createdAt DATETIME,
+    is_active NUMERIC,
+    is_deleted NUMERIC
+);
+
+CREATE TABLE IF NOT EXISTS reservations (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER REFERENCES users (id),
+    pnr TEXT NOT NULL,
+    destination TEXT,
+    check_in_date DATETIME,
+    check_out_date DATETIME,
+    accommodation TEXT,
+    guest_count INTEGER,
+    createdAt DATETIME,
+    is_active NUMERIC,
+    is_deleted NUMERIC
+);
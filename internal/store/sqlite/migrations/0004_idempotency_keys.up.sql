@@ -0,0 +1,13 @@
+CREATE TABLE idempotency_keys (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER,
+    idempotency_key VARCHAR(255),
+    fingerprint VARCHAR(64),
+    response TEXT,
+    createdAt DATETIME,
+    expires_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_idempotency_keys_user_key ON idempotency_keys (user_id, idempotency_key);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1 @@
+DROP INDEX idx_reservations_pnr;
@@ -0,0 +1,5 @@
+UPDATE reservations
+SET pnr = 'R' || printf('%07X', id)
+WHERE id NOT IN (SELECT MIN(id) FROM reservations GROUP BY pnr);
+
+CREATE UNIQUE INDEX idx_reservations_pnr ON reservations (pnr);
@@ -0,0 +1,3 @@
+DROP TABLE reservation_audits;
+
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'guest';
+
+CREATE TABLE reservation_audits (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    reservation_id INTEGER NOT NULL REFERENCES reservations (id),
+    pnr VARCHAR(16) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor_user_id INTEGER NOT NULL,
+    ip_address VARCHAR(45),
+    request_id VARCHAR(128),
+    changes TEXT,
+    createdAt DATETIME NOT NULL
+);
+
+CREATE INDEX idx_reservation_audits_pnr ON reservation_audits (pnr, id);
@@ -0,0 +1,25 @@
+CREATE TABLE outbox_events (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    createdAt DATETIME NOT NULL,
+    published_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_outbox_events_event_id ON outbox_events (event_id);
+
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at, id);
+
+CREATE TABLE outbox_deliveries (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    outbox_event_id INTEGER NOT NULL,
+    publisher VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    updatedAt DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_outbox_deliveries_event_publisher ON outbox_deliveries (outbox_event_id, publisher);
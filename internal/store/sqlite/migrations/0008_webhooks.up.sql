@@ -0,0 +1,38 @@
+CREATE TABLE webhook_subscriptions (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    url VARCHAR(2048) NOT NULL,
+    event_types VARCHAR(255) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    user_id INTEGER NOT NULL DEFAULT 0,
+    is_active NUMERIC NOT NULL,
+    createdAt DATETIME NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions (id),
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    createdAt DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_subscription_event ON webhook_deliveries (subscription_id, event_id);
+
+CREATE INDEX idx_webhook_deliveries_status_next_attempt ON webhook_deliveries (status, next_attempt_at);
+
+CREATE TABLE webhook_delivery_attempts (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    delivery_id INTEGER NOT NULL REFERENCES webhook_deliveries (id),
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    createdAt DATETIME NOT NULL
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts (delivery_id);
@@ -0,0 +1,11 @@
+DROP INDEX idx_reservations_expires_at;
+
+DROP INDEX idx_reservations_check_in;
+
+ALTER TABLE reservations DROP COLUMN expires_at;
+
+ALTER TABLE reservations DROP COLUMN reminded_at;
+
+ALTER TABLE reservations DROP COLUMN language;
+
+DROP TABLE jobs;
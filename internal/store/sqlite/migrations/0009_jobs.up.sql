@@ -0,0 +1,23 @@
+CREATE TABLE jobs (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(64) NOT NULL,
+    schedule VARCHAR(64) NOT NULL,
+    next_run_at DATETIME NOT NULL,
+    locked_by VARCHAR(64),
+    locked_until DATETIME,
+    last_run_at DATETIME,
+    last_error TEXT,
+    createdAt DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_jobs_name ON jobs (name);
+
+ALTER TABLE reservations ADD COLUMN language VARCHAR(35) NOT NULL DEFAULT '';
+
+ALTER TABLE reservations ADD COLUMN reminded_at DATETIME;
+
+ALTER TABLE reservations ADD COLUMN expires_at DATETIME;
+
+CREATE INDEX idx_reservations_check_in ON reservations (check_in_date);
+
+CREATE INDEX idx_reservations_expires_at ON reservations (expires_at);
@@ -0,0 +1,9 @@
+DROP INDEX idx_reservations_check_out;
+
+ALTER TABLE reservations DROP COLUMN penalty_currency;
+
+ALTER TABLE reservations DROP COLUMN penalty_amount;
+
+ALTER TABLE reservations DROP COLUMN checked_in_at;
+
+ALTER TABLE reservations DROP COLUMN stay_status;
@@ -0,0 +1,12 @@
+CREATE TABLE calendar_tokens (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    language VARCHAR(35) NOT NULL DEFAULT '',
+    createdAt DATETIME NOT NULL,
+    revoked_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_calendar_tokens_token_hash ON calendar_tokens (token_hash);
+
+CREATE INDEX idx_calendar_tokens_user_id ON calendar_tokens (user_id);
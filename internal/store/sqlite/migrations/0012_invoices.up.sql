@@ -0,0 +1,21 @@
+CREATE TABLE invoices (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    number INTEGER NOT NULL,
+    reservation_id INTEGER NOT NULL,
+    reservation_version INTEGER NOT NULL,
+    pnr VARCHAR(16) NOT NULL,
+    nights INTEGER NOT NULL,
+    nightly_rate INTEGER NOT NULL,
+    net_amount INTEGER NOT NULL,
+    vat_rate INTEGER NOT NULL,
+    vat_amount INTEGER NOT NULL,
+    accommodation_tax_rate INTEGER NOT NULL,
+    accommodation_tax_amount INTEGER NOT NULL,
+    total_amount INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    issued_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_invoices_number ON invoices (number);
+
+CREATE UNIQUE INDEX idx_invoices_reservation_version ON invoices (reservation_id, reservation_version);
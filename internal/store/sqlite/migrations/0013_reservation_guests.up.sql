@@ -0,0 +1,14 @@
+CREATE TABLE reservation_guests (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    reservation_id INTEGER NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    full_name VARCHAR(100) NOT NULL,
+    guest_type VARCHAR(8) NOT NULL,
+    age INTEGER,
+    nationality VARCHAR(2) NOT NULL,
+    document_type TEXT,
+    document_number TEXT,
+    createdAt DATETIME NOT NULL
+);
+
+CREATE INDEX idx_reservation_guests_reservation_id ON reservation_guests (reservation_id);
@@ -0,0 +1,17 @@
+CREATE TABLE notifications (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    sent_at DATETIME,
+    next_attempt_at DATETIME NOT NULL,
+    createdAt DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_notifications_event_id ON notifications (event_id);
+
+CREATE INDEX idx_notifications_created_at ON notifications (createdAt);
+
+CREATE INDEX idx_notifications_sent_next_attempt ON notifications (sent_at, next_attempt_at);
@@ -0,0 +1,94 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/glebarez/go-sqlite"
+	gormsqlite "github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"hotel-california-backend/internal/migrate"
+	"hotel-california-backend/internal/store"
+	"io/fs"
+	sqlite3 "modernc.org/sqlite/lib"
+	"net/url"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Options represents sqlite store options
+type Options struct {
+	Path        string
+	BusyTimeout time.Duration
+	PingTimeout time.Duration
+	Plugins     []gorm.Plugin
+}
+
+// Dialect is the dialect of sqlite databases
+var Dialect = store.Dialect{
+	Migrations: Migrations(),
+	Migrate:    migrate.SQLite,
+	ErrorKind:  errorKind,
+}
+
+// NewStore creates and returns store keeping its data in the sqlite database file at opts.Path
+func NewStore(opts Options) (store.Store, error) {
+	return store.NewStore(gormsqlite.Open(dsn(opts)), Dialect, store.Options{
+		PingTimeout: opts.PingTimeout,
+		Plugins:     opts.Plugins,
+	})
+}
+
+// Migrations returns the sqlite migration scripts embedded in the binary
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic(err)
+	}
+
+	return sub
+}
+
+// OpenDB opens and returns a database handle for running migrations outside the store
+func OpenDB(opts Options) (*sql.DB, error) {
+	return sql.Open(gormsqlite.DriverName, dsn(opts))
+}
+
+// dsn enables foreign keys, waits for locks instead of failing and starts transactions as writers so that
+// concurrent transactions do not fail upgrading their read lock
+func dsn(opts Options) string {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", opts.BusyTimeout.Milliseconds()))
+	q.Set("_txlock", "immediate")
+	q.Set("_time_format", "sqlite")
+
+	return opts.Path + "?" + q.Encode()
+}
+
+// errorKind returns the error kind of sqlite errors gorm leaves untranslated
+func errorKind(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return nil
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return store.ErrConflict
+	}
+
+	// extended result codes keep the primary result code in their low byte
+	switch sqliteErr.Code() & 0xff {
+	case sqlite3.SQLITE_CONSTRAINT:
+		return store.ErrConstraintViolation
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED, sqlite3.SQLITE_READONLY, sqlite3.SQLITE_FULL, sqlite3.SQLITE_CANTOPEN:
+		return store.ErrUnavailable
+	}
+
+	return nil
+}
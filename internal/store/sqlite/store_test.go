@@ -0,0 +1,80 @@
+package sqlitestore
+
+import (
+	"context"
+	gormsqlite "github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hotel-california-backend/internal/migrate"
+	"hotel-california-backend/internal/store"
+	"hotel-california-backend/internal/store/storetest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Database {
+		opts := Options{
+			Path:        filepath.Join(t.TempDir(), "hotel-california.db"),
+			BusyTimeout: time.Second,
+		}
+
+		db, err := OpenDB(opts)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+
+		return storetest.Database{
+			DB:         db,
+			Migrations: Migrations(),
+			Dialect:    migrate.SQLite,
+			Gorm:       gormsqlite.Dialector{Conn: db},
+			NewStore: func() (store.Store, error) {
+				return NewStore(opts)
+			},
+		}
+	})
+}
+
+func TestStore_ReadsRecordsMissingOnReplicaFromPrimary(t *testing.T) {
+	ctx := context.Background()
+
+	primary := Options{Path: filepath.Join(t.TempDir(), "primary.db"), BusyTimeout: time.Second}
+	replica := Options{Path: filepath.Join(t.TempDir(), "replica.db"), BusyTimeout: time.Second}
+
+	for _, opts := range []Options{primary, replica} {
+		db, err := OpenDB(opts)
+		require.NoError(t, err)
+
+		_, err = migrate.NewMigrator(db, Migrations(), migrate.SQLite).Up(ctx)
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+	}
+
+	db, err := OpenDB(primary)
+	require.NoError(t, err)
+
+	// the reservation is not replicated yet
+	_, err = db.Exec("INSERT INTO users (first_name, last_name, username, password, is_active, is_deleted) VALUES ('John', 'Doe', 'john@doe.com', 'x', TRUE, FALSE)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO reservations (user_id, pnr, destination, check_in_date, check_out_date, accommodation, guest_count, createdAt, is_active) VALUES (1, 'HC7K2M8P', 'Istanbul', '2024-03-24 00:00:00', '2024-03-26 00:00:00', 'city', 2, '2024-03-01 00:00:00', TRUE)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	s, err := store.NewStore(gormsqlite.Open(dsn(primary)), Dialect, store.Options{}, gormsqlite.Open(dsn(replica)))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	reservation, err := s.FindReservation(ctx, "HC7K2M8P", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Istanbul", reservation.Destination)
+
+	_, err = s.FindReservation(ctx, "HC7K2M8Q", 1)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// noShowWindow is how long after the no-show cutoff a reservation whose guest has not checked in is marked as no-show
+const noShowWindow = 24 * time.Hour
+
+// CheckInReservation checks the guest of the reservation of res.PNR in at the time, from the check-in date until the
+// check-out date of the reservation
+func (s *store) CheckInReservation(ctx context.Context, res *Reservation, at time.Time) error {
+	err := s.changeReservation(ctx, res, AuditActionCheckIn, func(r *Reservation) error {
+		if !r.IsActive {
+			return ErrNotActive
+		}
+
+		if r.StayStatus != "" {
+			return ErrCheckedIn
+		}
+
+		if at.Before(r.CheckInDate) || !at.Before(r.CheckOutDate) {
+			return ErrNotCheckInDay
+		}
+
+		r.StayStatus = StatusCheckedIn
+		r.CheckedInAt = &at
+
+		return nil
+	})
+	if err != nil {
+		return s.translateError("CheckInReservation", err)
+	}
+
+	s.replicas.wrote(res.UserID)
+
+	return nil
+}
+
+// NoShowReservations returns at most limit active reservations checking in within a day by arrivedBy whose guests
+// have not checked in. Older reservations are not no-shows, they have been checked in without being recorded, e.g.
+// before stays were tracked, and are completed after their check-out date.
+func (s *store) NoShowReservations(ctx context.Context, arrivedBy time.Time, limit int) ([]*Reservation, error) {
+	var reservations []*Reservation
+
+	err := s.db.WithContext(ctx).
+		Where("is_active = ? AND stay_status = ? AND check_in_date <= ? AND check_in_date >= ?", true, "", arrivedBy,
+			arrivedBy.Add(-noShowWindow)).
+		Order("check_in_date").
+		Order("id").
+		Limit(limit).
+		Find(&reservations).Error
+	if err != nil {
+		return nil, s.translateError("NoShowReservations", err)
+	}
+
+	return reservations, nil
+}
+
+// MarkReservationNoShow closes the reservation of res.PNR as a no-show with the penalty, in minor units of the
+// currency, unless its guest has checked in
+func (s *store) MarkReservationNoShow(ctx context.Context, res *Reservation, penaltyAmount int64, penaltyCurrency string) error {
+	err := s.changeReservation(ctx, res, AuditActionNoShow, func(r *Reservation) error {
+		if !r.IsActive {
+			return ErrNotActive
+		}
+
+		if r.StayStatus != "" {
+			return ErrCheckedIn
+		}
+
+		r.StayStatus = StatusNoShow
+		r.IsActive = false
+
+		if penaltyAmount > 0 {
+			r.PenaltyAmount = penaltyAmount
+			r.PenaltyCurrency = penaltyCurrency
+		}
+
+		return nil
+	})
+	if err != nil {
+		return s.translateError("MarkReservationNoShow", err)
+	}
+
+	s.replicas.wrote(res.UserID)
+
+	return nil
+}
+
+// CompletableReservations returns at most limit active reservations checking out by now
+func (s *store) CompletableReservations(ctx context.Context, now time.Time, limit int) ([]*Reservation, error) {
+	var reservations []*Reservation
+
+	err := s.db.WithContext(ctx).
+		Where("is_active = ? AND check_out_date <= ?", true, now).
+		Order("check_out_date").
+		Order("id").
+		Limit(limit).
+		Find(&reservations).Error
+	if err != nil {
+		return nil, s.translateError("CompletableReservations", err)
+	}
+
+	return reservations, nil
+}
+
+// CompleteReservation closes the reservation of res.PNR as a completed stay
+func (s *store) CompleteReservation(ctx context.Context, res *Reservation) error {
+	err := s.changeReservation(ctx, res, AuditActionComplete, func(r *Reservation) error {
+		if !r.IsActive {
+			return ErrNotActive
+		}
+
+		r.StayStatus = StatusCompleted
+		r.IsActive = false
+
+		return nil
+	})
+	if err != nil {
+		return s.translateError("CompleteReservation", err)
+	}
+
+	s.replicas.wrote(res.UserID)
+
+	return nil
+}
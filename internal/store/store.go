@@ -0,0 +1,944 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"hotel-california-backend/internal/events"
+	"hotel-california-backend/internal/migrate"
+	"time"
+)
+
+type User struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	FirstName string    `gorm:"column:first_name"`
+	LastName  string    `gorm:"column:last_name"`
+	Username  string    `gorm:"column:username"`
+	Password  string    `gorm:"column:password"`
+	CreatedAt time.Time `gorm:"column:createdAt"`
+	IsActive  bool      `gorm:"column:is_active"`
+	IsDeleted bool      `gorm:"column:is_deleted"`
+	Role      string    `gorm:"column:role;size:16;not null;default:guest"`
+}
+
+// user roles, staff can read the history of every reservation
+const (
+	RoleGuest = "guest"
+	RoleStaff = "staff"
+)
+
+// reservation statuses, the statuses of stays are stored and the others follow from IsActive
+const (
+	StatusConfirmed = "confirmed"
+	StatusCancelled = "cancelled"
+	StatusCheckedIn = "checked_in"
+	StatusCompleted = "completed"
+	StatusNoShow    = "no_show"
+)
+
+// Reservation represents a reservation, Language is the language it was made in and reminders are sent in.
+// ExpiresAt is the end of the hold of a reservation which is cancelled unless it is confirmed by then, it is nil for
+// confirmed reservations and reservations made without a hold. StayStatus is empty until the guest checks in or the
+// stay is closed, the penalty of a no-show is in minor units of its currency. Guests are the named guests of the
+// reservation in order, they are only loaded by the methods returning a reservation to show. Invoice is issued with the
+// reservation when it is created or updated, it is not loaded.
+type Reservation struct {
+	ID              int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID          int64      `gorm:"column:user_id;index:idx_reservations_user_check_in,priority:1;index:idx_reservations_user_created,priority:1"`
+	User            User       `gorm:"foreignKey:UserID"`
+	PNR             string     `gorm:"column:pnr;size:16;not null;uniqueIndex:idx_reservations_pnr"`
+	Destination     string     `gorm:"column:destination"`
+	CheckInDate     time.Time  `gorm:"column:check_in_date;index:idx_reservations_user_check_in,priority:2;index:idx_reservations_check_in"`
+	CheckOutDate    time.Time  `gorm:"column:check_out_date;index:idx_reservations_check_out"`
+	Accommodation   string     `gorm:"column:accommodation"`
+	GuestCount      int        `gorm:"column:guest_count"`
+	Version         int64      `gorm:"column:version;not null;default:1"`
+	Language        string     `gorm:"column:language;size:35;not null;default:''"`
+	RemindedAt      *time.Time `gorm:"column:reminded_at"`
+	ExpiresAt       *time.Time `gorm:"column:expires_at;index:idx_reservations_expires_at"`
+	StayStatus      string     `gorm:"column:stay_status;size:16;not null;default:''"`
+	CheckedInAt     *time.Time `gorm:"column:checked_in_at"`
+	PenaltyAmount   int64      `gorm:"column:penalty_amount;not null;default:0"`
+	PenaltyCurrency string     `gorm:"column:penalty_currency;size:3;not null;default:''"`
+	CreatedAt       time.Time  `gorm:"column:createdAt;index:idx_reservations_user_created,priority:2"`
+	IsActive        bool       `gorm:"column:is_active"`
+	IsDeleted       bool       `gorm:"column:is_deleted"`
+
+	Guests  []ReservationGuest `gorm:"foreignKey:ReservationID"`
+	Invoice *Invoice           `gorm:"-"`
+}
+
+// Status returns the status of the reservation
+func (r *Reservation) Status() string {
+	switch {
+	case r.StayStatus != "":
+		return r.StayStatus
+	case !r.IsActive:
+		return StatusCancelled
+	default:
+		return StatusConfirmed
+	}
+}
+
+// reservation sort columns
+const (
+	SortByCheckInDate ReservationSort = "check_in_date"
+	SortByCreatedAt   ReservationSort = "createdAt"
+)
+
+// ReservationSort represents the column reservations are listed by
+type ReservationSort string
+
+// ReservationQuery represents filters, sorting and page of a reservation list
+type ReservationQuery struct {
+	UserID        int64
+	IsActive      *bool
+	From          time.Time
+	To            time.Time
+	Destination   string
+	Accommodation string
+	SortBy        ReservationSort
+	Descending    bool
+	After         *ReservationCursor
+	Limit         int
+}
+
+// ReservationCursor represents the position of the last listed reservation
+type ReservationCursor struct {
+	Value time.Time
+	ID    int64
+}
+
+// IdempotencyKey represents a client supplied key remembered for replaying reservation creation
+type IdempotencyKey struct {
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID      int64     `gorm:"column:user_id;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key         string    `gorm:"column:idempotency_key;size:255;uniqueIndex:idx_idempotency_keys_user_key"`
+	Fingerprint string    `gorm:"column:fingerprint;size:64"`
+	Response    string    `gorm:"column:response;type:text"`
+	CreatedAt   time.Time `gorm:"column:createdAt"`
+	ExpiresAt   time.Time `gorm:"column:expires_at;index"`
+}
+
+type Store interface {
+	SignIn(ctx context.Context, username, password string) (usr *User, err error)
+	CreateReservation(ctx context.Context, res *Reservation) error
+	UpdateReservation(ctx context.Context, res *Reservation) error
+	CancelReservation(ctx context.Context, res *Reservation) error
+	ConfirmReservation(ctx context.Context, res *Reservation) error
+	DueReservationReminders(ctx context.Context, from, to time.Time, limit int) ([]*Reservation, error)
+	RemindReservation(ctx context.Context, res *Reservation, remindedAt time.Time) (bool, error)
+	ExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*Reservation, error)
+	ExpireReservation(ctx context.Context, res *Reservation) error
+	CheckInReservation(ctx context.Context, res *Reservation, at time.Time) error
+	NoShowReservations(ctx context.Context, arrivedBy time.Time, limit int) ([]*Reservation, error)
+	MarkReservationNoShow(ctx context.Context, res *Reservation, penaltyAmount int64, penaltyCurrency string) error
+	CompletableReservations(ctx context.Context, now time.Time, limit int) ([]*Reservation, error)
+	CompleteReservation(ctx context.Context, res *Reservation) error
+	FindReservation(ctx context.Context, pnr string, userID int64) (*Reservation, error)
+	FindReservationByPNR(ctx context.Context, pnr string) (*Reservation, error)
+	FindReservationAudits(ctx context.Context, pnr string) ([]*ReservationAudit, error)
+	FindUser(ctx context.Context, id int64) (*User, error)
+	PendingOutboxEvents(ctx context.Context, limit int) ([]*OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64, publishedAt time.Time) error
+	MarkOutboxDeliveryPublished(ctx context.Context, id int64, publisher string) error
+	MarkOutboxDeliveryFailed(ctx context.Context, id int64, publisher, lastError string, maxAttempts int) (string, error)
+	DeletePublishedOutboxEvents(ctx context.Context, before time.Time, limit int) (int64, error)
+	CreateWebhookSubscription(ctx context.Context, sub *WebhookSubscription) error
+	FindWebhookSubscriptions(ctx context.Context) ([]*WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
+	CreateNotification(ctx context.Context, n *Notification) error
+	DueNotifications(ctx context.Context, now time.Time, limit int) ([]*Notification, error)
+	ClaimNotification(ctx context.Context, n *Notification, until time.Time) (bool, error)
+	MarkNotificationSent(ctx context.Context, id int64, at time.Time) error
+	DeleteNotifications(ctx context.Context, before time.Time, limit int) (int64, error)
+	CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error
+	DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error)
+	ClaimWebhookDelivery(ctx context.Context, d *WebhookDelivery, until time.Time) (bool, error)
+	RecordWebhookAttempt(ctx context.Context, d *WebhookDelivery, attempt *WebhookDeliveryAttempt) error
+	FindWebhookDeliveries(ctx context.Context, q WebhookDeliveryQuery) ([]*WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, id int64, now time.Time) error
+	EnsureJob(ctx context.Context, job *Job) error
+	AcquireJob(ctx context.Context, name, owner string, now, lockedUntil time.Time) (*Job, error)
+	CompleteJob(ctx context.Context, job *Job) error
+	CreateCalendarToken(ctx context.Context, token *CalendarToken) error
+	FindCalendarToken(ctx context.Context, tokenHash string) (*CalendarToken, error)
+	RevokeCalendarTokens(ctx context.Context, userID int64, revokedAt time.Time) (int64, error)
+	IssueInvoice(ctx context.Context, inv *Invoice) error
+	FindInvoice(ctx context.Context, reservationID int64) (*Invoice, error)
+	FindReservations(ctx context.Context, q ReservationQuery) ([]*Reservation, int64, error)
+	AcquireIdempotencyKey(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, id int64, response string) error
+	ReleaseIdempotencyKey(ctx context.Context, id int64) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time, limit int) (int64, error)
+	Ping(ctx context.Context) error
+	Stats() sql.DBStats
+	Close() error
+}
+
+// store implements Store interface
+type store struct {
+	db       *gorm.DB
+	replicas *replicaSet
+	dialect  Dialect
+	opts     Options
+}
+
+// compile-time proof of interface implementation
+var _ Store = (*store)(nil)
+
+// maxConnectBackoff caps the wait between connection attempts
+const maxConnectBackoff = 30 * time.Second
+
+// ErrDisconnectTimeout is returned when queries in progress do not finish within the disconnect timeout
+var ErrDisconnectTimeout = errors.New("timed out waiting for queries to finish while disconnecting")
+
+// Options represents store options, zero values keep the driver and database/sql defaults.
+// ConnectTimeout is in seconds, ConnectRetries is how many times the first connection is retried waiting
+// ConnectRetryBackoff doubled after every attempt. Replicas are read replica hosts, see ReplicaOptions.
+type Options struct {
+	URI                   string
+	Database              string
+	UserName              string
+	Password              string
+	Port                  string
+	SSLMode               string
+	ConnectTimeout        int
+	PingTimeout           time.Duration
+	ReadTimeout           time.Duration
+	WriteTimeout          time.Duration
+	DisconnectTimeout     time.Duration
+	MaxOpenConns          int
+	MaxIdleConns          int
+	ConnMaxLifetime       time.Duration
+	ConnMaxIdleTime       time.Duration
+	ConnectRetries        int
+	ConnectRetryBackoff   time.Duration
+	Replicas              []string
+	ReplicaHealthInterval time.Duration
+	ReplicaStickiness     time.Duration
+	Plugins               []gorm.Plugin
+}
+
+// NewStore creates and returns store on the database opened by dialector, every database shares the models and queries
+// of the store. It refuses a database whose schema is not at the latest migration of the dialect, reads which can be
+// served by replicas are routed to them.
+func NewStore(dialector gorm.Dialector, dialect Dialect, opts Options, replicas ...gorm.Dialector) (Store, error) {
+	db, err := gorm.Open(dialector, newGormConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range opts.Plugins {
+		if err = db.Use(p); err != nil {
+			return nil, err
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	configurePool(sqlDB, opts)
+
+	if err = connect(context.Background(), sqlDB, opts); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+
+	if err = migrate.NewMigrator(sqlDB, dialect.Migrations, dialect.Migrate).Check(context.Background()); err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("checking schema version failed, run the migrate command, %w", err)
+	}
+
+	rs, err := openReplicas(replicas, newGormConfig, opts)
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+
+	cli := store{
+		db:       db,
+		replicas: rs,
+		dialect:  dialect,
+		opts:     opts,
+	}
+
+	return &cli, nil
+}
+
+// newGormConfig returns a new config for every database, gorm keeps registered plugins in it
+func newGormConfig() *gorm.Config {
+	return &gorm.Config{
+		Logger:               logger.Default.LogMode(logger.Silent),
+		TranslateError:       true,
+		DisableAutomaticPing: true,
+	}
+}
+
+func configurePool(db *sql.DB, opts Options) {
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+
+	if opts.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+
+	if opts.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
+}
+
+// connect pings the database until it answers, so that the service can start before its database is ready
+func connect(ctx context.Context, db *sql.DB, opts Options) error {
+	backoff := opts.ConnectRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := ping(ctx, db, opts.PingTimeout)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= opts.ConnectRetries {
+			return fmt.Errorf("connecting to database failed after %d attempts, %w", attempt+1, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+func ping(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	if timeout > 0 {
+		var cf context.CancelFunc
+		ctx, cf = context.WithTimeout(ctx, timeout)
+		defer cf()
+	}
+
+	return db.PingContext(ctx)
+}
+
+func (c *store) SignIn(ctx context.Context, username, password string) (usr *User, err error) {
+	query := "username= ? and password = ? and is_active = ? and is_deleted = ?"
+	err = c.db.WithContext(ctx).Model(&User{}).Where(query, username, password, true, false).Find(&usr).Error
+	if err != nil {
+		return nil, c.translateError("SignIn", err)
+	}
+
+	if usr.ID == 0 {
+		return nil, &Error{Kind: ErrNotFound, Op: "SignIn", Err: errors.New("user not found")}
+	}
+
+	return usr, nil
+}
+
+// FindUser returns the user
+func (s *store) FindUser(ctx context.Context, id int64) (*User, error) {
+	var usr User
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&usr).Error; err != nil {
+		return nil, s.translateError("FindUser", err)
+	}
+
+	return &usr, nil
+}
+
+// CreateReservation creates the reservation with its audit entry, event and invoice, the actor is taken from the
+// context. A pnr is generated when res.PNR is empty, and generated again when it is taken.
+func (s *store) CreateReservation(ctx context.Context, res *Reservation) error {
+	generate := res.PNR == ""
+
+	for attempt := 1; ; attempt++ {
+		if generate {
+			pnr, err := generatePNR(PNRLength)
+			if err != nil {
+				return err
+			}
+
+			res.PNR = pnr
+		}
+
+		err := s.createReservation(ctx, res)
+		if !generate || !errors.Is(err, ErrConflict) || attempt == pnrRetries {
+			return err
+		}
+
+		// invoice numbers are unique too, so the pnr is generated again only when it is the one taken
+		var taken int64
+		if cerr := s.db.WithContext(ctx).Model(&Reservation{}).Where("pnr = ?", res.PNR).Count(&taken).Error; cerr != nil {
+			return s.translateError("CreateReservation", cerr)
+		}
+
+		if taken == 0 {
+			return err
+		}
+	}
+}
+
+func (s *store) createReservation(ctx context.Context, res *Reservation) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Guests").Create(res).Error; err != nil {
+			return err
+		}
+
+		if err := replaceGuests(tx, res); err != nil {
+			return err
+		}
+
+		if err := recordChange(ctx, tx, AuditActionCreate, nil, res); err != nil {
+			return err
+		}
+
+		if res.Invoice == nil {
+			return nil
+		}
+
+		res.Invoice.ReservationID = res.ID
+		res.Invoice.ReservationVersion = res.Version
+		res.Invoice.PNR = res.PNR
+
+		return s.issueInvoice(tx, res.Invoice)
+	})
+	if err != nil {
+		// the id of the rolled back row is not kept for the next attempt
+		res.ID = 0
+
+		return s.translateError("CreateReservation", err)
+	}
+
+	s.replicas.wrote(res.UserID)
+
+	return nil
+}
+
+// UpdateReservation updates the reservation and issues res.Invoice for the new version when the price changed, and
+// when res.Version is set only if it is still the stored version. res.Version is set to the new version on success.
+func (s *store) UpdateReservation(ctx context.Context, res *Reservation) error {
+	err := s.changeReservation(ctx, res, AuditActionUpdate, func(r *Reservation) error {
+		if time.Now().After(r.CheckInDate) {
+			return ErrPastCheckIn
+		}
+
+		// a moved stay is reminded of again
+		if !r.CheckInDate.Equal(res.CheckInDate) {
+			r.RemindedAt = nil
+		}
+
+		r.Destination = res.Destination
+		r.CheckInDate = res.CheckInDate
+		r.CheckOutDate = res.CheckOutDate
+		r.Accommodation = res.Accommodation
+		r.GuestCount = res.GuestCount
+
+		// guests are kept unless they are given
+		if res.Guests != nil {
+			r.Guests = res.Guests
+		}
+
+		if len(r.Guests) > 0 && len(r.Guests) != r.GuestCount {
+			return ErrGuestCount
+		}
+
+		return nil
+	})
+	if err != nil {
+		return s.translateError("UpdateReservation", err)
+	}
+
+	s.replicas.wrote(res.UserID)
+
+	return nil
+}
+
+// CancelReservation deactivates the reservation of res.PNR, and when res.Version is set only if it is still the
+// stored version. res.Version is set to the new version on success.
+func (s *store) CancelReservation(ctx context.Context, res *Reservation) error {
+	err := s.changeReservation(ctx, res, AuditActionCancel, func(r *Reservation) error {
+		if time.Now().After(r.CheckInDate) {
+			return ErrPastCheckIn
+		}
+
+		if !r.IsActive {
+			return ErrNotActive
+		}
+
+		r.IsActive = false
+
+		return nil
+	})
+	if err != nil {
+		return s.translateError("CancelReservation", err)
+	}
+
+	s.replicas.wrote(res.UserID)
+
+	return nil
+}
+
+// DueReservationReminders returns at most limit active reservations checking in after from and until to which have
+// not been reminded of
+func (s *store) DueReservationReminders(ctx context.Context, from, to time.Time, limit int) ([]*Reservation, error) {
+	var reservations []*Reservation
+
+	err := s.db.WithContext(ctx).
+		Where("is_active = ? AND reminded_at IS NULL AND check_in_date > ? AND check_in_date <= ?", true, from, to).
+		Order("check_in_date").
+		Order("id").
+		Limit(limit).
+		Find(&reservations).Error
+	if err != nil {
+		return nil, s.translateError("DueReservationReminders", err)
+	}
+
+	return reservations, nil
+}
+
+// RemindReservation marks the reservation reminded of and writes the reminder event, it reports false when the
+// reservation was already reminded of
+func (s *store) RemindReservation(ctx context.Context, res *Reservation, remindedAt time.Time) (bool, error) {
+	reminded := false
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Reservation{}).
+			Where("id = ? AND reminded_at IS NULL", res.ID).
+			Update("reminded_at", remindedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		oe, err := newOutboxEvent(events.ReservationReminderDue, res, nil, res.Language, remindedAt)
+		if err != nil {
+			return err
+		}
+
+		reminded = true
+		res.RemindedAt = &remindedAt
+
+		return tx.Create(oe).Error
+	})
+	if err != nil {
+		return false, s.translateError("RemindReservation", err)
+	}
+
+	return reminded, nil
+}
+
+// ExpiredReservations returns at most limit active reservations whose hold ended by now and which check in later
+func (s *store) ExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*Reservation, error) {
+	var reservations []*Reservation
+
+	err := s.db.WithContext(ctx).
+		Where("is_active = ? AND expires_at <= ? AND check_in_date > ?", true, now, now).
+		Order("expires_at").
+		Order("id").
+		Limit(limit).
+		Find(&reservations).Error
+	if err != nil {
+		return nil, s.translateError("ExpiredReservations", err)
+	}
+
+	return reservations, nil
+}
+
+// ExpireReservation cancels the reservation of res.PNR if its hold has ended, ErrNotExpired is returned otherwise
+func (s *store) ExpireReservation(ctx context.Context, res *Reservation) error {
+	err := s.changeReservation(ctx, res, AuditActionExpire, func(r *Reservation) error {
+		if time.Now().After(r.CheckInDate) {
+			return ErrPastCheckIn
+		}
+
+		if !r.IsActive {
+			return ErrNotActive
+		}
+
+		if r.ExpiresAt == nil || r.ExpiresAt.After(time.Now()) {
+			return ErrNotExpired
+		}
+
+		r.IsActive = false
+
+		return nil
+	})
+	if err != nil {
+		return s.translateError("ExpireReservation", err)
+	}
+
+	s.replicas.wrote(res.UserID)
+
+	return nil
+}
+
+// ConfirmReservation releases the hold of the reservation of res.PNR so that it is kept, ErrNotHeld is returned for
+// reservations made without a hold, already confirmed or whose hold has ended
+func (s *store) ConfirmReservation(ctx context.Context, res *Reservation) error {
+	err := s.changeReservation(ctx, res, AuditActionConfirm, func(r *Reservation) error {
+		if time.Now().After(r.CheckInDate) {
+			return ErrPastCheckIn
+		}
+
+		if !r.IsActive {
+			return ErrNotActive
+		}
+
+		if r.ExpiresAt == nil || !r.ExpiresAt.After(time.Now()) {
+			return ErrNotHeld
+		}
+
+		r.ExpiresAt = nil
+
+		return nil
+	})
+	if err != nil {
+		return s.translateError("ConfirmReservation", err)
+	}
+
+	s.replicas.wrote(res.UserID)
+
+	return nil
+}
+
+// changeReservation applies change to the stored reservation of the owner and records its audit entry and event, change
+// refuses the changes which are not allowed to the reservation. res.Invoice is issued for the new version when it is
+// given and its amounts differ from the last invoice of the reservation.
+func (s *store) changeReservation(ctx context.Context, res *Reservation, action string, change func(r *Reservation) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before Reservation
+		if err := tx.Where("pnr = ?", res.PNR).Preload("Guests", orderGuests).First(&before).Error; err != nil {
+			return err
+		}
+
+		if res.UserID != before.UserID {
+			return ErrForbiddenOwner
+		}
+
+		if res.Version != 0 && res.Version != before.Version {
+			return ErrVersionMismatch
+		}
+
+		after := before
+		if err := change(&after); err != nil {
+			return err
+		}
+
+		after.Version = before.Version + 1
+
+		result := tx.Model(&Reservation{}).
+			Where("id = ? AND version = ?", before.ID, before.Version).
+			Updates(map[string]interface{}{
+				"destination":      after.Destination,
+				"check_in_date":    after.CheckInDate,
+				"check_out_date":   after.CheckOutDate,
+				"accommodation":    after.Accommodation,
+				"guest_count":      after.GuestCount,
+				"is_active":        after.IsActive,
+				"reminded_at":      after.RemindedAt,
+				"expires_at":       after.ExpiresAt,
+				"stay_status":      after.StayStatus,
+				"checked_in_at":    after.CheckedInAt,
+				"penalty_amount":   after.PenaltyAmount,
+				"penalty_currency": after.PenaltyCurrency,
+				"version":          after.Version,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrVersionMismatch
+		}
+
+		if !sameGuests(before.Guests, after.Guests) {
+			if err := replaceGuests(tx, &after); err != nil {
+				return err
+			}
+		}
+
+		if err := recordChange(ctx, tx, action, &before, &after); err != nil {
+			return err
+		}
+
+		if res.Invoice != nil {
+			res.Invoice.ReservationID = after.ID
+			res.Invoice.ReservationVersion = after.Version
+			res.Invoice.PNR = after.PNR
+
+			same, err := invoiced(tx, res.Invoice)
+			if err != nil {
+				return err
+			}
+
+			if !same {
+				if err := s.issueInvoice(tx, res.Invoice); err != nil {
+					return err
+				}
+			}
+		}
+
+		res.Version = after.Version
+
+		return nil
+	})
+}
+
+// FindReservationByPNR returns the reservation of any user, callers check who may see it
+func (s *store) FindReservationByPNR(ctx context.Context, pnr string) (*Reservation, error) {
+	var reservation Reservation
+	if err := s.db.WithContext(ctx).Where("pnr = ?", pnr).Preload("User").Preload("Guests", orderGuests).First(&reservation).Error; err != nil {
+		return nil, s.translateError("FindReservationByPNR", err)
+	}
+
+	return &reservation, nil
+}
+
+func (s *store) FindReservation(ctx context.Context, pnr string, userID int64) (*Reservation, error) {
+	var reservation Reservation
+
+	err := s.read(ctx, userID, func(db *gorm.DB) error {
+		return db.Where("pnr = ? AND user_id = ?", pnr, userID).Preload("User").Preload("Guests", orderGuests).First(&reservation).Error
+	})
+	if err != nil {
+		return nil, s.translateError("FindReservation", err)
+	}
+
+	return &reservation, nil
+}
+
+// FindReservations returns a page of the user's reservations matching the query and the total count of matches
+func (s *store) FindReservations(ctx context.Context, q ReservationQuery) ([]*Reservation, int64, error) {
+	var reservations []*Reservation
+	var total int64
+
+	err := s.read(ctx, q.UserID, func(db *gorm.DB) (err error) {
+		reservations, total, err = findReservations(db, q)
+		return err
+	})
+	if err != nil {
+		return nil, 0, s.translateError("FindReservations", err)
+	}
+
+	return reservations, total, nil
+}
+
+func findReservations(db *gorm.DB, q ReservationQuery) ([]*Reservation, int64, error) {
+	db = db.Model(&Reservation{}).Where("user_id = ?", q.UserID)
+
+	if q.IsActive != nil {
+		db = db.Where("is_active = ?", *q.IsActive)
+	}
+
+	// date range overlaps the stay
+	if !q.From.IsZero() {
+		db = db.Where("check_out_date >= ?", q.From)
+	}
+
+	if !q.To.IsZero() {
+		db = db.Where("check_in_date <= ?", q.To)
+	}
+
+	if q.Destination != "" {
+		db = db.Where("destination = ?", q.Destination)
+	}
+
+	if q.Accommodation != "" {
+		db = db.Where("accommodation = ?", q.Accommodation)
+	}
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	sortBy := q.SortBy
+	if sortBy == "" {
+		sortBy = SortByCheckInDate
+	}
+
+	col := clause.Column{Name: string(sortBy)}
+	id := clause.Column{Name: "id"}
+
+	if q.After != nil {
+		op := ">"
+		if q.Descending {
+			op = "<"
+		}
+
+		db = db.Where(clause.Or(
+			clause.Expr{SQL: "? " + op + " ?", Vars: []interface{}{col, q.After.Value}},
+			clause.And(
+				clause.Expr{SQL: "? = ?", Vars: []interface{}{col, q.After.Value}},
+				clause.Expr{SQL: "? " + op + " ?", Vars: []interface{}{id, q.After.ID}},
+			),
+		))
+	}
+
+	var reservations []*Reservation
+
+	if err := db.Order(clause.OrderByColumn{Column: col, Desc: q.Descending}).
+		Order(clause.OrderByColumn{Column: id, Desc: q.Descending}).
+		Limit(q.Limit).
+		Preload("User").
+		Preload("Guests", orderGuests).
+		Find(&reservations).
+		Error; err != nil {
+		return nil, 0, err
+	}
+
+	return reservations, total, nil
+}
+
+// AcquireIdempotencyKey stores the key and returns nil, or returns the unexpired key already stored for the user
+func (s *store) AcquireIdempotencyKey(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, error) {
+	db := s.db.WithContext(ctx)
+
+	query := "user_id = ? AND idempotency_key = ?"
+
+	if err := db.Where(query+" AND expires_at <= ?", key.UserID, key.Key, key.CreatedAt).Delete(&IdempotencyKey{}).Error; err != nil {
+		return nil, s.translateError("AcquireIdempotencyKey", err)
+	}
+
+	err := s.translateError("AcquireIdempotencyKey", db.Create(key).Error)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, ErrConflict) {
+		return nil, err
+	}
+
+	var existing IdempotencyKey
+	if err = db.Where(query, key.UserID, key.Key).First(&existing).Error; err != nil {
+		return nil, s.translateError("AcquireIdempotencyKey", err)
+	}
+
+	return &existing, nil
+}
+
+// CompleteIdempotencyKey stores the response to replay for the key
+func (s *store) CompleteIdempotencyKey(ctx context.Context, id int64, response string) error {
+	err := s.db.WithContext(ctx).Model(&IdempotencyKey{}).Where("id = ?", id).Update("response", response).Error
+
+	return s.translateError("CompleteIdempotencyKey", err)
+}
+
+// ReleaseIdempotencyKey deletes the key so that the request can be retried
+func (s *store) ReleaseIdempotencyKey(ctx context.Context, id int64) error {
+	err := s.db.WithContext(ctx).Where("id = ?", id).Delete(&IdempotencyKey{}).Error
+
+	return s.translateError("ReleaseIdempotencyKey", err)
+}
+
+// DeleteExpiredIdempotencyKeys deletes at most limit keys expired by now and returns how many were deleted
+func (s *store) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time, limit int) (int64, error) {
+	db := s.db.WithContext(ctx)
+
+	// the ids are read first since not every database supports a limit on delete
+	var ids []int64
+	if err := db.Model(&IdempotencyKey{}).Where("expires_at <= ?", now).Order("id").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, s.translateError("DeleteExpiredIdempotencyKeys", err)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := db.Where("id IN ? AND expires_at <= ?", ids, now).Delete(&IdempotencyKey{})
+	if result.Error != nil {
+		return 0, s.translateError("DeleteExpiredIdempotencyKeys", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// Ping verifies a connection to the database is alive, waiting at most opts.PingTimeout when it is set
+func (s *store) Ping(ctx context.Context) error {
+	db, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return s.translateError("Ping", ping(ctx, db, s.opts.PingTimeout))
+}
+
+// read runs fn on a replica when one can serve the user's reads, and on the primary when none can or the replica
+// turns out to be unavailable. A record missing on the replica is read from the primary too, since it may not have
+// been replicated yet when the user wrote it through another instance.
+func (s *store) read(ctx context.Context, userID int64, fn func(db *gorm.DB) error) error {
+	if r := s.replicas.reader(userID); r != nil {
+		err := fn(r.db.WithContext(ctx))
+		if ctx.Err() != nil || err == nil {
+			return err
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fn(s.db.WithContext(ctx))
+		}
+
+		if !errors.Is(s.translateError("read", err), ErrUnavailable) {
+			return err
+		}
+
+		r.healthy.Store(false)
+	}
+
+	return fn(s.db.WithContext(ctx))
+}
+
+func (s *store) translateError(op string, err error) error {
+	return translateError(op, err, s.dialect.ErrorKind)
+}
+
+// Stats returns connection pool statistics
+func (s *store) Stats() sql.DBStats {
+	db, err := s.db.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+
+	return db.Stats()
+}
+
+// Close closes the database, waiting for queries in progress at most opts.DisconnectTimeout when it is set
+func (c *store) Close() error {
+	db, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+
+	closeAll := func() error {
+		return errors.Join(c.replicas.close(), db.Close())
+	}
+
+	if c.opts.DisconnectTimeout <= 0 {
+		return closeAll()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- closeAll()
+	}()
+
+	select {
+	case err = <-done:
+		return err
+	case <-time.After(c.opts.DisconnectTimeout):
+		return ErrDisconnectTimeout
+	}
+}
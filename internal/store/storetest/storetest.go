@@ -0,0 +1,1111 @@
+// Package storetest runs the same store tests against every database the store supports
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"hotel-california-backend/internal/events"
+	"hotel-california-backend/internal/migrate"
+	"hotel-california-backend/internal/store"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Database represents a database the suite runs against, its tables are dropped and migrated again by every test
+type Database struct {
+	// DB is used to migrate and seed the database
+	DB         *sql.DB
+	Migrations fs.FS
+	Dialect    migrate.Dialect
+	// NewStore creates the store under test on the same database
+	NewStore func() (store.Store, error)
+	// Gorm opens gorm on DB, to build the schema as gorm AutoMigrate did before migrations
+	Gorm gorm.Dialector
+}
+
+// Run runs the suite, open is called by every test and has to close what it opens with t.Cleanup
+func Run(t *testing.T, open func(t *testing.T) Database) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, db Database)
+	}{
+		{name: "Migrations", test: testMigrations},
+		{name: "RefusesUnmigratedDatabase", test: testRefusesUnmigratedDatabase},
+		{name: "UpgradesAutoMigratedDatabase", test: testUpgradesAutoMigratedDatabase},
+		{name: "Reservations", test: testReservations},
+		{name: "FindReservations", test: testFindReservations},
+		{name: "IdempotencyKeys", test: testIdempotencyKeys},
+		{name: "AuditTrail", test: testAuditTrail},
+		{name: "OutboxEvents", test: testOutboxEvents},
+		{name: "WebhookDeliveries", test: testWebhookDeliveries},
+		{name: "Notifications", test: testNotifications},
+		{name: "Jobs", test: testJobs},
+		{name: "ReservationJobs", test: testReservationJobs},
+		{name: "Stays", test: testStays},
+		{name: "StayStatusBackfill", test: testStayStatusBackfill},
+		{name: "CalendarTokens", test: testCalendarTokens},
+		{name: "Invoices", test: testInvoices},
+		{name: "Guests", test: testGuests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := open(t)
+
+			_, err := migrate.NewMigrator(db.DB, db.Migrations, db.Dialect).To(context.Background(), 0)
+			require.NoError(t, err)
+
+			tt.test(t, db)
+		})
+	}
+}
+
+// newStore migrates the database, seeds users 1 and 2 and returns the store
+func newStore(t *testing.T, db Database) store.Store {
+	_, err := migrate.NewMigrator(db.DB, db.Migrations, db.Dialect).Up(context.Background())
+	require.NoError(t, err)
+
+	_, err = db.DB.Exec("INSERT INTO users (first_name, last_name, username, password, is_active, is_deleted) VALUES ('John', 'Doe', 'john@doe.com', 'x', TRUE, FALSE), ('Jane', 'Doe', 'jane@doe.com', 'x', TRUE, FALSE)")
+	require.NoError(t, err)
+
+	s, err := db.NewStore()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return s
+}
+
+// migrateBefore migrates the database up to the migration preceding the named one and returns the migrator
+func migrateBefore(t *testing.T, db Database, name string) *migrate.Migrator {
+	m := migrate.NewMigrator(db.DB, db.Migrations, db.Dialect)
+
+	migrations, err := m.Migrations()
+	require.NoError(t, err)
+
+	var version int64
+	for _, mg := range migrations {
+		if mg.Name == name {
+			break
+		}
+
+		version = mg.Version
+	}
+
+	_, err = m.To(context.Background(), version)
+	require.NoError(t, err)
+
+	return m
+}
+
+// insertReservation inserts a reservation with the columns every schema version has
+func insertReservation(t *testing.T, db Database, pnr string, checkIn, checkOut time.Time, isActive bool) {
+	ph := make([]string, 9)
+	for i := range ph {
+		ph[i] = db.Dialect.Placeholder(i + 1)
+	}
+
+	_, err := db.DB.Exec("INSERT INTO reservations (user_id, pnr, destination, check_in_date, check_out_date, accommodation, guest_count, createdAt, is_active, is_deleted) VALUES ("+
+		strings.Join(ph, ", ")+", FALSE)", 1, pnr, "Istanbul", checkIn, checkOut, "city", 2, time.Now().UTC(), isActive)
+	require.NoError(t, err)
+}
+
+func newReservation(pnr string, userID int64, checkIn time.Time) *store.Reservation {
+	return &store.Reservation{
+		UserID:        userID,
+		PNR:           pnr,
+		Destination:   "Istanbul",
+		CheckInDate:   checkIn,
+		CheckOutDate:  checkIn.AddDate(0, 0, 2),
+		Accommodation: "city",
+		GuestCount:    2,
+		Version:       1,
+		CreatedAt:     time.Now().UTC(),
+		IsActive:      true,
+	}
+}
+
+func testMigrations(t *testing.T, db Database) {
+	ctx := context.Background()
+
+	m := migrate.NewMigrator(db.DB, db.Migrations, db.Dialect)
+
+	latest, err := m.Latest()
+	require.NoError(t, err)
+
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, m.Check(ctx))
+
+	run, err := m.Down(ctx)
+	require.NoError(t, err)
+	require.Len(t, run, 1)
+	assert.Equal(t, latest, run[0].Version)
+	assert.True(t, errors.Is(m.Check(ctx), migrate.ErrUnexpectedVersion))
+
+	_, err = m.To(ctx, 0)
+	require.NoError(t, err)
+
+	version, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, st := range statuses {
+		assert.False(t, st.Applied)
+	}
+
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, m.Check(ctx))
+}
+
+func testRefusesUnmigratedDatabase(t *testing.T, db Database) {
+	_, err := db.NewStore()
+
+	assert.True(t, errors.Is(err, migrate.ErrUnexpectedVersion))
+}
+
+// autoMigratedUser and autoMigratedReservation are the models the schema was created from with gorm AutoMigrate
+// before migrations
+type autoMigratedUser struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	FirstName string    `gorm:"column:first_name"`
+	LastName  string    `gorm:"column:last_name"`
+	Username  string    `gorm:"column:username"`
+	Password  string    `gorm:"column:password"`
+	CreatedAt time.Time `gorm:"column:createdAt"`
+	IsActive  bool      `gorm:"column:is_active"`
+	IsDeleted bool      `gorm:"column:is_deleted"`
+}
+
+func (autoMigratedUser) TableName() string {
+	return "users"
+}
+
+type autoMigratedReservation struct {
+	ID            int64            `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID        int64            `gorm:"column:user_id"`
+	User          autoMigratedUser `gorm:"foreignKey:UserID"`
+	PNR           string           `gorm:"column:pnr"`
+	Destination   string           `gorm:"column:destination"`
+	CheckInDate   time.Time        `gorm:"column:check_in_date"`
+	CheckOutDate  time.Time        `gorm:"column:check_out_date"`
+	Accommodation string           `gorm:"column:accommodation"`
+	GuestCount    int              `gorm:"column:guest_count"`
+	CreatedAt     time.Time        `gorm:"column:createdAt"`
+	IsActive      bool             `gorm:"column:is_active"`
+	IsDeleted     bool             `gorm:"column:is_deleted"`
+}
+
+func (autoMigratedReservation) TableName() string {
+	return "reservations"
+}
+
+func testUpgradesAutoMigratedDatabase(t *testing.T, db Database) {
+	ctx := context.Background()
+
+	gdb, err := gorm.Open(db.Gorm, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&autoMigratedUser{}, &autoMigratedReservation{}))
+
+	checkIn := time.Now().UTC().Truncate(time.Second).AddDate(0, 0, 7)
+
+	require.NoError(t, gdb.Create(&autoMigratedReservation{
+		User:          autoMigratedUser{FirstName: "John", LastName: "Doe", Username: "john@doe.com", Password: "x", IsActive: true},
+		PNR:           "pE5TYsDj",
+		Destination:   "Istanbul",
+		CheckInDate:   checkIn,
+		CheckOutDate:  checkIn.AddDate(0, 0, 2),
+		Accommodation: "city",
+		GuestCount:    2,
+		CreatedAt:     time.Now().UTC(),
+		IsActive:      true,
+	}).Error)
+
+	// pnrs were not unique before migrations
+	require.NoError(t, gdb.Create(&autoMigratedReservation{
+		UserID:        1,
+		PNR:           "pE5TYsDj",
+		Destination:   "Izmir",
+		CheckInDate:   checkIn,
+		CheckOutDate:  checkIn.AddDate(0, 0, 3),
+		Accommodation: "beach",
+		GuestCount:    1,
+		CreatedAt:     time.Now().UTC(),
+		IsActive:      true,
+	}).Error)
+
+	m := migrate.NewMigrator(db.DB, db.Migrations, db.Dialect)
+
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, m.Check(ctx))
+
+	s, err := db.NewStore()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	res, err := s.FindReservation(ctx, "pE5TYsDj", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Version)
+	assert.Equal(t, store.StatusConfirmed, res.Status())
+	assert.Equal(t, "John", res.User.FirstName)
+	assert.Equal(t, "Istanbul", res.Destination)
+
+	// the newer reservation of a duplicated pnr is issued a pnr of its id
+	reissued, err := s.FindReservation(ctx, "R0000002", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Izmir", reissued.Destination)
+
+	res.Version = 1
+	res.GuestCount = 3
+	require.NoError(t, s.UpdateReservation(ctx, res))
+	assert.Equal(t, int64(2), res.Version)
+
+	page, _, err := s.FindReservations(ctx, store.ReservationQuery{UserID: 1, SortBy: store.SortByCreatedAt, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page, 2)
+
+	existing, err := s.AcquireIdempotencyKey(ctx, &store.IdempotencyKey{UserID: 1, Key: "6f1c2a4e", Fingerprint: "a", CreatedAt: time.Now().UTC(), ExpiresAt: time.Now().UTC().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	assert.True(t, errors.Is(s.CreateReservation(ctx, newReservation("pE5TYsDj", 1, checkIn)), store.ErrConflict))
+}
+
+func testReservations(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	checkIn := time.Now().UTC().Truncate(time.Second).AddDate(0, 0, 7)
+
+	require.NoError(t, s.CreateReservation(ctx, newReservation("ABCDEFG2", 1, checkIn)))
+
+	err := s.CreateReservation(ctx, newReservation("ABCDEFG2", 1, checkIn))
+	assert.True(t, errors.Is(err, store.ErrConflict))
+
+	generated := newReservation("", 2, checkIn)
+	require.NoError(t, s.CreateReservation(ctx, generated))
+	assert.True(t, store.ValidPNR(generated.PNR))
+
+	res, err := s.FindReservation(ctx, "ABCDEFG2", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "John", res.User.FirstName)
+	assert.True(t, checkIn.Equal(res.CheckInDate))
+
+	_, err = s.FindReservation(ctx, "ABCDEFG2", 2)
+	assert.True(t, errors.Is(err, store.ErrNotFound))
+
+	update := newReservation("ABCDEFG2", 1, checkIn.AddDate(0, 0, 1))
+	update.Version = 1
+	require.NoError(t, s.UpdateReservation(ctx, update))
+	assert.Equal(t, int64(2), update.Version)
+
+	update.Version = 1
+	assert.True(t, errors.Is(s.UpdateReservation(ctx, update), store.ErrVersionMismatch))
+
+	update.UserID = 2
+	assert.True(t, errors.Is(s.UpdateReservation(ctx, update), store.ErrForbiddenOwner))
+}
+
+func testFindReservations(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	checkIn := time.Now().UTC().Truncate(time.Second).AddDate(0, 0, 7)
+
+	for i, pnr := range []string{"ABCDEFG2", "ABCDEFG3", "ABCDEFG4"} {
+		require.NoError(t, s.CreateReservation(ctx, newReservation(pnr, 1, checkIn.AddDate(0, 0, i))))
+	}
+
+	require.NoError(t, s.CreateReservation(ctx, newReservation("ABCDEFG5", 2, checkIn)))
+
+	page, total, err := s.FindReservations(ctx, store.ReservationQuery{
+		UserID: 1,
+		SortBy: store.SortByCheckInDate,
+		Limit:  2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, page, 2)
+	assert.Equal(t, "ABCDEFG2", page[0].PNR)
+
+	page, _, err = s.FindReservations(ctx, store.ReservationQuery{
+		UserID: 1,
+		SortBy: store.SortByCheckInDate,
+		After:  &store.ReservationCursor{Value: page[1].CheckInDate, ID: page[1].ID},
+		Limit:  2,
+	})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "ABCDEFG4", page[0].PNR)
+
+	page, total, err = s.FindReservations(ctx, store.ReservationQuery{
+		UserID:     1,
+		To:         checkIn,
+		SortBy:     store.SortByCreatedAt,
+		Descending: true,
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, page, 1)
+}
+
+func testIdempotencyKeys(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	now := time.Now().UTC()
+
+	key := &store.IdempotencyKey{UserID: 1, Key: "6f1c2a4e", Fingerprint: "a", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	existing, err := s.AcquireIdempotencyKey(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	require.NoError(t, s.CompleteIdempotencyKey(ctx, key.ID, `{"pnr":"ABCDEFG2"}`))
+
+	existing, err = s.AcquireIdempotencyKey(ctx, &store.IdempotencyKey{UserID: 1, Key: "6f1c2a4e", Fingerprint: "a", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, `{"pnr":"ABCDEFG2"}`, existing.Response)
+
+	require.NoError(t, s.ReleaseIdempotencyKey(ctx, key.ID))
+
+	existing, err = s.AcquireIdempotencyKey(ctx, &store.IdempotencyKey{UserID: 1, Key: "6f1c2a4e", Fingerprint: "b", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	expired := &store.IdempotencyKey{UserID: 2, Key: "9d3b7e10", Fingerprint: "a", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+
+	existing, err = s.AcquireIdempotencyKey(ctx, expired)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	n, err := s.DeleteExpiredIdempotencyKeys(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	existing, err = s.AcquireIdempotencyKey(ctx, &store.IdempotencyKey{UserID: 1, Key: "6f1c2a4e", Fingerprint: "c", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.NotNil(t, existing, "unexpired keys are kept")
+
+	assert.NoError(t, s.Ping(ctx))
+}
+
+func testAuditTrail(t *testing.T, db Database) {
+	s := newStore(t, db)
+
+	ctx := store.WithActor(context.Background(), store.Actor{UserID: 1, IPAddress: "10.0.0.1", RequestID: "4bf92f35"})
+
+	checkIn := time.Now().UTC().Truncate(time.Second).AddDate(0, 0, 7)
+
+	require.NoError(t, s.CreateReservation(ctx, newReservation("ABCDEFG2", 1, checkIn)))
+
+	update := newReservation("ABCDEFG2", 1, checkIn.AddDate(0, 0, 1))
+	require.NoError(t, s.UpdateReservation(ctx, update))
+
+	cancel := &store.Reservation{PNR: "ABCDEFG2", UserID: 1, Version: update.Version}
+	require.NoError(t, s.CancelReservation(ctx, cancel))
+	assert.Equal(t, int64(3), cancel.Version)
+
+	assert.True(t, errors.Is(s.CancelReservation(ctx, cancel), store.ErrNotActive))
+
+	res, err := s.FindReservationByPNR(ctx, "ABCDEFG2")
+	require.NoError(t, err)
+	assert.False(t, res.IsActive)
+
+	audits, err := s.FindReservationAudits(ctx, "ABCDEFG2")
+	require.NoError(t, err)
+	require.Len(t, audits, 3)
+
+	assert.Equal(t, store.AuditActionCreate, audits[0].Action)
+	assert.Equal(t, store.AuditActionUpdate, audits[1].Action)
+	assert.Equal(t, store.AuditActionCancel, audits[2].Action)
+
+	for _, audit := range audits {
+		assert.Equal(t, res.ID, audit.ReservationID)
+		assert.Equal(t, int64(1), audit.ActorUserID)
+		assert.Equal(t, "10.0.0.1", audit.IPAddress)
+		assert.Equal(t, "4bf92f35", audit.RequestID)
+	}
+
+	diff, err := audits[1].Diff()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]store.AuditChange{
+		"checkInDate": {From: checkIn.Format("2006-01-02"), To: checkIn.AddDate(0, 0, 1).Format("2006-01-02")},
+		"checkOutDate": {
+			From: checkIn.AddDate(0, 0, 2).Format("2006-01-02"),
+			To:   checkIn.AddDate(0, 0, 3).Format("2006-01-02"),
+		},
+	}, diff)
+
+	diff, err = audits[2].Diff()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]store.AuditChange{"isActive": {From: true, To: false}}, diff)
+
+	usr, err := s.FindUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, store.RoleGuest, usr.Role)
+}
+
+func testOutboxEvents(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	checkIn := time.Now().UTC().Truncate(time.Second).AddDate(0, 0, 7)
+
+	require.NoError(t, s.CreateReservation(ctx, newReservation("ABCDEFG2", 1, checkIn)))
+	require.NoError(t, s.UpdateReservation(ctx, newReservation("ABCDEFG2", 1, checkIn.AddDate(0, 0, 1))))
+	require.NoError(t, s.CancelReservation(ctx, &store.Reservation{PNR: "ABCDEFG2", UserID: 1}))
+
+	// a failed change writes no event
+	assert.Error(t, s.CancelReservation(ctx, &store.Reservation{PNR: "ABCDEFG2", UserID: 1}))
+
+	oes, err := s.PendingOutboxEvents(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, oes, 3)
+
+	var types []string
+	for _, oe := range oes {
+		e, err := oe.Event()
+		require.NoError(t, err)
+		assert.Equal(t, oe.EventID, e.ID)
+		assert.Equal(t, "ABCDEFG2", e.AggregateID)
+
+		types = append(types, e.Type)
+	}
+
+	assert.Equal(t, []string{events.ReservationCreated, events.ReservationUpdated, events.ReservationCancelled}, types)
+
+	e, err := oes[2].Event()
+	require.NoError(t, err)
+
+	var data events.ReservationData
+	require.NoError(t, json.Unmarshal(e.Data, &data))
+	assert.False(t, data.IsActive)
+	assert.Equal(t, int64(3), data.Version)
+	assert.JSONEq(t, `{"isActive":{"from":true,"to":false}}`, string(data.Changes))
+
+	// publishers keep their own deliveries, the first event is dead for the http publisher after two attempts
+	require.NoError(t, s.MarkOutboxDeliveryPublished(ctx, oes[0].ID, "bus"))
+
+	status, err := s.MarkOutboxDeliveryFailed(ctx, oes[0].ID, "http", "endpoint is down", 2)
+	require.NoError(t, err)
+	assert.Equal(t, store.OutboxDeliveryPending, status)
+
+	status, err = s.MarkOutboxDeliveryFailed(ctx, oes[0].ID, "http", "endpoint is down", 2)
+	require.NoError(t, err)
+	assert.Equal(t, store.OutboxDeliveryDead, status)
+
+	require.NoError(t, s.MarkOutboxEventPublished(ctx, oes[0].ID, time.Now().Add(-time.Hour)))
+	require.NoError(t, s.MarkOutboxDeliveryPublished(ctx, oes[1].ID, "bus"))
+	require.NoError(t, s.MarkOutboxEventPublished(ctx, oes[1].ID, time.Now().Add(-time.Hour)))
+
+	status, err = s.MarkOutboxDeliveryFailed(ctx, oes[2].ID, "http", "endpoint is down", 2)
+	require.NoError(t, err)
+	assert.Equal(t, store.OutboxDeliveryPending, status)
+
+	pending, err := s.PendingOutboxEvents(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, oes[2].ID, pending[0].ID)
+	assert.False(t, pending[0].Delivered("http"))
+	assert.False(t, pending[0].Delivered("bus"))
+	require.NotNil(t, pending[0].Delivery("http"))
+	assert.Equal(t, 1, pending[0].Delivery("http").Attempts)
+	assert.Equal(t, "endpoint is down", pending[0].Delivery("http").LastError)
+
+	// events dead for a publisher are kept
+	n, err := s.DeletePublishedOutboxEvents(ctx, time.Now().Add(-time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = s.DeletePublishedOutboxEvents(ctx, time.Now().Add(-time.Minute), 10)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func testWebhookDeliveries(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	sub := &store.WebhookSubscription{
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: events.ReservationCreated,
+		Secret:     "s3cr3t",
+		IsActive:   true,
+		CreatedAt:  now,
+	}
+	require.NoError(t, s.CreateWebhookSubscription(ctx, sub))
+
+	d := &store.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        "2f1c8a3e",
+		EventType:      events.ReservationCreated,
+		Payload:        `{"id":"2f1c8a3e"}`,
+		Status:         store.WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+	require.NoError(t, s.CreateWebhookDelivery(ctx, d))
+
+	// an event relayed again is not queued twice
+	dup := *d
+	dup.ID = 0
+	assert.ErrorIs(t, s.CreateWebhookDelivery(ctx, &dup), store.ErrConflict)
+
+	due, err := s.DueWebhookDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "s3cr3t", due[0].Subscription.Secret)
+
+	// only one of two instances claims the delivery
+	other := *due[0]
+
+	claimed, err := s.ClaimWebhookDelivery(ctx, due[0], now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = s.ClaimWebhookDelivery(ctx, &other, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	due[0].Status = store.WebhookDeliveryDead
+	due[0].Attempts = 1
+	due[0].LastStatusCode = 500
+	due[0].LastError = "unexpected http status 500"
+	require.NoError(t, s.RecordWebhookAttempt(ctx, due[0], &store.WebhookDeliveryAttempt{
+		StatusCode: 500,
+		Error:      "unexpected http status 500",
+		DurationMs: 12,
+		CreatedAt:  now,
+	}))
+
+	ds, err := s.FindWebhookDeliveries(ctx, store.WebhookDeliveryQuery{Status: store.WebhookDeliveryDead, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	assert.Equal(t, 1, ds[0].Attempts)
+	require.Len(t, ds[0].AttemptLogs, 1)
+	assert.Equal(t, 500, ds[0].AttemptLogs[0].StatusCode)
+
+	require.NoError(t, s.RedeliverWebhookDelivery(ctx, d.ID, now))
+
+	due, err = s.DueWebhookDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 0, due[0].Attempts)
+
+	require.NoError(t, s.DeleteWebhookSubscription(ctx, sub.ID))
+	assert.ErrorIs(t, s.DeleteWebhookSubscription(ctx, sub.ID), store.ErrNotFound)
+
+	subs, err := s.FindWebhookSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+}
+
+func testNotifications(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	sent := &store.Notification{EventID: "2f1c8a3e", EventType: events.ReservationCreated, Recipient: "erhan@example.com", Subject: "Confirmed", Body: "Dear Erhan", NextAttemptAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)}
+	require.NoError(t, s.CreateNotification(ctx, sent))
+
+	pending := &store.Notification{EventID: "5b7d9e1f", EventType: events.ReservationUpdated, Recipient: "erhan@example.com", Subject: "Updated", Body: "Dear Erhan", NextAttemptAt: now.Add(time.Minute), CreatedAt: now}
+	require.NoError(t, s.CreateNotification(ctx, pending))
+
+	// an event is notified of once
+	assert.ErrorIs(t, s.CreateNotification(ctx, &store.Notification{EventID: "2f1c8a3e", EventType: events.ReservationCreated, Recipient: "erhan@example.com", Subject: "Confirmed", Body: "Dear Erhan", NextAttemptAt: now, CreatedAt: now}), store.ErrConflict)
+
+	// notifications are due once their lease expires
+	due, err := s.DueNotifications(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "2f1c8a3e", due[0].EventID)
+	assert.Equal(t, "Dear Erhan", due[0].Body)
+
+	claimed, err := s.ClaimNotification(ctx, due[0], now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// another instance claiming the same notification loses
+	claimed, err = s.ClaimNotification(ctx, &store.Notification{ID: due[0].ID, NextAttemptAt: now.Add(-time.Minute)}, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// sent notifications are not due again
+	require.NoError(t, s.MarkNotificationSent(ctx, sent.ID, now))
+
+	due, err = s.DueNotifications(ctx, now.Add(time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "5b7d9e1f", due[0].EventID)
+
+	n, err := s.DeleteNotifications(ctx, now.Add(-time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	require.NoError(t, s.CreateNotification(ctx, &store.Notification{EventID: "2f1c8a3e", EventType: events.ReservationCreated, Recipient: "erhan@example.com", Subject: "Confirmed", Body: "Dear Erhan", NextAttemptAt: now, CreatedAt: now}))
+}
+
+func testJobs(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	require.NoError(t, s.EnsureJob(ctx, &store.Job{Name: "remind", Schedule: "@hourly", NextRunAt: now, CreatedAt: now}))
+
+	// another instance starting with the same schedule keeps the next run
+	require.NoError(t, s.EnsureJob(ctx, &store.Job{Name: "remind", Schedule: "@hourly", NextRunAt: now.Add(time.Hour), CreatedAt: now}))
+
+	job, err := s.AcquireJob(ctx, "remind", "a", now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, "a", *job.LockedBy)
+
+	// only one instance runs the job at a time
+	other, err := s.AcquireJob(ctx, "remind", "b", now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, other)
+
+	// a lock left by a stopped instance expires
+	other, err = s.AcquireJob(ctx, "remind", "b", now.Add(time.Minute), now.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, other)
+
+	assert.ErrorIs(t, s.CompleteJob(ctx, job), store.ErrNotFound)
+
+	other.NextRunAt = now.Add(time.Hour)
+	other.LastRunAt = &now
+	require.NoError(t, s.CompleteJob(ctx, other))
+
+	job, err = s.AcquireJob(ctx, "remind", "a", now.Add(2*time.Minute), now.Add(3*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	job, err = s.AcquireJob(ctx, "remind", "a", now.Add(time.Hour), now.Add(time.Hour+time.Minute))
+	require.NoError(t, err)
+	assert.NotNil(t, job)
+}
+
+func testReservationJobs(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	past := now.Add(-time.Minute)
+
+	soon := newReservation("ABCDEFG2", 1, now.AddDate(0, 0, 1))
+	soon.Language = "tr"
+	later := newReservation("ABCDEFG3", 1, now.AddDate(0, 0, 5))
+	held := newReservation("ABCDEFG4", 2, now.AddDate(0, 0, 9))
+	held.ExpiresAt = &past
+
+	for _, res := range []*store.Reservation{soon, later, held} {
+		require.NoError(t, s.CreateReservation(ctx, res))
+	}
+
+	due, err := s.DueReservationReminders(ctx, now, now.AddDate(0, 0, 2), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "ABCDEFG2", due[0].PNR)
+
+	reminded, err := s.RemindReservation(ctx, due[0], now)
+	require.NoError(t, err)
+	assert.True(t, reminded)
+
+	// a reservation is reminded of once
+	reminded, err = s.RemindReservation(ctx, due[0], now)
+	require.NoError(t, err)
+	assert.False(t, reminded)
+
+	due, err = s.DueReservationReminders(ctx, now, now.AddDate(0, 0, 2), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	expired, err := s.ExpiredReservations(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, "ABCDEFG4", expired[0].PNR)
+
+	system := store.WithActor(ctx, store.Actor{System: true, RequestID: "job:expire"})
+	require.NoError(t, s.ExpireReservation(system, expired[0]))
+	assert.ErrorIs(t, s.ExpireReservation(system, expired[0]), store.ErrNotActive)
+	assert.ErrorIs(t, s.ExpireReservation(system, later), store.ErrNotExpired)
+
+	audits, err := s.FindReservationAudits(ctx, "ABCDEFG4")
+	require.NoError(t, err)
+	require.Len(t, audits, 2)
+	assert.Equal(t, store.AuditActionExpire, audits[1].Action)
+	assert.Zero(t, audits[1].ActorUserID)
+
+	// a confirmed reservation is kept when its hold would have ended
+	until := now.Add(time.Hour)
+	pending := newReservation("ABCDEFG5", 2, now.AddDate(0, 0, 9))
+	pending.ExpiresAt = &until
+	require.NoError(t, s.CreateReservation(ctx, pending))
+
+	require.NoError(t, s.ConfirmReservation(ctx, pending))
+	assert.ErrorIs(t, s.ConfirmReservation(ctx, pending), store.ErrNotHeld)
+	assert.ErrorIs(t, s.ConfirmReservation(ctx, later), store.ErrNotHeld)
+	assert.ErrorIs(t, s.ConfirmReservation(ctx, &store.Reservation{PNR: "ABCDEFG4", UserID: 2}), store.ErrNotActive)
+
+	expired, err = s.ExpiredReservations(ctx, until, 10)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	confirmed, err := s.FindReservation(ctx, "ABCDEFG5", 2)
+	require.NoError(t, err)
+	assert.Nil(t, confirmed.ExpiresAt)
+	assert.Equal(t, int64(2), confirmed.Version)
+
+	oes, err := s.PendingOutboxEvents(ctx, 10)
+	require.NoError(t, err)
+
+	var types []string
+	for _, oe := range oes {
+		types = append(types, oe.EventType)
+	}
+
+	assert.Equal(t, []string{
+		events.ReservationCreated,
+		events.ReservationCreated,
+		events.ReservationCreated,
+		events.ReservationReminderDue,
+		events.ReservationCancelled,
+		events.ReservationCreated,
+		events.ReservationConfirmed,
+	}, types)
+
+	e, err := oes[3].Event()
+	require.NoError(t, err)
+
+	var data events.ReservationData
+	require.NoError(t, json.Unmarshal(e.Data, &data))
+	assert.Equal(t, "tr", data.Language)
+}
+
+func testStays(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	today := now.Truncate(24 * time.Hour)
+
+	arriving := newReservation("ABCDEFG2", 1, today)
+	missed := newReservation("ABCDEFG3", 1, today.AddDate(0, 0, -1))
+	leaving := newReservation("ABCDEFG4", 2, today.AddDate(0, 0, -2))
+	future := newReservation("ABCDEFG5", 2, today.AddDate(0, 0, 5))
+	staying := newReservation("ABCDEFG6", 2, today.AddDate(0, 0, -2))
+	staying.CheckOutDate = today.AddDate(0, 0, 5)
+
+	for _, res := range []*store.Reservation{arriving, missed, leaving, future, staying} {
+		require.NoError(t, s.CreateReservation(ctx, res))
+	}
+
+	staff := store.WithActor(ctx, store.Actor{UserID: 2, RequestID: "check-in"})
+	require.NoError(t, s.CheckInReservation(staff, arriving, now))
+	require.NoError(t, s.CheckInReservation(staff, leaving, leaving.CheckInDate.Add(14*time.Hour)))
+	assert.ErrorIs(t, s.CheckInReservation(staff, arriving, now), store.ErrCheckedIn)
+	assert.ErrorIs(t, s.CheckInReservation(staff, future, now), store.ErrNotCheckInDay)
+
+	// guests who have checked in are not no-shows, nor are guests who were due more than a day before
+	noShows, err := s.NoShowReservations(ctx, today, 10)
+	require.NoError(t, err)
+	require.Len(t, noShows, 1)
+	assert.Equal(t, "ABCDEFG3", noShows[0].PNR)
+
+	system := store.WithActor(ctx, store.Actor{System: true, RequestID: "job:close-stays"})
+	require.NoError(t, s.MarkReservationNoShow(system, noShows[0], 15000, "TRY"))
+	assert.ErrorIs(t, s.MarkReservationNoShow(system, arriving, 15000, "TRY"), store.ErrCheckedIn)
+
+	found, err := s.FindReservationByPNR(ctx, "ABCDEFG3")
+	require.NoError(t, err)
+	assert.Equal(t, store.StatusNoShow, found.Status())
+	assert.False(t, found.IsActive)
+	assert.Equal(t, int64(15000), found.PenaltyAmount)
+	assert.Equal(t, "TRY", found.PenaltyCurrency)
+
+	completable, err := s.CompletableReservations(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, completable, 1)
+	assert.Equal(t, "ABCDEFG4", completable[0].PNR)
+
+	require.NoError(t, s.CompleteReservation(system, completable[0]))
+	assert.ErrorIs(t, s.CompleteReservation(system, completable[0]), store.ErrNotActive)
+
+	audits, err := s.FindReservationAudits(ctx, "ABCDEFG4")
+	require.NoError(t, err)
+	require.Len(t, audits, 3)
+	assert.Equal(t, store.AuditActionCheckIn, audits[1].Action)
+	assert.Equal(t, store.AuditActionComplete, audits[2].Action)
+
+	oes, err := s.PendingOutboxEvents(ctx, 10)
+	require.NoError(t, err)
+
+	var types []string
+	for _, oe := range oes {
+		types = append(types, oe.EventType)
+	}
+
+	assert.Equal(t, []string{
+		events.ReservationCreated,
+		events.ReservationCreated,
+		events.ReservationCreated,
+		events.ReservationCreated,
+		events.ReservationCreated,
+		events.ReservationCheckedIn,
+		events.ReservationCheckedIn,
+		events.ReservationNoShow,
+		events.ReservationCompleted,
+	}, types)
+
+	e, err := oes[7].Event()
+	require.NoError(t, err)
+
+	var data events.ReservationData
+	require.NoError(t, json.Unmarshal(e.Data, &data))
+	assert.Equal(t, store.StatusNoShow, data.Status)
+}
+
+func testStayStatusBackfill(t *testing.T, db Database) {
+	ctx := context.Background()
+
+	m := migrateBefore(t, db, "stay_status")
+
+	_, err := db.DB.Exec("INSERT INTO users (first_name, last_name, username, password, is_active, is_deleted) VALUES ('John', 'Doe', 'john@doe.com', 'x', TRUE, FALSE)")
+	require.NoError(t, err)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	insertReservation(t, db, "ABCDEFG2", today.AddDate(0, 0, -5), today.AddDate(0, 0, -3), true)
+	insertReservation(t, db, "ABCDEFG3", today.AddDate(0, 0, -1), today.AddDate(0, 0, 2), true)
+	insertReservation(t, db, "ABCDEFG4", today.AddDate(0, 0, 3), today.AddDate(0, 0, 5), true)
+	insertReservation(t, db, "ABCDEFG5", today.AddDate(0, 0, -5), today.AddDate(0, 0, -3), false)
+
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	s, err := db.NewStore()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	// reservations made before stays were tracked are not taken as no-shows
+	for pnr, status := range map[string]string{
+		"ABCDEFG2": store.StatusCompleted,
+		"ABCDEFG3": store.StatusCheckedIn,
+		"ABCDEFG4": store.StatusConfirmed,
+		"ABCDEFG5": store.StatusCancelled,
+	} {
+		res, err := s.FindReservationByPNR(ctx, pnr)
+		require.NoError(t, err)
+		assert.Equal(t, status, res.Status(), pnr)
+	}
+
+	noShows, err := s.NoShowReservations(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, noShows)
+}
+
+func testCalendarTokens(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	first := &store.CalendarToken{UserID: 1, TokenHash: "a1", Language: "tr", CreatedAt: now}
+	require.NoError(t, s.CreateCalendarToken(ctx, first))
+
+	found, err := s.FindCalendarToken(ctx, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), found.UserID)
+	assert.Equal(t, "tr", found.Language)
+
+	// a new token replaces the one before
+	require.NoError(t, s.CreateCalendarToken(ctx, &store.CalendarToken{UserID: 1, TokenHash: "b2", CreatedAt: now}))
+	require.NoError(t, s.CreateCalendarToken(ctx, &store.CalendarToken{UserID: 2, TokenHash: "c3", CreatedAt: now}))
+
+	_, err = s.FindCalendarToken(ctx, "a1")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	_, err = s.FindCalendarToken(ctx, "b2")
+	require.NoError(t, err)
+
+	revoked, err := s.RevokeCalendarTokens(ctx, 1, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revoked)
+
+	_, err = s.FindCalendarToken(ctx, "b2")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	// tokens of other users are kept
+	_, err = s.FindCalendarToken(ctx, "c3")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, s.CreateCalendarToken(ctx, &store.CalendarToken{UserID: 2, TokenHash: "b2", CreatedAt: now}), store.ErrConflict)
+}
+
+func testInvoices(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	invoice := func() *store.Invoice {
+		return &store.Invoice{
+			Nights:                 2,
+			NightlyRate:            250000,
+			NetAmount:              500000,
+			VATRate:                1000,
+			VATAmount:              50000,
+			AccommodationTaxRate:   200,
+			AccommodationTaxAmount: 10000,
+			TotalAmount:            560000,
+			Currency:               "TRY",
+			IssuedAt:               now,
+		}
+	}
+
+	first := newReservation("ABCDEFG2", 1, now.AddDate(0, 0, 3))
+	first.Invoice = invoice()
+	require.NoError(t, s.CreateReservation(ctx, first))
+	assert.Equal(t, int64(1), first.Invoice.Number)
+	assert.Equal(t, first.ID, first.Invoice.ReservationID)
+	assert.Equal(t, "ABCDEFG2", first.Invoice.PNR)
+
+	second := newReservation("ABCDEFG3", 2, now.AddDate(0, 0, 4))
+	second.Invoice = invoice()
+	require.NoError(t, s.CreateReservation(ctx, second))
+	assert.Equal(t, int64(2), second.Invoice.Number)
+
+	// reservations are not invoiced without an invoice
+	third := newReservation("ABCDEFG4", 2, now.AddDate(0, 0, 5))
+	require.NoError(t, s.CreateReservation(ctx, third))
+
+	_, err := s.FindInvoice(ctx, third.ID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	update := newReservation(first.PNR, 1, first.CheckInDate)
+	update.CheckOutDate = first.CheckOutDate.AddDate(0, 0, 1)
+	update.Invoice = invoice()
+	update.Invoice.Nights = 3
+	update.Invoice.NetAmount = 750000
+	update.Invoice.TotalAmount = 840000
+	require.NoError(t, s.UpdateReservation(ctx, update))
+	assert.Equal(t, int64(3), update.Invoice.Number)
+	assert.Equal(t, int64(2), update.Invoice.ReservationVersion)
+
+	found, err := s.FindInvoice(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), found.Number)
+	assert.Equal(t, int64(2), found.ReservationVersion)
+	assert.True(t, now.Equal(found.IssuedAt))
+
+	// a change which does not change the price is not invoiced again
+	priced := *found
+	update.Destination = "Izmir"
+	update.Invoice = &priced
+	require.NoError(t, s.UpdateReservation(ctx, update))
+	assert.Equal(t, int64(3), update.Version)
+
+	found, err = s.FindInvoice(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), found.Number)
+
+	// reservations without an invoice are invoiced once
+	inv := invoice()
+	inv.ReservationID = third.ID
+	inv.ReservationVersion = third.Version
+	inv.PNR = third.PNR
+	require.NoError(t, s.IssueInvoice(ctx, inv))
+	assert.Equal(t, int64(4), inv.Number)
+
+	again := invoice()
+	again.ReservationID = third.ID
+	again.ReservationVersion = third.Version
+	again.PNR = third.PNR
+	assert.ErrorIs(t, s.IssueInvoice(ctx, again), store.ErrConflict)
+}
+
+func testGuests(t *testing.T, db Database) {
+	ctx := context.Background()
+	s := newStore(t, db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	age := 7
+
+	res := newReservation("ABCDEFG2", 1, now.AddDate(0, 0, 3))
+	res.Guests = []store.ReservationGuest{
+		{FullName: "John Doe", Type: store.GuestTypeAdult, Nationality: "GB", DocumentType: "c2VhbGVk", DocumentNumber: "c2VhbGVk"},
+		{FullName: "Mary Doe", Type: store.GuestTypeChild, Age: &age, Nationality: "GB"},
+	}
+	require.NoError(t, s.CreateReservation(ctx, res))
+
+	found, err := s.FindReservation(ctx, res.PNR, 1)
+	require.NoError(t, err)
+	require.Len(t, found.Guests, 2)
+	assert.Equal(t, "John Doe", found.Guests[0].FullName)
+	assert.Equal(t, "c2VhbGVk", found.Guests[0].DocumentNumber)
+	assert.Nil(t, found.Guests[0].Age)
+	assert.Equal(t, "Mary Doe", found.Guests[1].FullName)
+	assert.Equal(t, 7, *found.Guests[1].Age)
+
+	// guests are kept when they are not given
+	update := newReservation(res.PNR, 1, res.CheckInDate)
+	update.Destination = "Izmir"
+	require.NoError(t, s.UpdateReservation(ctx, update))
+
+	found, err = s.FindReservationByPNR(ctx, res.PNR)
+	require.NoError(t, err)
+	assert.Len(t, found.Guests, 2)
+
+	// the guest count of a reservation with guests is changed together with its guests
+	update.GuestCount = 1
+	assert.True(t, errors.Is(s.UpdateReservation(ctx, update), store.ErrGuestCount))
+
+	update.Guests = []store.ReservationGuest{
+		{FullName: "Jane Doe", Type: store.GuestTypeAdult, Nationality: "TR"},
+	}
+	require.NoError(t, s.UpdateReservation(ctx, update))
+
+	reservations, _, err := s.FindReservations(ctx, store.ReservationQuery{UserID: 1, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, reservations, 1)
+	require.Len(t, reservations[0].Guests, 1)
+	assert.Equal(t, "Jane Doe", reservations[0].Guests[0].FullName)
+
+	audits, err := s.FindReservationAudits(ctx, res.PNR)
+	require.NoError(t, err)
+	require.Len(t, audits, 3)
+
+	changes, err := audits[2].Diff()
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), changes["guests"].From)
+	assert.Equal(t, float64(1), changes["guests"].To)
+	assert.NotContains(t, audits[0].Changes, "Doe")
+	assert.NotContains(t, audits[2].Changes, "Doe")
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"hotel-california-backend/internal/tracing"
+)
+
+const querySpanKey = "tracing:query_span"
+
+// compile-time proof of gorm plugin interface implementation
+var _ gorm.Plugin = (*QueryTracing)(nil)
+
+// QueryTracing represents gorm plugin recording a span for every query
+type QueryTracing struct {
+	tracer trace.Tracer
+}
+
+// NewQueryTracing creates and returns query tracing plugin
+func NewQueryTracing() *QueryTracing {
+	return &QueryTracing{
+		tracer: tracing.Tracer(),
+	}
+}
+
+// Name returns plugin name
+func (qt *QueryTracing) Name() string {
+	return "hotel-california:query-tracing"
+}
+
+// Initialize registers callbacks around every gorm operation
+func (qt *QueryTracing) Initialize(db *gorm.DB) error {
+	return registerCallbacks(db, "tracing", qt.before, qt.after)
+}
+
+func (qt *QueryTracing) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := qt.tracer.Start(db.Statement.Context, "gorm."+operation, trace.WithSpanKind(trace.SpanKindClient))
+
+		db.Statement.Context = ctx
+		db.InstanceSet(querySpanKey, span)
+	}
+}
+
+func (qt *QueryTracing) after(_ string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(querySpanKey)
+		if !ok {
+			return
+		}
+
+		span, ok := v.(trace.Span)
+		if !ok {
+			return
+		}
+
+		defer span.End()
+
+		// statement is recorded with placeholders, values never leave the process
+		span.SetAttributes(
+			semconv.DBSystemKey.String(db.Dialector.Name()),
+			semconv.DBSQLTable(db.Statement.Table),
+			semconv.DBStatement(db.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", db.RowsAffected),
+		)
+
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			tracing.SpanError(span, db.Error)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// webhook delivery statuses, failed deliveries stay pending until they are dead after the last attempt
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookSubscription represents a partner endpoint notified of events, UserID limits the events to reservations of
+// the user and is 0 for every reservation. EventTypes is a comma separated list.
+type WebhookSubscription struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	URL        string    `gorm:"column:url;size:2048;not null"`
+	EventTypes string    `gorm:"column:event_types;size:255;not null"`
+	Secret     string    `gorm:"column:secret;size:128;not null"`
+	UserID     int64     `gorm:"column:user_id;not null;default:0"`
+	IsActive   bool      `gorm:"column:is_active;not null"`
+	CreatedAt  time.Time `gorm:"column:createdAt;not null"`
+}
+
+// Subscribes reports whether the subscription is notified of events of the type
+func (w *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, t := range strings.Split(w.EventTypes, ",") {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery represents the delivery of an event to a subscription, Payload is the json encoded event
+type WebhookDelivery struct {
+	ID             int64                     `gorm:"column:id;primaryKey;autoIncrement"`
+	SubscriptionID int64                     `gorm:"column:subscription_id;not null;uniqueIndex:idx_webhook_deliveries_subscription_event,priority:1"`
+	Subscription   WebhookSubscription       `gorm:"foreignKey:SubscriptionID"`
+	EventID        string                    `gorm:"column:event_id;size:36;not null;uniqueIndex:idx_webhook_deliveries_subscription_event,priority:2"`
+	EventType      string                    `gorm:"column:event_type;size:64;not null"`
+	Payload        string                    `gorm:"column:payload;type:text;not null"`
+	Status         string                    `gorm:"column:status;size:16;not null"`
+	Attempts       int                       `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt  time.Time                 `gorm:"column:next_attempt_at;not null"`
+	LastStatusCode int                       `gorm:"column:last_status_code;not null;default:0"`
+	LastError      string                    `gorm:"column:last_error;type:text"`
+	CreatedAt      time.Time                 `gorm:"column:createdAt;not null"`
+	AttemptLogs    []*WebhookDeliveryAttempt `gorm:"foreignKey:DeliveryID"`
+}
+
+// WebhookDeliveryAttempt represents the log of an attempt to deliver an event, StatusCode is 0 when no response
+// was received
+type WebhookDeliveryAttempt struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	DeliveryID int64     `gorm:"column:delivery_id;not null;index:idx_webhook_delivery_attempts_delivery"`
+	StatusCode int       `gorm:"column:status_code;not null;default:0"`
+	Error      string    `gorm:"column:error;type:text"`
+	DurationMs int64     `gorm:"column:duration_ms;not null;default:0"`
+	CreatedAt  time.Time `gorm:"column:createdAt;not null"`
+}
+
+// WebhookDeliveryQuery represents filters of a delivery list, zero values match every delivery
+type WebhookDeliveryQuery struct {
+	SubscriptionID int64
+	Status         string
+	Limit          int
+}
+
+// CreateWebhookSubscription creates the subscription
+func (s *store) CreateWebhookSubscription(ctx context.Context, sub *WebhookSubscription) error {
+	return s.translateError("CreateWebhookSubscription", s.db.WithContext(ctx).Create(sub).Error)
+}
+
+// FindWebhookSubscriptions returns the active subscriptions
+func (s *store) FindWebhookSubscriptions(ctx context.Context) ([]*WebhookSubscription, error) {
+	var subs []*WebhookSubscription
+
+	if err := s.db.WithContext(ctx).Where("is_active = ?", true).Order("id").Find(&subs).Error; err != nil {
+		return nil, s.translateError("FindWebhookSubscriptions", err)
+	}
+
+	return subs, nil
+}
+
+// DeleteWebhookSubscription deactivates the subscription, its deliveries are kept for the logs
+func (s *store) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	result := s.db.WithContext(ctx).Model(&WebhookSubscription{}).
+		Where("id = ? AND is_active = ?", id, true).
+		Update("is_active", false)
+	if result.Error != nil {
+		return s.translateError("DeleteWebhookSubscription", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return &Error{Kind: ErrNotFound, Op: "DeleteWebhookSubscription", Err: errors.New("subscription not found")}
+	}
+
+	return nil
+}
+
+// CreateWebhookDelivery creates the delivery, ErrConflict is returned when the event was already queued for the
+// subscription
+func (s *store) CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	return s.translateError("CreateWebhookDelivery", s.db.WithContext(ctx).Omit("Subscription").Create(d).Error)
+}
+
+// DueWebhookDeliveries returns at most limit pending deliveries whose next attempt is due, with their subscriptions
+func (s *store) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error) {
+	var ds []*WebhookDelivery
+
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", WebhookDeliveryPending, now).
+		Order("next_attempt_at").
+		Order("id").
+		Limit(limit).
+		Preload("Subscription").
+		Find(&ds).Error
+	if err != nil {
+		return nil, s.translateError("DueWebhookDeliveries", err)
+	}
+
+	return ds, nil
+}
+
+// ClaimWebhookDelivery postpones the next attempt of the delivery to until, so that other instances do not attempt
+// it at the same time. It reports false when another instance claimed the delivery first.
+func (s *store) ClaimWebhookDelivery(ctx context.Context, d *WebhookDelivery, until time.Time) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", d.ID, WebhookDeliveryPending, d.NextAttemptAt).
+		Update("next_attempt_at", until)
+	if result.Error != nil {
+		return false, s.translateError("ClaimWebhookDelivery", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	d.NextAttemptAt = until
+
+	return true, nil
+}
+
+// RecordWebhookAttempt logs the attempt and stores the status, attempts and next attempt of the delivery
+func (s *store) RecordWebhookAttempt(ctx context.Context, d *WebhookDelivery, attempt *WebhookDeliveryAttempt) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attempt.DeliveryID = d.ID
+
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&WebhookDelivery{}).Where("id = ?", d.ID).Updates(map[string]interface{}{
+			"status":           d.Status,
+			"attempts":         d.Attempts,
+			"next_attempt_at":  d.NextAttemptAt,
+			"last_status_code": d.LastStatusCode,
+			"last_error":       d.LastError,
+		}).Error
+	})
+
+	return s.translateError("RecordWebhookAttempt", err)
+}
+
+// FindWebhookDeliveries returns the latest deliveries matching the query with their attempt logs
+func (s *store) FindWebhookDeliveries(ctx context.Context, q WebhookDeliveryQuery) ([]*WebhookDelivery, error) {
+	db := s.db.WithContext(ctx)
+
+	if q.SubscriptionID != 0 {
+		db = db.Where("subscription_id = ?", q.SubscriptionID)
+	}
+
+	if q.Status != "" {
+		db = db.Where("status = ?", q.Status)
+	}
+
+	var ds []*WebhookDelivery
+
+	err := db.Order("id DESC").
+		Limit(q.Limit).
+		Preload("AttemptLogs", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Find(&ds).Error
+	if err != nil {
+		return nil, s.translateError("FindWebhookDeliveries", err)
+	}
+
+	return ds, nil
+}
+
+// RedeliverWebhookDelivery queues the delivery again with a fresh count of attempts
+func (s *store) RedeliverWebhookDelivery(ctx context.Context, id int64, now time.Time) error {
+	result := s.db.WithContext(ctx).Model(&WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+	})
+	if result.Error != nil {
+		return s.translateError("RedeliverWebhookDelivery", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return &Error{Kind: ErrNotFound, Op: "RedeliverWebhookDelivery", Err: errors.New("delivery not found")}
+	}
+
+	return nil
+}
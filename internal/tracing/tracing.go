@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"hotel-california-backend/configs/envvars"
+	"os"
+)
+
+// TracerName is the instrumentation name of the service's spans
+const TracerName = "hotel-california-backend"
+
+// exporters
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// errors
+var (
+	ErrUnknownExporter = errors.New("unknown tracing exporter")
+)
+
+// Tracer returns the service's tracer from the global tracer provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Setup registers the global tracer provider exporting spans as configured and W3C trace context propagation,
+// returned shutdown flushes remaining spans
+func Setup(ctx context.Context, t envvars.Tracing, environment string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(t.ServiceName),
+		semconv.DeploymentEnvironment(environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("creating tracing resource failed, %s", err.Error())
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.SampleRatio))),
+	}
+
+	closeExporter := func() error { return nil }
+
+	switch t.Exporter {
+	case ExporterNone, "":
+	case ExporterOTLP:
+		eo := []otlptracehttp.Option{otlptracehttp.WithEndpoint(t.OTLPEndpoint)}
+		if t.OTLPInsecure {
+			eo = append(eo, otlptracehttp.WithInsecure())
+		}
+
+		e, err := otlptracehttp.New(ctx, eo...)
+		if err != nil {
+			return nil, fmt.Errorf("creating otlp trace exporter failed, %s", err.Error())
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(e))
+	case ExporterStdout:
+		e, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("creating stdout trace exporter failed, %s", err.Error())
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(e))
+	case ExporterFile:
+		f, err := os.OpenFile(t.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("opening trace file failed, %s", err.Error())
+		}
+
+		e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("creating file trace exporter failed, %s", err.Error())
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(e))
+		closeExporter = f.Close
+	default:
+		return nil, fmt.Errorf("%w, %s", ErrUnknownExporter, t.Exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		return errors.Join(tp.Shutdown(ctx), closeExporter())
+	}, nil
+}
+
+// SpanError marks the span as failed with the error
+func SpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -5,6 +5,7 @@ import (
 
 	kitTransport "github.com/go-kit/kit/transport"
 	"github.com/go-kit/log"
+	"hotel-california-backend/internal/requestid"
 )
 
 // compile-time proof of go-kit transport's error handler interface implementation
@@ -26,5 +27,5 @@ func NewErrorHandler(l log.Logger, endpointName string) *ErrorHandler {
 
 // Handle logs error
 func (eh *ErrorHandler) Handle(ctx context.Context, err error) {
-	_ = eh.l.Log("error", err.Error())
+	_ = eh.l.Log("endpoint", eh.EndpointName, "request_id", requestid.FromContext(ctx), "error", err.Error())
 }
@@ -0,0 +1,87 @@
+package httptransport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var clientIPKey = struct{ Key string }{"client-ip"}
+
+// withClientIP resolves the ip address of the client once per request. Forwarding headers are only trusted when the
+// request comes from one of the proxies, and X-Forwarded-For is read from the right, so that the client is the first
+// address not belonging to a proxy and addresses prepended by the client itself are skipped.
+func withClientIP(proxies []*net.IPNet, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ip := resolveClientIP(r, proxies)
+
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), clientIPKey, ip)))
+	})
+}
+
+// clientIP returns the ip address of the client resolved by withClientIP, or the remote address when it was not
+func clientIP(ctx context.Context, r *http.Request) string {
+	if ip, ok := ctx.Value(clientIPKey).(string); ok {
+		return ip
+	}
+
+	return remoteIP(r)
+}
+
+func resolveClientIP(r *http.Request, proxies []*net.IPNet) string {
+	ip := remoteIP(r)
+	if !trusted(ip, proxies) {
+		return ip
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := net.ParseIP(strings.TrimSpace(hops[i]))
+
+			// the hop is made up by someone before the proxy, the last address seen is the best known
+			if hop == nil {
+				return ip
+			}
+
+			ip = hop.String()
+			if !trusted(ip, proxies) {
+				return ip
+			}
+		}
+
+		return ip
+	}
+
+	if realIP := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); realIP != nil {
+		return realIP.String()
+	}
+
+	return ip
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func trusted(ip string, proxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, p := range proxies {
+		if p.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
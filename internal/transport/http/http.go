@@ -11,35 +11,86 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/iris-contrib/schema"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	hotelcalifornia "hotel-california-backend"
 	apierror "hotel-california-backend/internal/api-error"
 	"hotel-california-backend/internal/endpoints"
 	"hotel-california-backend/internal/localization"
+	"hotel-california-backend/internal/tracing"
 	"hotel-california-backend/internal/transport"
+	"mime"
+	"net"
 	"net/http"
 	"reflect"
+	"strings"
 )
 
 // endpoint names
 const (
-	health            = "Health"
-	signIn            = "SignIn"
-	createReservation = "CreateReservation"
-	updateReservation = "UpdateReservation"
-	findReservation   = "FindReservation"
-	findReservations  = "FindReservations"
+	health             = "Health"
+	liveness           = "Liveness"
+	readiness          = "Readiness"
+	signIn             = "SignIn"
+	createReservation  = "CreateReservation"
+	updateReservation  = "UpdateReservation"
+	cancelReservation  = "CancelReservation"
+	confirmReservation = "ConfirmReservation"
+	findReservation    = "FindReservation"
+	findReservations   = "FindReservations"
+
+	findReservationHistory = "FindReservationHistory"
+
+	createWebhookSubscription = "CreateWebhookSubscription"
+	findWebhookSubscriptions  = "FindWebhookSubscriptions"
+	deleteWebhookSubscription = "DeleteWebhookSubscription"
+	findWebhookDeliveries     = "FindWebhookDeliveries"
+	redeliverWebhookDelivery  = "RedeliverWebhookDelivery"
+
+	checkInReservation = "CheckInReservation"
+
+	exportReservations         = "ExportReservations"
+	exportReservation          = "ExportReservation"
+	createCalendarSubscription = "CreateCalendarSubscription"
+	revokeCalendarSubscription = "RevokeCalendarSubscription"
+
+	exportReservationConfirmation = "ExportReservationConfirmation"
+
+	lookupReservation = "LookupReservation"
 )
 
 // decoder tags
 const (
 	headerTag = "header"
 	queryTag  = "query"
+	pathTag   = "path"
+	jsonTag   = "json"
 )
 
 const invalidResponseError = "invalid response"
 
-// MakeHTTPHandler makes and returns http handler
-func MakeHTTPHandler(l log.Logger, s hotelcalifornia.Service) http.Handler {
+// structValidator is shared since validator caches struct metadata per type
+var structValidator = newValidator()
+
+// localizedRules lists validation rules with dedicated localized messages
+var localizedRules = map[string]struct{}{
+	"required": {},
+	"min":      {},
+	"max":      {},
+	"len":      {},
+	"gt":       {},
+	"gte":      {},
+	"lt":       {},
+	"lte":      {},
+	"oneof":    {},
+	"email":    {},
+	"url":      {},
+	"datetime": {},
+	"alphanum": {},
+	"numeric":  {},
+}
+
+// MakeHTTPHandler makes and returns http handler, forwarding headers are trusted only from the trusted proxies
+func MakeHTTPHandler(l log.Logger, s hotelcalifornia.Service, trustedProxies []*net.IPNet) http.Handler {
 	es := endpoints.MakeEndpoints(s)
 
 	r := mux.NewRouter()
@@ -49,6 +100,16 @@ func MakeHTTPHandler(l log.Logger, s hotelcalifornia.Service) http.Handler {
 		makeHealthHandler(es.HealthEndpoint, makeDefaultServerOptions(l, health)),
 	)
 
+	// GET /health/live
+	r.Methods(http.MethodGet).Path("/health/live").Handler(
+		makeLivenessHandler(es.LivenessEndpoint, makeDefaultServerOptions(l, liveness)),
+	)
+
+	// GET /health/ready
+	r.Methods(http.MethodGet).Path("/health/ready").Handler(
+		makeReadinessHandler(es.ReadinessEndpoint, makeDefaultServerOptions(l, readiness)),
+	)
+
 	// hotel california router
 	router := r.PathPrefix("/v1/").Subrouter()
 
@@ -67,6 +128,21 @@ func MakeHTTPHandler(l log.Logger, s hotelcalifornia.Service) http.Handler {
 		makeUpdateReservationHandler(es.UpdateReservationEndpoint, makeDefaultServerOptions(l, updateReservation)),
 	)
 
+	// POST /reservation/cancel
+	router.Methods(http.MethodPost).Path("/reservation/cancel").Handler(
+		makeCancelReservationHandler(es.CancelReservationEndpoint, makeDefaultServerOptions(l, cancelReservation)),
+	)
+
+	// POST /reservation/confirm
+	router.Methods(http.MethodPost).Path("/reservation/confirm").Handler(
+		makeConfirmReservationHandler(es.ConfirmReservationEndpoint, makeDefaultServerOptions(l, confirmReservation)),
+	)
+
+	// GET /reservation/history
+	router.Methods(http.MethodGet).Path("/reservation/history").Handler(
+		makeFindReservationHistoryHandler(es.FindReservationHistoryEndpoint, makeDefaultServerOptions(l, findReservationHistory)),
+	)
+
 	// GET /reservation
 	router.Methods(http.MethodGet).Path("/reservation").Handler(
 		makeFindReservationHandler(es.FindReservationEndpoint, makeDefaultServerOptions(l, findReservation)),
@@ -77,6 +153,76 @@ func MakeHTTPHandler(l log.Logger, s hotelcalifornia.Service) http.Handler {
 		makeFindReservationsHandler(es.FindReservationsEndpoint, makeDefaultServerOptions(l, findReservations)),
 	)
 
+	// POST /admin/webhooks
+	router.Methods(http.MethodPost).Path("/admin/webhooks").Handler(
+		makeCreateWebhookSubscriptionHandler(es.CreateWebhookSubscriptionEndpoint, makeDefaultServerOptions(l, createWebhookSubscription)),
+	)
+
+	// GET /admin/webhooks
+	router.Methods(http.MethodGet).Path("/admin/webhooks").Handler(
+		makeFindWebhookSubscriptionsHandler(es.FindWebhookSubscriptionsEndpoint, makeDefaultServerOptions(l, findWebhookSubscriptions)),
+	)
+
+	// POST /admin/webhooks/delete
+	router.Methods(http.MethodPost).Path("/admin/webhooks/delete").Handler(
+		makeDeleteWebhookSubscriptionHandler(es.DeleteWebhookSubscriptionEndpoint, makeDefaultServerOptions(l, deleteWebhookSubscription)),
+	)
+
+	// GET /admin/webhooks/deliveries
+	router.Methods(http.MethodGet).Path("/admin/webhooks/deliveries").Handler(
+		makeFindWebhookDeliveriesHandler(es.FindWebhookDeliveriesEndpoint, makeDefaultServerOptions(l, findWebhookDeliveries)),
+	)
+
+	// POST /admin/webhooks/deliveries/redeliver
+	router.Methods(http.MethodPost).Path("/admin/webhooks/deliveries/redeliver").Handler(
+		makeRedeliverWebhookDeliveryHandler(es.RedeliverWebhookDeliveryEndpoint, makeDefaultServerOptions(l, redeliverWebhookDelivery)),
+	)
+
+	// POST /admin/reservation/check-in
+	router.Methods(http.MethodPost).Path("/admin/reservation/check-in").Handler(
+		makeCheckInReservationHandler(es.CheckInReservationEndpoint, makeDefaultServerOptions(l, checkInReservation)),
+	)
+
+	// GET /reservations.ics
+	router.Methods(http.MethodGet).Path("/reservations.ics").Handler(
+		makeExportReservationsHandler(es.ExportReservationsEndpoint, makeDefaultServerOptions(l, exportReservations)),
+	)
+
+	// GET /reservation.ics
+	router.Methods(http.MethodGet).Path("/reservation.ics").Handler(
+		makeExportReservationHandler(es.ExportReservationEndpoint, makeDefaultServerOptions(l, exportReservation)),
+	)
+
+	// POST /calendar/subscription
+	router.Methods(http.MethodPost).Path("/calendar/subscription").Handler(
+		makeCreateCalendarSubscriptionHandler(es.CreateCalendarSubscriptionEndpoint, makeDefaultServerOptions(l, createCalendarSubscription)),
+	)
+
+	// POST /calendar/subscription/revoke
+	router.Methods(http.MethodPost).Path("/calendar/subscription/revoke").Handler(
+		makeRevokeCalendarSubscriptionHandler(es.RevokeCalendarSubscriptionEndpoint, makeDefaultServerOptions(l, revokeCalendarSubscription)),
+	)
+
+	// GET /reservation/{pnr}/confirmation.pdf
+	router.Methods(http.MethodGet).Path("/reservation/{pnr}/confirmation.pdf").Handler(
+		makeExportReservationConfirmationHandler(es.ExportReservationConfirmationEndpoint, makeDefaultServerOptions(l, exportReservationConfirmation)),
+	)
+
+	// GET /reservation/lookup
+	router.Methods(http.MethodGet).Path("/reservation/lookup").Handler(
+		makeLookupReservationHandler(es.LookupReservationEndpoint, makeDefaultServerOptions(l, lookupReservation)),
+	)
+
+	return withRequestID(withClientIP(trustedProxies, r))
+}
+
+// MakeAdminHandler makes and returns admin http handler
+func MakeAdminHandler() http.Handler {
+	r := mux.NewRouter()
+
+	// GET /metrics
+	r.Methods(http.MethodGet).Path("/metrics").Handler(promhttp.Handler())
+
 	return r
 }
 
@@ -86,6 +232,18 @@ func makeHealthHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption
 	return h
 }
 
+func makeLivenessHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.LivenessRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeReadinessHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.ReadinessRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
 func makeSignInHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
 	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.SignInRequest{}), encoder, serverOptions...)
 
@@ -104,6 +262,24 @@ func makeUpdateReservationHandler(e endpoint.Endpoint, serverOptions []kithttp.S
 	return h
 }
 
+func makeCancelReservationHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.CancelReservationRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeConfirmReservationHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.ConfirmReservationRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeFindReservationHistoryHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.FindReservationHistoryRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
 func makeFindReservationHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
 	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.FindReservationRequest{}), encoder, serverOptions...)
 
@@ -116,16 +292,91 @@ func makeFindReservationsHandler(e endpoint.Endpoint, serverOptions []kithttp.Se
 	return h
 }
 
+func makeCreateWebhookSubscriptionHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.CreateWebhookSubscriptionRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeFindWebhookSubscriptionsHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.FindWebhookSubscriptionsRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeDeleteWebhookSubscriptionHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.DeleteWebhookSubscriptionRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeFindWebhookDeliveriesHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.FindWebhookDeliveriesRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeRedeliverWebhookDeliveryHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.RedeliverWebhookDeliveryRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeCheckInReservationHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.CheckInReservationRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeExportReservationsHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.ExportReservationsRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeExportReservationHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.ExportReservationRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeCreateCalendarSubscriptionHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.CreateCalendarSubscriptionRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeRevokeCalendarSubscriptionHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.RevokeCalendarSubscriptionRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeExportReservationConfirmationHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.ExportReservationConfirmationRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
+func makeLookupReservationHandler(e endpoint.Endpoint, serverOptions []kithttp.ServerOption) http.Handler {
+	h := kithttp.NewServer(e, makeDecoder(hotelcalifornia.LookupReservationRequest{}), encoder, serverOptions...)
+
+	return h
+}
+
 func makeDefaultServerOptions(l log.Logger, endpointName string) []kithttp.ServerOption {
-	return []kithttp.ServerOption{
+	return append(makeTracingServerOptions(endpointName),
 		kithttp.ServerErrorEncoder(errorEncoder),
 		kithttp.ServerErrorHandler(transport.NewErrorHandler(l, endpointName)),
 		kithttp.ServerBefore(localization.AddLocalizerToContext),
-	}
+	)
 }
 
 func makeDecoder(emptyReq interface{}) kithttp.DecodeRequestFunc {
-	return func(_ context.Context, r *http.Request) (interface{}, error) {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		_, span := tracing.Tracer().Start(ctx, "HTTP decode")
+		defer span.End()
+
 		req := reflect.New(reflect.TypeOf(emptyReq)).Interface()
 
 		if err := newHeaderDecoder().Decode(req, r.Header); err != nil {
@@ -136,18 +387,25 @@ func makeDecoder(emptyReq interface{}) kithttp.DecodeRequestFunc {
 			return nil, fmt.Errorf("decoding request query failed, %s", err.Error())
 		}
 
+		if err := newPathDecoder().Decode(req, pathValues(r)); err != nil {
+			return nil, fmt.Errorf("decoding request path failed, %s", err.Error())
+		}
+
 		if requestHasBody(r) {
 			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
 				return nil, fmt.Errorf("decoding request body failed, %s", err.Error())
 			}
 		}
 
-		if err := validate(req); err != nil {
-			apiError := apierror.NewValidationError(err.Error(), "")
-			apiError.BaseError = err
+		if apiError := validate(req); apiError != nil {
+			tracing.SpanError(span, apiError.BaseError)
 			return nil, apiError
 		}
 
+		if rq, ok := req.(hotelcalifornia.Request); ok {
+			rq.SetIPAddress(clientIP(ctx, r))
+		}
+
 		return req, nil
 	}
 }
@@ -160,6 +418,22 @@ func newQueryDecoder() *schema.Decoder {
 	return newDecoder(queryTag)
 }
 
+func newPathDecoder() *schema.Decoder {
+	return newDecoder(pathTag)
+}
+
+// pathValues returns the variables of the route path, e.g. pnr of /reservation/{pnr}/confirmation.pdf
+func pathValues(r *http.Request) map[string][]string {
+	vars := mux.Vars(r)
+
+	values := make(map[string][]string, len(vars))
+	for k, v := range vars {
+		values[k] = []string{v}
+	}
+
+	return values
+}
+
 func newDecoder(tag string) *schema.Decoder {
 	decoder := schema.NewDecoder()
 	decoder.IgnoreUnknownKeys(true)
@@ -175,15 +449,78 @@ func requestHasBody(r *http.Request) bool {
 	return r.Body != http.NoBody
 }
 
-func validate(req interface{}) error {
-	errs := validator.New().Struct(req)
+func validate(req interface{}) *apierror.APIError {
+	errs := structValidator.Struct(req)
 	if errs == nil {
 		return nil
 	}
 
-	firstErr := errs.(validator.ValidationErrors)[0]
+	var ves validator.ValidationErrors
+	if !errors.As(errs, &ves) {
+		apiError := apierror.NewValidationError(errs.Error(), localization.ValidationError)
+		apiError.BaseError = errs
+		return apiError
+	}
+
+	fields := make([]*apierror.FieldError, 0, len(ves))
+	for _, ve := range ves {
+		fields = append(fields, newFieldError(ve))
+	}
 
-	return errors.New("validation failed, tag: " + firstErr.Tag() + ", field: " + firstErr.Field())
+	apiError := apierror.NewValidationError("validation failed", localization.ValidationError, fields...)
+	apiError.BaseError = errs
+
+	return apiError
+}
+
+func newFieldError(ve validator.FieldError) *apierror.FieldError {
+	field := fieldName(ve)
+
+	key := localization.ValidationFieldPrefix + ve.Tag()
+	if _, ok := localizedRules[ve.Tag()]; !ok {
+		key = localization.ValidationFieldInvalid
+	}
+
+	return &apierror.FieldError{
+		Field:               field,
+		Rule:                ve.Tag(),
+		Param:               ve.Param(),
+		Message:             fmt.Sprintf("%s failed on the '%s' rule", field, ve.Tag()),
+		MessageLocalizerKey: key,
+		TemplateData: map[string]interface{}{
+			"Field": field,
+			"Param": ve.Param(),
+		},
+	}
+}
+
+// fieldName returns the field's namespace without the root struct name, e.g. checkInDate
+func fieldName(ve validator.FieldError) string {
+	ns := ve.Namespace()
+
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+
+	return ve.Field()
+}
+
+// newValidator creates validator that reports fields by the name clients send them with
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{jsonTag, queryTag, pathTag, headerTag} {
+			name := strings.Split(f.Tag.Get(tag), ",")[0]
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+
+		return f.Name
+	})
+
+	return v
 }
 
 func encoder(ctx context.Context, rw http.ResponseWriter, response interface{}) error {
@@ -202,11 +539,43 @@ func encoder(ctx context.Context, rw http.ResponseWriter, response interface{})
 
 	lr := r.Localize(l)
 
+	if h, ok := response.(hotelcalifornia.Headerer); ok {
+		for k, vs := range h.Headers() {
+			for _, v := range vs {
+				rw.Header().Add(k, v)
+			}
+		}
+	}
+
+	if f, ok := response.(hotelcalifornia.Filer); ok && f.File() != nil {
+		return encodeFile(rw, f.File())
+	}
+
+	statusCode := http.StatusOK
+	if sc, ok := response.(hotelcalifornia.StatusCoder); ok {
+		statusCode = sc.StatusCode()
+	}
+
 	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
-	rw.WriteHeader(http.StatusOK)
+	rw.WriteHeader(statusCode)
 	return json.NewEncoder(rw).Encode(lr)
 }
 
+// encodeFile writes the file as an attachment named after it
+func encodeFile(rw http.ResponseWriter, f *hotelcalifornia.File) error {
+	rw.Header().Set("Content-Type", f.ContentType)
+
+	if f.Name != "" {
+		rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.Name}))
+	}
+
+	rw.WriteHeader(http.StatusOK)
+
+	_, err := rw.Write(f.Content)
+
+	return err
+}
+
 func errorEncoder(ctx context.Context, err error, rw http.ResponseWriter) {
 	var apiErr *apierror.APIError
 
@@ -215,9 +584,8 @@ func errorEncoder(ctx context.Context, err error, rw http.ResponseWriter) {
 		apiErr = apierror.DefaultInternalServerError
 	}
 
-	l := localization.GetLocalizerFromContext(ctx)
-
-	apiErr.Localize(l)
+	// package level errors are returned by concurrent requests, so they are localized into a copy
+	apiErr = apiErr.Localized(localization.GetLocalizerFromContext(ctx))
 
 	er := errorResponse{
 		Data:   nil,
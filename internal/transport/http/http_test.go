@@ -0,0 +1,251 @@
+package httptransport
+
+import (
+	"context"
+	"errors"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	hotelcalifornia "hotel-california-backend"
+	"hotel-california-backend/configs/envvars"
+	apierror "hotel-california-backend/internal/api-error"
+	"hotel-california-backend/internal/localization"
+	storemock "hotel-california-backend/internal/mock/store"
+	"hotel-california-backend/internal/requestid"
+	"hotel-california-backend/internal/service"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMakeDecoder_ValidationErrors(t *testing.T) {
+	err := localization.InitializeBundle(envvars.Localization{
+		LanguageFilesDirectory: "../../localization/language-files",
+	})
+	require.NoError(t, err)
+
+	body := `{"destination":"Istanbul","checkInDate":"2024-03-29","guestCount":1}`
+
+	r := httptest.NewRequest("POST", "/v1/reservation/new", strings.NewReader(body))
+	r.Header.Set("Accept-Language", "en")
+
+	ctx := localization.AddLocalizerToContext(context.Background(), r)
+
+	_, err = makeDecoder(hotelcalifornia.CreateReservationRequest{})(ctx, r)
+
+	var apiErr *apierror.APIError
+	require.True(t, errors.As(err, &apiErr))
+
+	apiErr.Localize(localization.GetLocalizerFromContext(ctx))
+
+	assert.Equal(t, apierror.NameValidationError, apiErr.Name)
+	assert.Equal(t, "One or more fields are invalid", apiErr.Message)
+	assert.Equal(t, []*apierror.FieldError{
+		{
+			Field:               "checkOutDate",
+			Rule:                "required",
+			Message:             "checkOutDate is required",
+			MessageLocalizerKey: "validation-field-required",
+			TemplateData:        map[string]interface{}{"Field": "checkOutDate", "Param": ""},
+		},
+		{
+			Field:               "accommodation",
+			Rule:                "required",
+			Message:             "accommodation is required",
+			MessageLocalizerKey: "validation-field-required",
+			TemplateData:        map[string]interface{}{"Field": "accommodation", "Param": ""},
+		},
+	}, apiErr.Fields)
+}
+
+func TestMakeDecoder_ValidationErrorsLocalized(t *testing.T) {
+	err := localization.InitializeBundle(envvars.Localization{
+		LanguageFilesDirectory: "../../localization/language-files",
+	})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest("GET", "/v1/reservation", nil)
+	r.Header.Set("Accept-Language", "tr")
+
+	ctx := localization.AddLocalizerToContext(context.Background(), r)
+
+	_, err = makeDecoder(hotelcalifornia.FindReservationRequest{})(ctx, r)
+
+	var apiErr *apierror.APIError
+	require.True(t, errors.As(err, &apiErr))
+
+	apiErr.Localize(localization.GetLocalizerFromContext(ctx))
+
+	require.Len(t, apiErr.Fields, 1)
+	assert.Equal(t, "pnr", apiErr.Fields[0].Field)
+	assert.Equal(t, "pnr alanı zorunludur", apiErr.Fields[0].Message)
+}
+
+func TestMakeHTTPHandler_ContinuesTraceContext(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	s := service.NewService("dev", log.NewNopLogger(), storemock.NewStore(), envvars.JWTToken{}, envvars.Reservation{})
+	h := MakeHTTPHandler(log.NewNopLogger(), s, nil)
+
+	r := httptest.NewRequest("GET", "/health", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+
+	require.Equal(t, http.StatusOK, rw.Code)
+
+	var server sdktrace.ReadOnlySpan
+	for _, span := range sr.Ended() {
+		if span.Name() == "HTTP "+health {
+			server = span
+		}
+	}
+
+	require.NotNil(t, server)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+}
+
+func TestMakeHTTPHandler_RequestID(t *testing.T) {
+	s := service.NewService("dev", log.NewNopLogger(), storemock.NewStore(), envvars.JWTToken{}, envvars.Reservation{})
+	h := MakeHTTPHandler(log.NewNopLogger(), s, nil)
+
+	r := httptest.NewRequest("GET", "/health", nil)
+	r.Header.Set(requestid.Header, "4bf92f35-77b3")
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+
+	assert.Equal(t, "4bf92f35-77b3", rw.Header().Get(requestid.Header))
+
+	r = httptest.NewRequest("GET", "/health", nil)
+	r.Header.Set(requestid.Header, "invalid request id")
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+
+	assert.NotEmpty(t, rw.Header().Get(requestid.Header))
+	assert.NotEqual(t, "invalid request id", rw.Header().Get(requestid.Header))
+}
+
+func TestClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+
+	resolve := func(r *http.Request) string {
+		var ip string
+		withClientIP([]*net.IPNet{proxies}, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			ip = clientIP(r.Context(), r)
+		})).ServeHTTP(httptest.NewRecorder(), r)
+
+		return ip
+	}
+
+	r := httptest.NewRequest("GET", "/v1/reservation/history", nil)
+	r.RemoteAddr = "10.0.0.9:51234"
+
+	assert.Equal(t, "10.0.0.9", resolve(r))
+
+	// the client is the rightmost address not belonging to a proxy, addresses the client sends are skipped
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7, 10.0.0.2")
+	assert.Equal(t, "203.0.113.7", resolve(r))
+
+	r.Header.Set("X-Forwarded-For", "unknown, 10.0.0.2")
+	assert.Equal(t, "10.0.0.2", resolve(r))
+
+	r.Header.Del("X-Forwarded-For")
+	r.Header.Set("X-Real-IP", "203.0.113.7")
+	assert.Equal(t, "203.0.113.7", resolve(r))
+
+	// forwarding headers of clients connecting directly are ignored
+	r.RemoteAddr = "198.51.100.1:51234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	assert.Equal(t, "198.51.100.1", resolve(r))
+}
+
+func TestMakeDecoder_SetsIPAddress(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/reservation/history?pnr=XYZ2345X", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	req, err := makeDecoder(hotelcalifornia.FindReservationHistoryRequest{})(context.Background(), r)
+	require.NoError(t, err)
+
+	assert.Equal(t, "203.0.113.7", req.(*hotelcalifornia.FindReservationHistoryRequest).IPAddress)
+}
+
+func TestEncoder_File(t *testing.T) {
+	rw := httptest.NewRecorder()
+
+	err := encoder(context.Background(), rw, hotelcalifornia.ExportReservationResponse{
+		Calendar: &hotelcalifornia.File{
+			Name:        "HC7K2M9Q.ics",
+			ContentType: "text/calendar; charset=utf-8",
+			Content:     []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", rw.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=HC7K2M9Q.ics`, rw.Header().Get("Content-Disposition"))
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", rw.Body.String())
+}
+
+func TestMakeDecoder_GuestValidationErrors(t *testing.T) {
+	body := `{"destination":"Istanbul","checkInDate":"2024-03-29","checkOutDate":"2024-03-31","accommodation":"city","guestCount":2,
+		"guests":[{"fullName":"Işıl Güneş","type":"adult","nationality":"TR","documentNumber":"U1234567"},{"fullName":"Ada Güneş","type":"child","nationality":"tr"}]}`
+
+	r := httptest.NewRequest("POST", "/v1/reservation/new", strings.NewReader(body))
+
+	_, err := makeDecoder(hotelcalifornia.CreateReservationRequest{})(context.Background(), r)
+
+	var apiErr *apierror.APIError
+	require.True(t, errors.As(err, &apiErr))
+
+	fields := make(map[string]string, len(apiErr.Fields))
+	for _, f := range apiErr.Fields {
+		fields[f.Field] = f.Rule
+	}
+
+	assert.Equal(t, map[string]string{
+		"guests[0].documentType": "required_with",
+		"guests[1].age":          "required_if",
+		"guests[1].nationality":  "iso3166_1_alpha2",
+	}, fields)
+}
+
+func TestErrorEncoder_LocalizesCopy(t *testing.T) {
+	err := localization.InitializeBundle(envvars.Localization{
+		LanguageFilesDirectory: "../../localization/language-files",
+	})
+	require.NoError(t, err)
+
+	message := apierror.DefaultNotFoundError.Message
+
+	bodies := map[string]string{}
+
+	for _, lang := range []string{"tr", "en"} {
+		r := httptest.NewRequest("GET", "/v1/reservation", nil)
+		r.Header.Set("Accept-Language", lang)
+
+		rw := httptest.NewRecorder()
+		errorEncoder(localization.AddLocalizerToContext(context.Background(), r), apierror.DefaultNotFoundError, rw)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		bodies[lang] = rw.Body.String()
+	}
+
+	// the shared error is left as it is, so the languages do not leak into each other
+	assert.Equal(t, message, apierror.DefaultNotFoundError.Message)
+	assert.NotEqual(t, bodies["tr"], bodies["en"])
+}
@@ -0,0 +1,20 @@
+package httptransport
+
+import (
+	"hotel-california-backend/internal/requestid"
+	"net/http"
+)
+
+// withRequestID propagates the caller's request id or generates one, and returns it in the response headers
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		rw.Header().Set(requestid.Header, id)
+
+		next.ServeHTTP(rw, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
@@ -0,0 +1,43 @@
+package httptransport
+
+import (
+	"context"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"hotel-california-backend/internal/tracing"
+	"net/http"
+)
+
+// makeTracingServerOptions returns server options recording a span per request which continues the caller's W3C trace context
+func makeTracingServerOptions(endpointName string) []kithttp.ServerOption {
+	return []kithttp.ServerOption{
+		kithttp.ServerBefore(func(ctx context.Context, r *http.Request) context.Context {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+
+			ctx, _ = tracing.Tracer().Start(ctx, "HTTP "+endpointName,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+
+			return ctx
+		}),
+		kithttp.ServerFinalizer(func(ctx context.Context, code int, _ *http.Request) {
+			span := trace.SpanFromContext(ctx)
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+
+			if code >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(code))
+			}
+
+			span.End()
+		}),
+	}
+}
@@ -0,0 +1,295 @@
+// Package webhook delivers domain events to the webhook subscriptions of partners
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"hotel-california-backend/internal/events"
+	"hotel-california-backend/internal/store"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// http headers sent with deliveries, the signature is computed over the timestamp and the body so that receivers
+// can reject replayed deliveries
+const (
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// signaturePrefix names the algorithm of the signature header
+const signaturePrefix = "sha256="
+
+// defaults used for zero options
+const (
+	defaultInterval    = time.Second
+	defaultBatchSize   = 50
+	defaultTimeout     = 10 * time.Second
+	defaultMaxAttempts = 8
+	defaultBackoff     = 30 * time.Second
+	defaultMaxBackoff  = time.Hour
+)
+
+// maxErrorLength limits the response bodies kept in delivery logs
+const maxErrorLength = 1024
+
+// Options represents delivery options, the n-th failed attempt of a delivery is retried after Backoff*2^(n-1) capped
+// at MaxBackoff and the delivery is dead after MaxAttempts
+type Options struct {
+	Interval    time.Duration
+	BatchSize   int
+	Timeout     time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Dispatcher queues events for the subscriptions they match and delivers them
+type Dispatcher struct {
+	l      log.Logger
+	ms     store.Store
+	client *http.Client
+	opts   Options
+	now    func() time.Time
+}
+
+// NewDispatcher creates and returns dispatcher
+func NewDispatcher(l log.Logger, ms store.Store, opts Options) *Dispatcher {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultBackoff
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+
+	return &Dispatcher{
+		l:      l,
+		ms:     ms,
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Handle queues a delivery of the event for every active subscription of its type, it is subscribed to the event
+// bus. Events relayed again are not queued twice for a subscription.
+func (d *Dispatcher) Handle(ctx context.Context, e events.Event) error {
+	subs, err := d.ms.FindWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var data events.ReservationData
+	if err = json.Unmarshal(e.Data, &data); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	now := d.now()
+
+	for _, sub := range subs {
+		if !sub.Subscribes(e.Type) || (sub.UserID != 0 && sub.UserID != data.UserID) {
+			continue
+		}
+
+		err = d.ms.CreateWebhookDelivery(ctx, &store.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        e.ID,
+			EventType:      e.Type,
+			Payload:        string(payload),
+			Status:         store.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+		if err != nil && !errors.Is(err, store.ErrConflict) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run delivers due deliveries every interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	t := time.NewTicker(d.opts.Interval)
+	defer t.Stop()
+
+	for {
+		// full batches are followed by the next one without waiting
+		for {
+			n, err := d.Deliver(ctx)
+			if err != nil || n < d.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Deliver attempts a batch of due deliveries and returns how many were due. Deliveries attempted by another
+// instance are skipped.
+func (d *Dispatcher) Deliver(ctx context.Context) (int, error) {
+	ds, err := d.ms.DueWebhookDeliveries(ctx, d.now(), d.opts.BatchSize)
+	if err != nil {
+		d.log(err, "DueWebhookDeliveries", nil)
+		return 0, err
+	}
+
+	for _, delivery := range ds {
+		// the claim outlives the attempt so that a crashed instance does not block the delivery for good, it is taken
+		// from the claim time since the previous attempts of the batch may have taken up to their timeouts
+		claimed, err := d.ms.ClaimWebhookDelivery(ctx, delivery, d.now().Add(2*d.opts.Timeout))
+		if err != nil {
+			d.log(err, "ClaimWebhookDelivery", delivery)
+			return 0, err
+		}
+
+		if claimed {
+			d.attempt(ctx, delivery)
+		}
+	}
+
+	return len(ds), nil
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery *store.WebhookDelivery) {
+	start := d.now()
+	statusCode, err := d.post(ctx, delivery)
+	end := d.now()
+
+	attempt := &store.WebhookDeliveryAttempt{
+		StatusCode: statusCode,
+		DurationMs: end.Sub(start).Milliseconds(),
+		CreatedAt:  end,
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = store.WebhookDeliverySucceeded
+	case delivery.Attempts >= d.opts.MaxAttempts:
+		delivery.Status = store.WebhookDeliveryDead
+	default:
+		delivery.NextAttemptAt = end.Add(d.backoff(delivery.Attempts))
+	}
+
+	if err != nil {
+		attempt.Error = err.Error()
+		delivery.LastError = attempt.Error
+		d.log(err, "Deliver", delivery)
+	}
+
+	if err = d.ms.RecordWebhookAttempt(ctx, delivery, attempt); err != nil {
+		d.log(err, "RecordWebhookAttempt", delivery)
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery *store.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(events.HeaderEventID, delivery.EventID)
+	req.Header.Set(events.HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(delivery.Subscription.Secret, timestamp, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer res.Body.Close()
+
+	// the body is kept in the logs of failed attempts and drained so that the connection can be reused
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorLength))
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("%w %d: %s", events.ErrUnexpectedStatus, res.StatusCode, resBody)
+	}
+
+	return res.StatusCode, nil
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.opts.Backoff
+
+	for i := 1; i < attempts && backoff < d.opts.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, d.opts.MaxBackoff)
+}
+
+func (d *Dispatcher) log(err error, action string, delivery *store.WebhookDelivery) {
+	logParams := []interface{}{"action", action}
+
+	if delivery != nil {
+		logParams = append(logParams,
+			"delivery_id", delivery.ID,
+			"subscription_id", delivery.SubscriptionID,
+			"event_id", delivery.EventID,
+			"attempts", delivery.Attempts,
+		)
+	}
+
+	logParams = append(logParams, "error", err.Error())
+
+	_ = level.Error(d.l).Log(logParams...)
+}
+
+// Sign returns the signature header value of the body sent at timestamp, the hex encoded HMAC-SHA256 of
+// "timestamp.body" keyed with the secret of the subscription
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
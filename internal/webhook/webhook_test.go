@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"hotel-california-backend/internal/events"
+	storemock "hotel-california-backend/internal/mock/store"
+	"hotel-california-backend/internal/store"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	assert.Equal(t,
+		"sha256=0daeb644c3d431859368c31760c2c281159db297302f27e9f76cd5ad5c739a37",
+		Sign("s3cr3t", "1700000000", []byte(`{"id":"2f1c8a3e"}`)),
+	)
+}
+
+func TestDispatcher_Handle(t *testing.T) {
+	ctx := context.Background()
+	data, err := json.Marshal(events.ReservationData{PNR: "HC7K2M9Q", UserID: 7})
+	require.NoError(t, err)
+
+	e := events.Event{ID: "2f1c8a3e", Type: events.ReservationCreated, AggregateID: "HC7K2M9Q", Data: data}
+
+	ms := storemock.NewStore()
+	ms.On("FindWebhookSubscriptions", ctx).Return([]*store.WebhookSubscription{
+		{ID: 1, EventTypes: events.ReservationCreated + "," + events.ReservationCancelled},
+		{ID: 2, EventTypes: events.ReservationUpdated},
+		{ID: 3, EventTypes: events.ReservationCreated, UserID: 8},
+		{ID: 4, EventTypes: events.ReservationCreated, UserID: 7},
+	}, nil)
+	ms.On("CreateWebhookDelivery", ctx, mock.Anything).Return(nil)
+
+	require.NoError(t, NewDispatcher(log.NewNopLogger(), ms, Options{}).Handle(ctx, e))
+
+	var subscriptionIDs []int64
+	for _, call := range ms.Calls {
+		if call.Method == "CreateWebhookDelivery" {
+			subscriptionIDs = append(subscriptionIDs, call.Arguments.Get(1).(*store.WebhookDelivery).SubscriptionID)
+		}
+	}
+
+	assert.Equal(t, []int64{1, 4}, subscriptionIDs)
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		status     int
+		attempts   int
+		wantStatus string
+		wantNext   time.Time
+	}{
+		{name: "Succeeded", status: http.StatusNoContent, wantStatus: store.WebhookDeliverySucceeded},
+		{name: "FirstRetry", status: http.StatusInternalServerError, wantStatus: store.WebhookDeliveryPending, wantNext: now.Add(30 * time.Second)},
+		{name: "BackoffDoubles", status: http.StatusInternalServerError, attempts: 2, wantStatus: store.WebhookDeliveryPending, wantNext: now.Add(2 * time.Minute)},
+		{name: "BackoffCapped", status: http.StatusInternalServerError, attempts: 6, wantStatus: store.WebhookDeliveryPending, wantNext: now.Add(10 * time.Minute)},
+		{name: "Dead", status: http.StatusInternalServerError, attempts: 7, wantStatus: store.WebhookDeliveryDead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			payload := `{"id":"2f1c8a3e"}`
+
+			var header http.Header
+			var body []byte
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			delivery := &store.WebhookDelivery{
+				ID:             1,
+				SubscriptionID: 1,
+				Subscription:   store.WebhookSubscription{ID: 1, URL: srv.URL, Secret: "s3cr3t"},
+				EventID:        "2f1c8a3e",
+				EventType:      events.ReservationCreated,
+				Payload:        payload,
+				Status:         store.WebhookDeliveryPending,
+				Attempts:       tt.attempts,
+				NextAttemptAt:  now,
+			}
+
+			ms := storemock.NewStore()
+			ms.On("DueWebhookDeliveries", ctx, now, 10).Return([]*store.WebhookDelivery{delivery}, nil)
+			ms.On("ClaimWebhookDelivery", ctx, delivery, now.Add(20*time.Second)).Return(true, nil)
+			ms.On("RecordWebhookAttempt", ctx, delivery, mock.Anything).Return(nil)
+
+			d := NewDispatcher(log.NewNopLogger(), ms, Options{
+				BatchSize:   10,
+				MaxAttempts: 8,
+				MaxBackoff:  10 * time.Minute,
+			})
+			d.now = func() time.Time { return now }
+
+			n, err := d.Deliver(ctx)
+
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Equal(t, payload, string(body))
+			assert.Equal(t, "1709294400", header.Get(HeaderTimestamp))
+			assert.Equal(t, Sign("s3cr3t", "1709294400", body), header.Get(HeaderSignature))
+			assert.Equal(t, "2f1c8a3e", header.Get(events.HeaderEventID))
+			assert.Equal(t, tt.wantStatus, delivery.Status)
+			assert.Equal(t, tt.attempts+1, delivery.Attempts)
+			assert.Equal(t, tt.status, delivery.LastStatusCode)
+
+			if !tt.wantNext.IsZero() {
+				assert.Equal(t, tt.wantNext, delivery.NextAttemptAt)
+			}
+
+			ms.AssertExpectations(t)
+		})
+	}
+}